@@ -0,0 +1,199 @@
+package i18n
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LoadAndroid loads Android string resources (strings.xml) of locale to db, eg:
+//
+//	<resources>
+//	  <string name="welcome">Hello, %1$s!</string>
+//	  <plurals name="files">
+//	    <item quantity="one">%d file</item>
+//	    <item quantity="other">%d files</item>
+//	  </plurals>
+//	  <string-array name="planets">
+//	    <item>Mercury</item>
+//	  </string-array>
+//	</resources>
+//
+// Key of translation is locale and resource name: "en.welcome", items of string array get their indexes: "en.planets.0".
+// Plurals fold to single translation with category keywords: "{one} !1 file|{other} !1 files".
+//
+// Format specifiers convert to placeholders "!1", "!2", ... (see PlaceholderReplacer), except strings with attribute
+// formatted="false". Android escapes and quotes are processed, inline markup is dropped.
+//
+// All translations save in single transaction, so nothing saves if file is malformed.
+func LoadAndroid(db *DB, locale string, r io.Reader) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	var res androidResources
+	if err := xml.NewDecoder(r).Decode(&res); err != nil {
+		return err
+	}
+	if res.XMLName.Local != "resources" {
+		return ErrBadAndroid
+	}
+	tx := db.Begin()
+	l := androidLoader{tx: tx, prefix: locale + "."}
+	for i := 0; i < len(res.Items); i++ {
+		if err := l.load(&res.Items[i]); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+type androidResources struct {
+	XMLName xml.Name
+	Items   []androidRes `xml:",any"`
+}
+
+type androidRes struct {
+	XMLName   xml.Name
+	Name      string        `xml:"name,attr"`
+	Formatted string        `xml:"formatted,attr"`
+	Inner     string        `xml:",innerxml"`
+	Items     []androidItem `xml:"item"`
+}
+
+type androidItem struct {
+	Quantity string `xml:"quantity,attr"`
+	Inner    string `xml:",innerxml"`
+}
+
+// Loader of Android resources to transaction.
+type androidLoader struct {
+	tx     *Txn
+	prefix string
+	forms  []catForm
+	buf    []byte
+}
+
+func (l *androidLoader) load(res *androidRes) error {
+	kind := res.XMLName.Local
+	if kind != "string" && kind != "plurals" && kind != "string-array" {
+		// Other resources (integers, colors, etc) are skipped.
+		return nil
+	}
+	if len(res.Name) == 0 {
+		return ErrBadAndroid
+	}
+	key := l.prefix + res.Name
+	format := res.Formatted != "false"
+	switch kind {
+	case "string":
+		t9n, err := l.text(res.Inner, format)
+		if err != nil {
+			return err
+		}
+		l.buf = escT9n(l.buf[:0], t9n)
+		return l.tx.Set(key, string(l.buf))
+	case "plurals":
+		l.forms = l.forms[:0]
+		for i := 0; i < len(res.Items); i++ {
+			item := &res.Items[i]
+			cat, ok := ParsePluralCategory(item.Quantity)
+			if !ok {
+				return ErrBadAndroid
+			}
+			t9n, err := l.text(item.Inner, format)
+			if err != nil {
+				return err
+			}
+			l.forms = append(l.forms, catForm{cat: cat, t9n: t9n})
+		}
+		if len(l.forms) == 0 {
+			return nil
+		}
+		sortCatForms(l.forms)
+		l.buf = catT9n(l.buf[:0], l.forms)
+		return l.tx.Set(key, string(l.buf))
+	default:
+		for i := 0; i < len(res.Items); i++ {
+			t9n, err := l.text(res.Items[i].Inner, format)
+			if err != nil {
+				return err
+			}
+			l.buf = escT9n(l.buf[:0], t9n)
+			if err = l.tx.Set(key+"."+strconv.Itoa(i), string(l.buf)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Get text of resource with processed escapes and converted format specifiers.
+func (l *androidLoader) text(inner string, format bool) (string, error) {
+	s, err := xmlPlain(inner)
+	if err != nil {
+		return "", err
+	}
+	if s, err = androidUnescape(s); err != nil {
+		return "", err
+	}
+	if !format {
+		return s, nil
+	}
+	l.buf, _ = printfPlaceholders(l.buf[:0], s, 1)
+	return string(l.buf), nil
+}
+
+// Process Android string escapes and quotes: whitespace collapses outside of double quotes, quotes are removed.
+func androidUnescape(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if strings.IndexAny(s, "\\\"\n\t\r ") == -1 {
+		return s, nil
+	}
+	buf := make([]byte, 0, len(s))
+	var quoted, space bool
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+			continue
+		case !quoted && (c == ' ' || c == '\n' || c == '\t' || c == '\r'):
+			space = true
+			continue
+		}
+		if space {
+			buf = append(buf, ' ')
+			space = false
+		}
+		if c != '\\' {
+			buf = append(buf, c)
+			continue
+		}
+		if i++; i == len(s) {
+			return "", ErrBadAndroid
+		}
+		switch c = s[i]; c {
+		case 'n':
+			buf = append(buf, '\n')
+		case 't':
+			buf = append(buf, '\t')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", ErrBadAndroid
+			}
+			n, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", ErrBadAndroid
+			}
+			buf = utf8.AppendRune(buf, rune(n))
+			i += 4
+		default:
+			// Escaped quotes, apostrophe, backslash, "@" and "?".
+			buf = append(buf, c)
+		}
+	}
+	return string(buf), nil
+}
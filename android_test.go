@@ -0,0 +1,89 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+const testAndroid = `<?xml version="1.0" encoding="utf-8"?>
+<resources xmlns:tools="http://schemas.android.com/tools">
+    <string name="app_name" translatable="false">Files</string>
+    <string name="welcome">Hello, %1$s! You have %2$d new messages.</string>
+    <string name="quoted">"  spaced   text "  and   \"escapes\" A\n</string>
+    <string name="apostrophe">Don\'t &amp; <b>won\'t</b></string>
+    <string name="percent" formatted="false">100% %s</string>
+    <string name="pipe">a|b</string>
+    <integer name="max">10</integer>
+    <plurals name="files">
+        <item quantity="one">%d файл</item>
+        <item quantity="few">%d файла</item>
+        <item quantity="many">%d файлов</item>
+        <item quantity="other">%d файла</item>
+    </plurals>
+    <string-array name="planets">
+        <item>Mercury</item>
+        <item>Venus</item>
+    </string-array>
+</resources>`
+
+func TestAndroid(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	if err := LoadAndroid(db, "ru", strings.NewReader(testAndroid)); err != nil {
+		t.Fatal(err)
+	}
+	assertT9n(t, db, "ru.app_name", "Files")
+	assertT9n(t, db, "ru.welcome", "Hello, !1! You have !2 new messages.")
+	assertT9n(t, db, "ru.quoted", "  spaced   text  and \"escapes\" A\n")
+	assertT9n(t, db, "ru.apostrophe", "Don't & won't")
+	assertT9n(t, db, "ru.percent", "100% %s")
+	assertT9n(t, db, "ru.pipe", "a|b")
+	assertT9n(t, db, "ru.max", "")
+	assertT9n(t, db, "ru.planets.0", "Mercury")
+	assertT9n(t, db, "ru.planets.1", "Venus")
+	assertT9nPlural(t, db, "ru.files", "!1 файл", 21)
+	assertT9nPlural(t, db, "ru.files", "!1 файла", 3)
+	assertT9nPlural(t, db, "ru.files", "!1 файлов", 5)
+
+	repl := PlaceholderReplacer{}
+	repl.AddKV("!1", "John").AddKV("!2", "5")
+	if s := db.GetWR("ru.welcome", "", &repl); s != "Hello, John! You have 5 new messages." {
+		t.Errorf("replace mismatch, got %q", s)
+	}
+
+	t.Run("placeholders", func(t *testing.T) {
+		for _, c := range []struct{ src, expect string }{
+			{"%s and %d", "!1 and !2"},
+			{"%2$s before %1$s", "!2 before !1"},
+			{"%@ has %lld items", "!1 has !2 items"},
+			{"%.2f%% done", "!1% done"},
+			{"%-10s|%05d", "!1|!2"},
+			{"line%nbreak", "line\nbreak"},
+			{"50% off", "50% off"},
+			{"trailing %", "trailing %"},
+		} {
+			buf, _ := printfPlaceholders(nil, c.src, 1)
+			if string(buf) != c.expect {
+				t.Errorf("%q: need %q got %q", c.src, c.expect, buf)
+			}
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, src := range []string{
+			`<resources><string name="a">x</string><string>y</string></resources>`,
+			`<resources><string name="a">x</string><plurals name="b"><item quantity="lot">y</item></plurals></resources>`,
+			`<resources><string name="a">x</string><string name="b">\u12</string></resources>`,
+			`<resources><string name="a">x</string>`,
+			`<strings><string name="a">x</string></strings>`,
+		} {
+			db, _ := New(xxhash.Hasher64[string]{})
+			if err := LoadAndroid(db, "en", strings.NewReader(src)); err == nil {
+				t.Errorf("%s: error expected", src)
+			}
+			if db.Get("en.a", "") != "" {
+				t.Errorf("%s: translations saved despite error", src)
+			}
+		}
+	})
+}
@@ -0,0 +1,288 @@
+package i18n
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ARBSidecar keeps ARB metadata which DB doesn't store, so it may survive import and export.
+//
+// Metadata keeps as raw JSON, sidecar may be saved in any format, eg JSON.
+type ARBSidecar struct {
+	// Global attributes except "@@locale" by name, eg: "@@last_modified".
+	Global map[string]json.RawMessage
+	// Metadata objects of messages by key without "@", eg: "welcome" for "@welcome".
+	Meta map[string]json.RawMessage
+}
+
+// LoadARB loads Flutter Application Resource Bundle (ARB) file to db, eg:
+//
+//	{
+//	  "@@locale": "en",
+//	  "welcome": "Hello, {name}!",
+//	  "@welcome": {"description": "Greeting", "placeholders": {"name": {"type": "String"}}},
+//	  "files": "{count, plural, =0{No files} one{One file} other{{count} files}}"
+//	}
+//
+// Key of translation is locale and message key: "en.welcome". Locale is taken from attribute "@@locale" if empty.
+// Messages are ICU messages (see DB.SetICU()) and render by GetICU() with Args, messages without arguments save as
+// regular translations. Metadata "@key" and global "@@" attributes are saved to sidecar sc if it isn't nil.
+//
+// All messages save in single transaction, so nothing saves if file is malformed.
+func LoadARB(db *DB, locale string, r io.Reader, sc *ARBSidecar) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	var m map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return err
+	}
+	if len(locale) == 0 {
+		if raw, ok := m["@@locale"]; !ok || json.Unmarshal(raw, &locale) != nil || len(locale) == 0 {
+			return ErrBadARB
+		}
+	}
+	if sc != nil {
+		if sc.Global == nil {
+			sc.Global = make(map[string]json.RawMessage)
+		}
+		if sc.Meta == nil {
+			sc.Meta = make(map[string]json.RawMessage)
+		}
+	}
+
+	tx := db.Begin()
+	var buf, text []byte
+	for key, raw := range m {
+		switch {
+		case strings.HasPrefix(key, "@@"):
+			if sc != nil && key != "@@locale" {
+				sc.Global[key] = raw
+			}
+			continue
+		case strings.HasPrefix(key, "@"):
+			if sc != nil {
+				sc.Meta[key[1:]] = raw
+			}
+			continue
+		}
+		var msg string
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			tx.Rollback()
+			return ErrBadARB
+		}
+		var err error
+		if isICUDynamic(msg) {
+			err = tx.SetICU(locale+"."+key, msg)
+		} else {
+			text = appendICUText(text[:0], msg, false)
+			buf = escT9n(buf[:0], string(text))
+			err = tx.Set(locale+"."+key, string(buf))
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DumpARB writes translations of locale to w as ARB file.
+//
+// ICU messages write as is, regular translations write with quoted ICU special chars. Plural translations which can be
+// expressed by plural categories write as ICU plural of argument "count" and select forms write as ICU select of
+// argument "select", eg:
+//
+//	"{count, plural, =0{No files} one{One file} other{Many files}}"
+//	"{select, select, male{He} female{She} other{They}}"
+//
+// Translations with ranges or plural forms which don't match categories of locale can't be expressed in ICU and return
+// ErrUnrepresentable. Contextual keys aren't representable in ARB and skip. Global attributes and metadata are taken
+// from sidecar sc if it isn't nil.
+func DumpARB(db *DB, locale string, w io.Writer, sc *ARBSidecar) error {
+	if err := db.checkStatus(); err != nil {
+		return err
+	}
+	if len(locale) == 0 {
+		return ErrBadARB
+	}
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString("{\n  \"@@locale\": ")
+	writeJSONString(bw, locale)
+	if sc != nil {
+		names := make([]string, 0, len(sc.Global))
+		for name := range sc.Global {
+			if name != "@@locale" {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			writeARBAttr(bw, name, sc.Global[name])
+		}
+	}
+
+	prefix := locale + "."
+	pr := db.plural(locale)
+	var (
+		forms []catForm
+		msg   []byte
+		err   error
+	)
+	db.snap().eachPrefix(prefix, func(key string, rules []rule, buf []byte) {
+		k, ctx := splitCtx(key)
+		if len(ctx) > 0 || err != nil {
+			return
+		}
+		k = k[len(prefix):]
+		msg = msg[:0]
+		var ok bool
+		switch bare, isBare := bareForms(nil, rules, buf); {
+		case isICU(rules):
+			msg = append(msg, rules[0].bp.take(buf)...)
+		case isBare && len(bare) == 1:
+			msg = quoteICUText(msg, bare[0], false)
+		case hasSelect(rules):
+			if msg, ok = arbSelect(msg, rules, buf, pr); !ok {
+				err = ErrUnrepresentable
+				return
+			}
+		default:
+			if forms, ok = categoryForms(forms[:0], rules, buf, pr); !ok || !hasCategory(forms, PluralOther) {
+				err = ErrUnrepresentable
+				return
+			}
+			msg = arbPlural(msg, forms)
+		}
+		_, _ = bw.WriteString(",\n  ")
+		writeJSONString(bw, k)
+		_, _ = bw.WriteString(": ")
+		writeJSONString(bw, string(msg))
+		if sc != nil {
+			if raw, ok := sc.Meta[k]; ok {
+				writeARBAttr(bw, "@"+k, raw)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	_, _ = bw.WriteString("\n}\n")
+	return bw.Flush()
+}
+
+// Compose ICU plural message of category forms.
+func arbPlural(dst []byte, forms []catForm) []byte {
+	dst = append(dst, "{count, plural,"...)
+	for i := 0; i < len(forms); i++ {
+		f := &forms[i]
+		dst = append(dst, ' ')
+		if f.zero {
+			dst = append(dst, "=0"...)
+		} else {
+			dst = append(dst, f.cat.String()...)
+		}
+		dst = append(dst, '{')
+		dst = quoteICUText(dst, f.t9n, true)
+		dst = append(dst, '}')
+	}
+	return append(dst, '}')
+}
+
+// Compose ICU select message of select forms.
+//
+// Forms without keyword serve as "other" branch. Forms of each keyword must be single form or plural categories,
+// otherwise returns false.
+func arbSelect(dst []byte, rules []rule, buf []byte, pr *pluralRule) ([]byte, bool) {
+	var (
+		kws             []string
+		hasOther, plain bool
+	)
+	for i := 0; i < len(rules); i++ {
+		kw := rules[i].sel(buf)
+		switch {
+		case len(kw) == 0:
+			plain = true
+			continue
+		case kw == "other":
+			hasOther = true
+		}
+		var dup bool
+		for j := 0; j < len(kws) && !dup; j++ {
+			dup = kws[j] == kw
+		}
+		if !dup {
+			kws = append(kws, kw)
+		}
+	}
+	if plain {
+		if hasOther {
+			// Forms without keyword are unreachable in ICU.
+			return dst, false
+		}
+		kws = append(kws, "")
+	}
+
+	var (
+		group []rule
+		forms []catForm
+	)
+	dst = append(dst, "{select, select,"...)
+	for _, kw := range kws {
+		group = group[:0]
+		for i := 0; i < len(rules); i++ {
+			if r := rules[i]; r.inGroup(buf, kw) {
+				// Drop keyword to check forms of group as regular ones.
+				r.arg &= maxSelLen
+				group = append(group, r)
+			}
+		}
+		dst = append(dst, ' ')
+		if len(kw) == 0 {
+			kw = "other"
+		}
+		dst = append(dst, kw...)
+		dst = append(dst, '{')
+		if len(group) == 1 && group[0].kind == ruleBare {
+			dst = quoteICUText(dst, unescT9n(group[0].bp.take(buf)), false)
+		} else {
+			var ok bool
+			if forms, ok = categoryForms(forms[:0], group, buf, pr); !ok || !hasCategory(forms, PluralOther) {
+				return dst, false
+			}
+			dst = arbPlural(dst, forms)
+		}
+		dst = append(dst, '}')
+	}
+	if !hasOther && !plain {
+		dst = append(dst, " other{}"...)
+	}
+	return append(dst, '}'), true
+}
+
+// Check if translation rules contain select keywords.
+func hasSelect(rules []rule) bool {
+	for i := 0; i < len(rules); i++ {
+		if rules[i].arg>>16 != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Write attribute with raw JSON value indented at the first level.
+func writeARBAttr(w *bufio.Writer, name string, raw json.RawMessage) {
+	_, _ = w.WriteString(",\n  ")
+	writeJSONString(w, name)
+	_, _ = w.WriteString(": ")
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "  ", "  "); err != nil {
+		_, _ = w.Write(raw)
+		return
+	}
+	_, _ = w.Write(buf.Bytes())
+}
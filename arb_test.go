@@ -0,0 +1,145 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+const testARB = `{
+  "@@locale": "en",
+  "@@last_modified": "2024-01-01T00:00:00Z",
+  "welcome": "Hello, {name}!",
+  "@welcome": {
+    "description": "Greeting on main page",
+    "placeholders": {"name": {"type": "String", "example": "John"}}
+  },
+  "title": "It's a|b",
+  "braces": "Use '{'braces'}'",
+  "files": "{count, plural, =0{No files} one{One file} other{{count} files}}",
+  "@files": {"placeholders": {"count": {"type": "int"}}},
+  "gender": "{gender, select, male{He} female{She} other{They}}"
+}`
+
+func TestARB(t *testing.T) {
+	var sc ARBSidecar
+	db, _ := New(xxhash.Hasher64[string]{})
+	if err := LoadARB(db, "", strings.NewReader(testARB), &sc); err != nil {
+		t.Fatal(err)
+	}
+	icu := func(key, expect string, args *Args) {
+		t.Helper()
+		if s := db.GetICU(key, "", args); s != expect {
+			t.Errorf("%s: need %q got %q", key, expect, s)
+		}
+	}
+	icu("en.welcome", "Hello, John!", (&Args{}).Str("name", "John"))
+	assertT9n(t, db, "en.title", "It's a|b")
+	assertT9n(t, db, "en.braces", "Use {braces}")
+	icu("en.files", "No files", (&Args{}).Int("count", 0))
+	icu("en.files", "One file", (&Args{}).Int("count", 1))
+	icu("en.files", "7 files", (&Args{}).Int("count", 7))
+	icu("en.gender", "She", (&Args{}).Str("gender", "female"))
+	if len(sc.Global) != 1 || len(sc.Meta) != 2 || sc.Meta["welcome"] == nil || sc.Global["@@last_modified"] == nil {
+		t.Errorf("sidecar mismatch, got %v", sc)
+	}
+
+	t.Run("dump", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := DumpARB(db, "en", &buf, &sc); err != nil {
+			t.Fatal(err)
+		}
+		var a, b map[string]any
+		_ = json.Unmarshal([]byte(testARB), &a)
+		if err := json.Unmarshal(buf.Bytes(), &b); err != nil {
+			t.Fatal(err, buf.String())
+		}
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("dump mismatch, need %v got %v", a, b)
+		}
+
+		raw := func(db *DB, key string) string { return db.snap().getRaw(db.hkey(key)) }
+		db1, _ := New(xxhash.Hasher64[string]{})
+		_ = db1.Set("en.apples", "{0} no apples|{one} one apple|{other} !count apples")
+		_ = db1.Set("en.quote", "Don't '{x}' #1")
+		_ = db1.Set("en.he", "{male} He|{female} She|{other} They")
+		_ = db1.Set("en.cats", "{female} {one} She has a cat|{female} {other} She has cats|They have cats")
+		_ = db1.Set("en.she", "They|{female} She")
+		buf.Reset()
+		if err := DumpARB(db1, "en", &buf, nil); err != nil {
+			t.Fatal(err)
+		}
+		db2, _ := New(xxhash.Hasher64[string]{})
+		if err := LoadARB(db2, "", bytes.NewReader(buf.Bytes()), nil); err != nil {
+			t.Fatal(err, buf.String())
+		}
+		if s := db2.GetICU("en.apples", "", (&Args{}).Int("count", 0)); s != "no apples" {
+			t.Errorf("plural mismatch, got %q", s)
+		}
+		if s := db2.GetICU("en.apples", "", (&Args{}).Int("count", 5)); s != "!count apples" {
+			t.Errorf("plural mismatch, got %q", s)
+		}
+		if a, b := raw(db1, "en.quote"), raw(db2, "en.quote"); a != b {
+			t.Errorf("quote mismatch, need %q got %q", a, b)
+		}
+		// Select forms become ICU select of argument "select".
+		if s := db2.GetICU("en.he", "", (&Args{}).Str("select", "female")); s != "She" {
+			t.Errorf("select mismatch, got %q", s)
+		}
+		if s := db2.GetICU("en.he", "", (&Args{}).Str("select", "robot")); s != "They" {
+			t.Errorf("select mismatch, got %q", s)
+		}
+		// Bare form before select forms serves as "other" branch.
+		if s := db2.GetICU("en.she", "", (&Args{}).Str("select", "female")); s != "She" {
+			t.Errorf("select mismatch, got %q", s)
+		}
+		if s := db2.GetICU("en.she", "", (&Args{}).Str("select", "robot")); s != "They" {
+			t.Errorf("select mismatch, got %q", s)
+		}
+		if s := db2.GetICU("en.cats", "", (&Args{}).Str("select", "female").Int("count", 1)); s != "She has a cat" {
+			t.Errorf("select plural mismatch, got %q", s)
+		}
+		if s := db2.GetICU("en.cats", "", (&Args{}).Str("select", "male").Int("count", 1)); s != "They have cats" {
+			t.Errorf("select plural mismatch, got %q", s)
+		}
+
+		// Ranges and forms which don't match categories aren't representable in ICU.
+		for _, t9n := range []string{
+			"[0,5] few|many",
+			"{male} [0,5] few|{male} many|{other} x",
+			"{male} x|{other} y|z",
+			"one|two|three",
+			"a|{0} b",
+		} {
+			db, _ := New(xxhash.Hasher64[string]{})
+			_ = db.Set("en.a", t9n)
+			if err := DumpARB(db, "en", &bytes.Buffer{}, nil); err != ErrUnrepresentable {
+				t.Errorf("%s: need ErrUnrepresentable got %v", t9n, err)
+			}
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, src := range []string{
+			`{"a": "x"}`,
+			`{"@@locale": "en", "a": "x", "b": 1}`,
+			`{"@@locale": "en", "a": "x", "b": "{n, plural, one{x}}"}`,
+			`{"@@locale": "en", "a": "x", "b": "unclosed {n"}`,
+			`{"@@locale": "en", "a": "x"`,
+		} {
+			db, _ := New(xxhash.Hasher64[string]{})
+			if err := LoadARB(db, "", strings.NewReader(src), nil); err == nil {
+				t.Errorf("%s: error expected", src)
+			}
+			if db.Get("en.a", "") != "" {
+				t.Errorf("%s: translations saved despite error", src)
+			}
+		}
+		if err := DumpARB(db, "", &bytes.Buffer{}, nil); err != ErrBadARB {
+			t.Errorf("need ErrBadARB got %v", err)
+		}
+	})
+}
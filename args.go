@@ -0,0 +1,104 @@
+package i18n
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Args is a storage of ICU message arguments.
+//
+// Args owns its buffers, so it may be reused to avoid allocations. Rendered message is valid until the next use of
+// args.
+type Args struct {
+	kv  []argKV
+	buf []byte
+	// Formatted plural values.
+	num []byte
+	// Render output.
+	out []byte
+}
+
+// Argument name-value pair in args buffer.
+type argKV struct {
+	k, v span
+}
+
+// Str adds string argument.
+func (a *Args) Str(name, value string) *Args {
+	off := len(a.buf)
+	a.buf = append(a.buf, name...)
+	a.buf = append(a.buf, value...)
+	return a.add(off, len(name), len(value))
+}
+
+// Int adds integer argument.
+func (a *Args) Int(name string, value int64) *Args {
+	off := len(a.buf)
+	a.buf = append(a.buf, name...)
+	a.buf = strconv.AppendInt(a.buf, value, 10)
+	return a.add(off, len(name), len(a.buf)-off-len(name))
+}
+
+// Float adds floating point argument using the shortest representation.
+func (a *Args) Float(name string, value float64) *Args {
+	off := len(a.buf)
+	a.buf = append(a.buf, name...)
+	a.buf = strconv.AppendFloat(a.buf, value, 'f', -1, 64)
+	return a.add(off, len(name), len(a.buf)-off-len(name))
+}
+
+// Decimal adds decimal argument given as string, eg: "1.50".
+//
+// Visible fraction digits are significant for plural selection, see DB.GetPluralDecimal().
+func (a *Args) Decimal(name, value string) *Args {
+	return a.Str(name, value)
+}
+
+// Replacer adds all pairs of placeholder replacer as arguments.
+//
+// Leading placeholder markers "!", ":", "%", "$" and "@" trim from keys, so "!count" becomes argument "count". Markers
+// of key format (see PlaceholderReplacer.SetKeyFormat()) the pair was added with trim as well.
+func (a *Args) Replacer(r *PlaceholderReplacer) *Args {
+	if r == nil {
+		return a
+	}
+	for i := 0; i < r.kvl; i++ {
+		kv := &r.kv[i]
+		k, v := kv.k.TakeAddress(r.buf).String(), kv.v.TakeAddress(r.buf).String()
+		k = k[kv.kpl : len(k)-kv.ksl]
+		a.Str(strings.TrimLeft(k, "!:%$@"), v)
+	}
+	return a
+}
+
+// Size returns count of arguments.
+func (a *Args) Size() int {
+	return len(a.kv)
+}
+
+// Reset all internal data.
+func (a *Args) Reset() {
+	a.kv = a.kv[:0]
+	a.buf = a.buf[:0]
+	a.num = a.num[:0]
+	a.out = a.out[:0]
+}
+
+func (a *Args) add(off, nameLen, valueLen int) *Args {
+	var kv argKV
+	kv.k.init(off, nameLen)
+	kv.v.init(off+nameLen, valueLen)
+	a.kv = append(a.kv, kv)
+	return a
+}
+
+// Get value of argument. The last added value wins.
+func (a *Args) get(name string) (string, bool) {
+	for i := len(a.kv) - 1; i >= 0; i-- {
+		kv := &a.kv[i]
+		if kv.k.take(a.buf) == name {
+			return kv.v.take(a.buf), true
+		}
+	}
+	return "", false
+}
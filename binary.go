@@ -0,0 +1,347 @@
+package i18n
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc64"
+	"io"
+	"sort"
+
+	"github.com/koykov/entry"
+)
+
+// Binary dump format.
+//
+// All numbers are little-endian, all sections are 8-byte aligned:
+// * header: magic, version, reserved uint16, hasher fingerprint and lengths of four sections
+// * index: records of hashed key, rules range and key range, sorted by hashed key
+// * rules: rules storage as is (see rule type)
+// * buf: translations storage padded to 8 bytes
+// * kbuf: keys storage padded to 8 bytes
+// * CRC-64 (ECMA) of all previous bytes.
+const (
+	binMagic   = "I18N"
+	binVersion = 4
+
+	binHeaderSize = 48
+	binIndexSize  = 24
+	binRuleSize   = 40
+	binTrailSize  = 8
+
+	// Probe key to identify keys hasher.
+	binHasherProbe = "en.github.com/koykov/i18n"
+)
+
+var crcTable = crc64.MakeTable(crc64.ECMA)
+
+// Binary dump sections.
+type binDump struct {
+	index, rules, buf, kbuf []byte
+	// Actual lengths of buf/kbuf without padding.
+	bufLen, kbufLen int
+}
+
+// WriteTo writes binary dump of DB data to w.
+//
+// Dump contains only live data, so it's always compacted. Writing uses current snapshot and doesn't block writers.
+func (db *DB) WriteTo(w io.Writer) (n int64, err error) {
+	if err = db.checkStatus(); err != nil {
+		return
+	}
+	// Collect sorted records to make dump deterministic.
+	var recs []binRecord
+	db.snap().each(func(hkey uint64, l *snapshot, e entry.Entry64) {
+		recs = append(recs, binRecord{hkey: hkey, l: l, e: e})
+	})
+	sort.Slice(recs, func(i, j int) bool { return recs[i].hkey < recs[j].hkey })
+
+	var nr, nb, nk int
+	for i := 0; i < len(recs); i++ {
+		rec := &recs[i]
+		lo, hi := rec.e.Decode()
+		nr += int(hi - lo)
+		nb += len(rec.raw())
+		nk += len(rec.key())
+	}
+
+	cw := &binWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	var p [binHeaderSize]byte
+	copy(p[:], binMagic)
+	binary.LittleEndian.PutUint16(p[4:], binVersion)
+	binary.LittleEndian.PutUint64(p[8:], db.hkey(binHasherProbe))
+	binary.LittleEndian.PutUint64(p[16:], uint64(len(recs)))
+	binary.LittleEndian.PutUint64(p[24:], uint64(nr))
+	binary.LittleEndian.PutUint64(p[32:], uint64(nb))
+	binary.LittleEndian.PutUint64(p[40:], uint64(nk))
+	_, _ = bw.Write(p[:])
+
+	// Write index with new ranges.
+	var ro, ko uint32
+	for i := 0; i < len(recs); i++ {
+		rec := &recs[i]
+		lo, hi := rec.e.Decode()
+		kl := uint32(len(rec.key()))
+		binary.LittleEndian.PutUint64(p[0:], rec.hkey)
+		binary.LittleEndian.PutUint32(p[8:], ro)
+		binary.LittleEndian.PutUint32(p[12:], ro+hi-lo)
+		binary.LittleEndian.PutUint32(p[16:], ko)
+		binary.LittleEndian.PutUint32(p[20:], ko+kl)
+		_, _ = bw.Write(p[:binIndexSize])
+		ro += hi - lo
+		ko += kl
+	}
+
+	// Write rules with shifted offsets.
+	var bo int
+	for i := 0; i < len(recs); i++ {
+		rec := &recs[i]
+		lo, hi := rec.e.Decode()
+		rules := rec.l.rules[lo:hi]
+		if len(rules) == 0 {
+			continue
+		}
+		rawOff := rules[0].rp.offset()
+		for j := 0; j < len(rules); j++ {
+			r := rules[j]
+			binary.LittleEndian.PutUint64(p[0:], uint64(r.lo))
+			binary.LittleEndian.PutUint64(p[8:], uint64(r.hi))
+			binary.LittleEndian.PutUint32(p[16:], uint32(r.rp.offset()-rawOff+bo))
+			binary.LittleEndian.PutUint32(p[20:], r.rp.ln)
+			binary.LittleEndian.PutUint32(p[24:], uint32(r.bp.offset()-rawOff+bo))
+			binary.LittleEndian.PutUint32(p[28:], r.bp.ln)
+			binary.LittleEndian.PutUint32(p[32:], r.kind)
+			binary.LittleEndian.PutUint32(p[36:], r.arg)
+			_, _ = bw.Write(p[:binRuleSize])
+		}
+		bo += len(rec.raw())
+	}
+
+	// Write storages.
+	for i := 0; i < len(recs); i++ {
+		_, _ = bw.WriteString(recs[i].raw())
+	}
+	_, _ = bw.Write(p[:binPad(nb)])
+	for i := 0; i < len(recs); i++ {
+		_, _ = bw.Write(recs[i].key())
+	}
+	_, _ = bw.Write(p[:binPad(nk)])
+
+	if err = bw.Flush(); err != nil {
+		return cw.n, err
+	}
+	binary.LittleEndian.PutUint64(p[:], cw.crc)
+	_, err = cw.Write(p[:binTrailSize])
+	return cw.n, err
+}
+
+// ReadFrom loads binary dump from r.
+//
+// All existing DB data will be replaced with the dump contents. Dump must be made using the same keys hasher.
+func (db *DB) ReadFrom(r io.Reader) (n int64, err error) {
+	if err = db.checkWrite(); err != nil {
+		return
+	}
+	var data []byte
+	data, err = io.ReadAll(r)
+	n = int64(len(data))
+	if err != nil {
+		return
+	}
+	var d binDump
+	if d, err = db.decodeBin(data); err != nil {
+		return
+	}
+
+	rules := make([]rule, len(d.rules)/binRuleSize)
+	for i := 0; i < len(rules); i++ {
+		rules[i] = decodeBinRule(d.rules[i*binRuleSize:])
+	}
+	buf := append([]byte(nil), d.buf[:d.bufLen]...)
+	kbuf := append([]byte(nil), d.kbuf[:d.kbufLen]...)
+
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	db.index.reset()
+	db.keys.reset()
+	for i := 0; i < len(d.index); i += binIndexSize {
+		hkey, e, ke := decodeBinIndex(d.index[i:])
+		db.index.put(hkey, e)
+		db.keys.put(hkey, ke)
+	}
+	db.rules, db.buf, db.kbuf = rules, buf, kbuf
+	db.prules, db.pbuf = 0, 0
+	db.wbuf, db.wrules, db.wkbuf = 0, 0, 0
+	db.dead = db.dead[:0]
+	db.publishLF()
+	return
+}
+
+// Sorted index section of binary dump.
+type table []byte
+
+// Get entry and key entry of hkey using binary search.
+func (t table) get(hkey uint64) (e, ke entry.Entry64) {
+	n := len(t) / binIndexSize
+	i := sort.Search(n, func(i int) bool {
+		return binary.LittleEndian.Uint64(t[i*binIndexSize:]) >= hkey
+	})
+	if i < n && binary.LittleEndian.Uint64(t[i*binIndexSize:]) == hkey {
+		_, e, ke = decodeBinIndex(t[i*binIndexSize:])
+	}
+	return
+}
+
+// Iterate over all records.
+func (t table) each(fn func(hkey uint64, e, ke entry.Entry64)) {
+	for i := 0; i < len(t); i += binIndexSize {
+		fn(decodeBinIndex(t[i:]))
+	}
+}
+
+// Check binary dump and split it to sections.
+func (db *DB) decodeBin(data []byte) (d binDump, err error) {
+	if len(data) < binHeaderSize+binTrailSize || string(data[:4]) != binMagic {
+		err = ErrBadDump
+		return
+	}
+	if binary.LittleEndian.Uint16(data[4:]) != binVersion {
+		err = ErrDumpVersion
+		return
+	}
+	if binary.LittleEndian.Uint64(data[8:]) != db.hkey(binHasherProbe) {
+		err = ErrHasherMismatch
+		return
+	}
+	ni := binary.LittleEndian.Uint64(data[16:])
+	nr := binary.LittleEndian.Uint64(data[24:])
+	nb := binary.LittleEndian.Uint64(data[32:])
+	nk := binary.LittleEndian.Uint64(data[40:])
+	// Limit sections to avoid overflows, anyway rules and storages addressing is 32-bit.
+	const max32 = 1<<32 - 1
+	if ni > max32 || nr > max32 || nb > max32 || nk > max32 {
+		err = ErrBadDump
+		return
+	}
+	size := binHeaderSize + ni*binIndexSize + nr*binRuleSize + nb + uint64(binPad(int(nb))) + nk +
+		uint64(binPad(int(nk))) + binTrailSize
+	if uint64(len(data)) != size {
+		err = ErrBadDump
+		return
+	}
+	tail := len(data) - binTrailSize
+	if crc64.Checksum(data[:tail], crcTable) != binary.LittleEndian.Uint64(data[tail:]) {
+		err = ErrChecksum
+		return
+	}
+
+	off := binHeaderSize
+	next := func(n int) []byte {
+		p := data[off : off+n : off+n]
+		off += n
+		return p
+	}
+	d.index = next(int(ni) * binIndexSize)
+	d.rules = next(int(nr) * binRuleSize)
+	d.bufLen, d.kbufLen = int(nb), int(nk)
+	d.buf = next(d.bufLen + binPad(d.bufLen))
+	d.kbuf = next(d.kbufLen + binPad(d.kbufLen))
+
+	// Check order and ranges to protect from malformed data.
+	var prev uint64
+	for i := 0; i < len(d.index); i += binIndexSize {
+		hkey, e, ke := decodeBinIndex(d.index[i:])
+		lo, hi := e.Decode()
+		klo, khi := ke.Decode()
+		if (i > 0 && hkey <= prev) || lo >= hi || uint64(hi) > nr || klo > khi || uint64(khi) > nk {
+			err = ErrBadDump
+			return
+		}
+		prev = hkey
+	}
+	for i := 0; i < len(d.rules); i += binRuleSize {
+		r := decodeBinRule(d.rules[i:])
+		if uint64(r.rp.off)+uint64(r.rp.ln) > nb || uint64(r.bp.off)+uint64(r.bp.ln) > nb {
+			err = ErrBadDump
+			return
+		}
+		// Select keyword must fit the rule.
+		if r.kind < ruleICU && r.arg>>16 > 0 && r.arg>>16 >= r.rp.ln {
+			err = ErrBadDump
+			return
+		}
+	}
+	// Nested rules of ICU nodes must fit the message.
+	var nodes []rule
+	for i := 0; i < len(d.index); i += binIndexSize {
+		_, e, _ := decodeBinIndex(d.index[i:])
+		lo, hi := e.Decode()
+		if decodeBinRule(d.rules[int(lo)*binRuleSize:]).kind != ruleICU {
+			continue
+		}
+		nodes = nodes[:0]
+		for j := lo + 1; j < hi; j++ {
+			nodes = append(nodes, decodeBinRule(d.rules[int(j)*binRuleSize:]))
+		}
+		if !checkICUNodes(nodes) {
+			err = ErrBadDump
+			return
+		}
+	}
+	return
+}
+
+// Decode index record.
+func decodeBinIndex(p []byte) (hkey uint64, e, ke entry.Entry64) {
+	_ = p[binIndexSize-1]
+	hkey = binary.LittleEndian.Uint64(p)
+	e.Encode(binary.LittleEndian.Uint32(p[8:]), binary.LittleEndian.Uint32(p[12:]))
+	ke.Encode(binary.LittleEndian.Uint32(p[16:]), binary.LittleEndian.Uint32(p[20:]))
+	return
+}
+
+// Decode rule record.
+func decodeBinRule(p []byte) (r rule) {
+	_ = p[binRuleSize-1]
+	r.lo, r.hi = int64(binary.LittleEndian.Uint64(p)), int64(binary.LittleEndian.Uint64(p[8:]))
+	r.rp.off, r.rp.ln = binary.LittleEndian.Uint32(p[16:]), binary.LittleEndian.Uint32(p[20:])
+	r.bp.off, r.bp.ln = binary.LittleEndian.Uint32(p[24:]), binary.LittleEndian.Uint32(p[28:])
+	r.kind, r.arg = binary.LittleEndian.Uint32(p[32:]), binary.LittleEndian.Uint32(p[36:])
+	return
+}
+
+// Get padding size to align n to 8 bytes.
+func binPad(n int) int {
+	return (8 - n%8) % 8
+}
+
+// Dumping entry of snapshot layer.
+type binRecord struct {
+	hkey uint64
+	l    *snapshot
+	e    entry.Entry64
+}
+
+func (r *binRecord) raw() string {
+	return rawT9n(r.e, r.l.rules, r.l.buf)
+}
+
+func (r *binRecord) key() []byte {
+	ke := r.l.key(r.hkey)
+	lo, hi := ke.Decode()
+	return r.l.kbuf[lo:hi]
+}
+
+// Writer wrapper that counts written bytes and checksum.
+type binWriter struct {
+	w   io.Writer
+	n   int64
+	crc uint64
+}
+
+func (w *binWriter) Write(p []byte) (n int, err error) {
+	n, err = w.w.Write(p)
+	w.crc = crc64.Update(w.crc, crcTable, p[:n])
+	w.n += int64(n)
+	return
+}
@@ -0,0 +1,92 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc64"
+	"strconv"
+	"testing"
+
+	"github.com/koykov/hash/fnv"
+	"github.com/koykov/hash/xxhash"
+)
+
+func TestBinary(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	for i := 0; i < 1000; i++ {
+		_ = db.Set("en.key"+strconv.Itoa(i), "Hello there!")
+	}
+	_ = db.Set("en.key42", "foo")
+	_ = db.Set("en.key43", "There is one apple|There are many apples")
+	_ = db.Delete("en.key44")
+
+	var buf bytes.Buffer
+	if _, err := db.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("read", func(t *testing.T) {
+		db1, _ := New(xxhash.Hasher64[string]{})
+		_ = db1.Set("en.garbage", "must be removed")
+		if _, err := db1.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+		assertT9n(t, db1, "en.key0", "Hello there!")
+		assertT9n(t, db1, "en.key42", "foo")
+		assertT9n(t, db1, "en.key44", "")
+		assertT9n(t, db1, "en.garbage", "")
+		assertT9nPlural(t, db1, "en.key43", "There are many apples", 10)
+		if st := db1.Stats(); st.Entries != 999 || st.WastedBytes != 0 {
+			t.Errorf("stats mismatch, got %+v", st)
+		}
+
+		// Loaded DB must be writable and dump must be deterministic.
+		_ = db1.Set("en.key44", "Hello there!")
+		_ = db1.Delete("en.key44")
+		var buf1 bytes.Buffer
+		_, _ = db1.WriteTo(&buf1)
+		if !bytes.Equal(buf.Bytes(), buf1.Bytes()) {
+			t.Error("dumps mismatch")
+		}
+	})
+	t.Run("hasher", func(t *testing.T) {
+		db1, _ := New(fnv.Hasher{})
+		if _, err := db1.ReadFrom(bytes.NewReader(buf.Bytes())); err != ErrHasherMismatch {
+			t.Error("error mismatch, need ErrHasherMismatch got", err)
+		}
+	})
+	t.Run("checksum", func(t *testing.T) {
+		p := append([]byte(nil), buf.Bytes()...)
+		p[len(p)/2] ^= 0xff
+		db1, _ := New(xxhash.Hasher64[string]{})
+		if _, err := db1.ReadFrom(bytes.NewReader(p)); err != ErrChecksum {
+			t.Error("error mismatch, need ErrChecksum got", err)
+		}
+		if _, err := db1.ReadFrom(bytes.NewReader(p[:100])); err != ErrBadDump {
+			t.Error("error mismatch, need ErrBadDump got", err)
+		}
+	})
+	t.Run("icu", func(t *testing.T) {
+		db, _ := New(xxhash.Hasher64[string]{})
+		_ = db.SetICU("en.files", "{n, plural, one {# file} other {# files}}")
+		var buf bytes.Buffer
+		_, _ = db.WriteTo(&buf)
+		p := buf.Bytes()
+		ni := int(binary.LittleEndian.Uint64(p[16:]))
+		nr := int(binary.LittleEndian.Uint64(p[24:]))
+		// Make nested rules of plural node exceed the message and fix the checksum.
+		off := binHeaderSize + ni*binIndexSize
+		for i := 0; i < nr; i++ {
+			r := p[off+i*binRuleSize:]
+			if binary.LittleEndian.Uint32(r[32:]) == ruleICUPlural {
+				binary.LittleEndian.PutUint64(r[8:], 100)
+			}
+		}
+		tail := len(p) - binTrailSize
+		binary.LittleEndian.PutUint64(p[tail:], crc64.Checksum(p[:tail], crcTable))
+		db1, _ := New(xxhash.Hasher64[string]{})
+		if _, err := db1.ReadFrom(bytes.NewReader(p)); err != ErrBadDump {
+			t.Error("error mismatch, need ErrBadDump got", err)
+		}
+	})
+}
@@ -0,0 +1,240 @@
+package i18n
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ChromeSidecar keeps descriptions and placeholders of browser extension messages, so they may survive import and
+// export.
+//
+// Sidecar contains exported fields only and may be saved in any format, eg JSON.
+type ChromeSidecar struct {
+	// Messages data by name, eg: "welcome".
+	Messages map[string]ChromeMessage
+}
+
+// ChromeMessage describes description and placeholders of message.
+type ChromeMessage struct {
+	Description  string                       `json:"description,omitempty"`
+	Placeholders map[string]ChromePlaceholder `json:"placeholders,omitempty"`
+}
+
+// ChromePlaceholder describes named placeholder of message.
+type ChromePlaceholder struct {
+	// Content of placeholder, eg: "$1" or literal text.
+	Content string `json:"content"`
+	Example string `json:"example,omitempty"`
+}
+
+// Message of messages.json file.
+type chromeEntry struct {
+	Message string `json:"message"`
+	ChromeMessage
+}
+
+// LoadChromeMessages loads browser extension messages (_locales/<locale>/messages.json) of locale to db, eg:
+//
+//	{
+//	  "welcome": {
+//	    "message": "Hello, $USER$! Price is $$10.",
+//	    "description": "Greeting",
+//	    "placeholders": {"user": {"content": "$1", "example": "John"}}
+//	  }
+//	}
+//
+// Key of translation is locale and message name: "en.welcome". Named placeholders expand to their contents and
+// substitutions "$1".."$9" convert to placeholders "!1".."!9" (see PlaceholderReplacer), so message above saves as
+// "Hello, !1! Price is $10.". Descriptions and placeholders are saved to sidecar sc if it isn't nil.
+//
+// All messages save in single transaction, so nothing saves if file is malformed.
+func LoadChromeMessages(db *DB, locale string, r io.Reader, sc *ChromeSidecar) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	var m map[string]chromeEntry
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return err
+	}
+	if sc != nil && sc.Messages == nil {
+		sc.Messages = make(map[string]ChromeMessage)
+	}
+
+	tx := db.Begin()
+	var buf, text []byte
+	for name, e := range m {
+		if len(name) == 0 {
+			tx.Rollback()
+			return ErrBadChrome
+		}
+		// Placeholder names are case-insensitive.
+		ph := make(map[string]ChromePlaceholder, len(e.Placeholders))
+		for k, p := range e.Placeholders {
+			ph[strings.ToLower(k)] = p
+		}
+		var err error
+		if text, err = chromeExpand(text[:0], e.Message, ph); err != nil {
+			tx.Rollback()
+			return err
+		}
+		buf = escT9n(buf[:0], string(text))
+		if err = tx.Set(locale+"."+name, string(buf)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if sc != nil && (len(e.Description) > 0 || len(e.Placeholders) > 0) {
+			sc.Messages[name] = e.ChromeMessage
+		}
+	}
+	return tx.Commit()
+}
+
+// DumpChromeMessages writes translations of locale to w as browser extension messages.json.
+//
+// Placeholders "!1".."!9" convert to named placeholders of sidecar sc with content "$1".."$9" if it isn't nil, other
+// placeholders write as substitutions "$1".."$9". Expanded contents of other named placeholders of sidecar convert back
+// to placeholders, eg: "Acme" to "$BRAND$". Plural translations and ICU messages can't be represented in
+// messages.json and write as is, contextual keys skip. Descriptions and placeholders are taken from sidecar.
+func DumpChromeMessages(db *DB, locale string, w io.Writer, sc *ChromeSidecar) error {
+	if err := db.checkStatus(); err != nil {
+		return err
+	}
+	prefix := locale + "."
+	m := make(map[string]chromeEntry)
+	var buf []byte
+	db.snap().eachPrefix(prefix, func(key string, rules []rule, raw []byte) {
+		k, ctx := splitCtx(key)
+		if len(ctx) > 0 {
+			return
+		}
+		name := k[len(prefix):]
+		var e chromeEntry
+		if sc != nil {
+			e.ChromeMessage = sc.Messages[name]
+		}
+		t9n := rawRules(rules, raw)
+		switch bare, ok := bareForms(nil, rules, raw); {
+		case isICU(rules):
+			t9n = rules[0].bp.take(raw)
+		case ok && len(bare) == 1:
+			t9n = bare[0]
+		}
+		buf = chromeCollapse(buf[:0], t9n, e.Placeholders)
+		e.Message = string(buf)
+		m[name] = e
+	})
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// Expand message placeholders: named placeholders "$name$" replace with their contents, substitutions "$1".."$9"
+// convert to "!1".."!9" and "$$" unescapes to "$".
+//
+// Placeholders ph are keyed by lowercase names, nil ph means text is content of placeholder and can't be nested.
+func chromeExpand(dst []byte, s string, ph map[string]ChromePlaceholder) ([]byte, error) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '$' || i+1 == len(s) {
+			dst = append(dst, c)
+			continue
+		}
+		switch n := s[i+1]; {
+		case n == '$':
+			dst = append(dst, '$')
+			i++
+		case n >= '1' && n <= '9':
+			dst = append(dst, '!', n)
+			i++
+		case ph != nil:
+			j := i + 1
+			for j < len(s) && isChromeName(s[j]) {
+				j++
+			}
+			if j == i+1 || j == len(s) || s[j] != '$' {
+				dst = append(dst, c)
+				continue
+			}
+			p, ok := ph[strings.ToLower(s[i+1:j])]
+			if !ok {
+				return dst, ErrBadChrome
+			}
+			dst, _ = chromeExpand(dst, p.Content, nil)
+			i = j
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return dst, nil
+}
+
+// Collapse placeholders "!1".."!9" of translation to named placeholders of ph with substitution content and escape "$".
+//
+// Other placeholders of ph restore from their expanded contents, eg: "Acme" of placeholder with content "Acme".
+func chromeCollapse(dst []byte, s string, ph map[string]ChromePlaceholder) []byte {
+	var (
+		names [10]string
+		lits  []chromeLiteral
+	)
+	if len(ph) > 0 {
+		keys := make([]string, 0, len(ph))
+		for k := range ph {
+			keys = append(keys, k)
+		}
+		// Sort names to get the same placeholder if some have equal contents.
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+		for _, k := range keys {
+			c := ph[k].Content
+			if len(c) == 2 && c[0] == '$' && c[1] >= '1' && c[1] <= '9' {
+				names[c[1]-'0'] = k
+				continue
+			}
+			if text, _ := chromeExpand(nil, c, nil); len(text) > 0 {
+				lits = append(lits, chromeLiteral{name: k, text: string(text)})
+			}
+		}
+		// Longer contents check first to restore placeholders containing others.
+		sort.SliceStable(lits, func(i, j int) bool { return len(lits[i].text) > len(lits[j].text) })
+	}
+loop:
+	for i := 0; i < len(s); i++ {
+		for j := 0; j < len(lits); j++ {
+			if strings.HasPrefix(s[i:], lits[j].text) {
+				dst = append(dst, '$')
+				dst = append(dst, strings.ToUpper(lits[j].name)...)
+				dst = append(dst, '$')
+				i += len(lits[j].text) - 1
+				continue loop
+			}
+		}
+		c := s[i]
+		switch {
+		case c == '$':
+			dst = append(dst, "$$"...)
+		case c == '!' && i+1 < len(s) && s[i+1] >= '1' && s[i+1] <= '9':
+			i++
+			if name := names[s[i]-'0']; len(name) > 0 {
+				dst = append(dst, '$')
+				dst = append(dst, strings.ToUpper(name)...)
+				dst = append(dst, '$')
+			} else {
+				dst = append(dst, '$', s[i])
+			}
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
+
+// Named placeholder and its expanded content.
+type chromeLiteral struct {
+	name, text string
+}
+
+func isChromeName(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '@'
+}
@@ -0,0 +1,125 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+const testChrome = `{
+  "welcome": {
+    "message": "Hello, $USER$! You have $count$ new <b>messages</b>.",
+    "description": "Greeting on popup",
+    "placeholders": {
+      "user": {"content": "$1", "example": "John"},
+      "count": {"content": "$2"}
+    }
+  },
+  "price": {
+    "message": "Price is $$10 in $STORE$",
+    "placeholders": {"store": {"content": "Web Store"}}
+  },
+  "raw": {"message": "$2 of $1"},
+  "name": {"message": "Extension"}
+}`
+
+func TestChromeMessages(t *testing.T) {
+	var sc ChromeSidecar
+	db, _ := New(xxhash.Hasher64[string]{})
+	if err := LoadChromeMessages(db, "en", strings.NewReader(testChrome), &sc); err != nil {
+		t.Fatal(err)
+	}
+	assertT9n(t, db, "en.welcome", "Hello, !1! You have !2 new <b>messages</b>.")
+	assertT9n(t, db, "en.price", "Price is $10 in Web Store")
+	assertT9n(t, db, "en.raw", "!2 of !1")
+	assertT9n(t, db, "en.name", "Extension")
+	if m := sc.Messages["welcome"]; m.Description != "Greeting on popup" || m.Placeholders["user"].Example != "John" {
+		t.Errorf("sidecar mismatch, got %v", sc)
+	}
+	if _, ok := sc.Messages["name"]; ok || len(sc.Messages) != 2 {
+		t.Errorf("sidecar mismatch, got %v", sc)
+	}
+
+	t.Run("dump", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := DumpChromeMessages(db, "en", &buf, &sc); err != nil {
+			t.Fatal(err)
+		}
+		db1, _ := New(xxhash.Hasher64[string]{})
+		var sc1 ChromeSidecar
+		if err := LoadChromeMessages(db1, "en", bytes.NewReader(buf.Bytes()), &sc1); err != nil {
+			t.Fatal(err, buf.String())
+		}
+		for _, key := range []string{"en.welcome", "en.price", "en.raw", "en.name"} {
+			if a, b := db.Get(key, ""), db1.Get(key, ""); a != b {
+				t.Errorf("%s mismatch, need %q got %q", key, a, b)
+			}
+		}
+		if !reflect.DeepEqual(sc, sc1) {
+			t.Errorf("sidecar mismatch, need %v got %v", sc, sc1)
+		}
+		var m map[string]chromeEntry
+		_ = json.Unmarshal(buf.Bytes(), &m)
+		if msg := m["welcome"].Message; msg != "Hello, $USER$! You have $COUNT$ new <b>messages</b>." {
+			t.Errorf("placeholders mismatch, got %q", msg)
+		}
+		if msg := m["price"].Message; msg != "Price is $$10 in $STORE$" {
+			t.Errorf("literal placeholder mismatch, got %q", msg)
+		}
+
+		// Placeholders with mixed content restore as well, the longest content wins.
+		db2, _ := New(xxhash.Hasher64[string]{})
+		sc2 := ChromeSidecar{Messages: map[string]ChromeMessage{"total": {Placeholders: map[string]ChromePlaceholder{
+			"sum":  {Content: "($1 USD)"},
+			"usd":  {Content: "USD"},
+			"shop": {Content: "Acme $$"},
+		}}}}
+		_ = db2.Set("en.total", "Acme $: (!1 USD), USD")
+		buf.Reset()
+		if err := DumpChromeMessages(db2, "en", &buf, &sc2); err != nil {
+			t.Fatal(err)
+		}
+		m = nil
+		_ = json.Unmarshal(buf.Bytes(), &m)
+		if msg := m["total"].Message; msg != "$SHOP$: $SUM$, $USD$" {
+			t.Errorf("literal placeholder mismatch, got %q", msg)
+		}
+
+		// Mixed forms write as is.
+		db3, _ := New(xxhash.Hasher64[string]{})
+		_ = db3.Set("en.apples", "some apples|{0} no apples")
+		_ = db3.Set("en.he", "They|{female} She")
+		buf.Reset()
+		if err := DumpChromeMessages(db3, "en", &buf, nil); err != nil {
+			t.Fatal(err)
+		}
+		m = nil
+		_ = json.Unmarshal(buf.Bytes(), &m)
+		if msg := m["apples"].Message; msg != "some apples|{0} no apples" {
+			t.Errorf("plural mismatch, got %q", msg)
+		}
+		if msg := m["he"].Message; msg != "They|{female} She" {
+			t.Errorf("select mismatch, got %q", msg)
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, src := range []string{
+			`{"a": {"message": "x"}, "b": {"message": "Hi, $USER$"}}`,
+			`{"a": {"message": "x"}, "b": {"message": 1}}`,
+			`{"a": {"message": "x"}, "": {"message": "y"}}`,
+			`{"a": {"message": "x"}`,
+		} {
+			db, _ := New(xxhash.Hasher64[string]{})
+			if err := LoadChromeMessages(db, "en", strings.NewReader(src), nil); err == nil {
+				t.Errorf("%s: error expected", src)
+			}
+			if db.Get("en.a", "") != "" {
+				t.Errorf("%s: translations saved despite error", src)
+			}
+		}
+	})
+}
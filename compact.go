@@ -0,0 +1,155 @@
+package i18n
+
+import "github.com/koykov/entry"
+
+// Stats describes DB storage usage.
+type Stats struct {
+	// Count of translations.
+	Entries int
+	// Count of all and dead rules.
+	Rules, DeadRules int
+	// Live and wasted bytes of translations storage.
+	LiveBytes, WastedBytes int
+	// Live and wasted bytes of keys storage.
+	LiveKeyBytes, WastedKeyBytes int
+}
+
+// WastedRatio returns the fraction of wasted bytes in both storages.
+func (s Stats) WastedRatio() float64 {
+	total := s.LiveBytes + s.WastedBytes + s.LiveKeyBytes + s.WastedKeyBytes
+	if total == 0 {
+		return 0
+	}
+	return float64(s.WastedBytes+s.WastedKeyBytes) / float64(total)
+}
+
+// Stats returns current storage usage.
+func (db *DB) Stats() (s Stats) {
+	if err := db.checkStatus(); err != nil {
+		return
+	}
+	db.mux.Lock()
+	s = db.statsLF()
+	db.mux.Unlock()
+	return
+}
+
+// Lock-free inner stats getter.
+func (db *DB) statsLF() Stats {
+	if db.ro {
+		s := db.snap()
+		return Stats{
+			Entries:      len(s.table) / binIndexSize,
+			Rules:        len(s.rules),
+			LiveBytes:    len(s.buf),
+			LiveKeyBytes: len(s.kbuf),
+		}
+	}
+	return Stats{
+		Entries:        db.index.len(),
+		Rules:          len(db.rules),
+		DeadRules:      db.wrules,
+		LiveBytes:      len(db.buf) - db.wbuf,
+		WastedBytes:    db.wbuf,
+		LiveKeyBytes:   len(db.kbuf) - db.wkbuf,
+		WastedKeyBytes: db.wkbuf,
+	}
+}
+
+// SetCompactThreshold enables auto compaction when wasted ratio (see Stats.WastedRatio()) exceeds threshold.
+//
+// Threshold must be in range (0, 1), other values disable auto compaction.
+func (db *DB) SetCompactThreshold(threshold float64) {
+	if err := db.checkWrite(); err != nil {
+		return
+	}
+	if threshold <= 0 || threshold >= 1 {
+		threshold = 0
+	}
+	db.mux.Lock()
+	db.ctr = threshold
+	db.mux.Unlock()
+}
+
+// Compact rewrites translations and keys storages to keep only live data.
+func (db *DB) Compact() {
+	if err := db.checkWrite(); err != nil {
+		return
+	}
+	db.mux.Lock()
+	db.compactLF()
+	db.publishLF()
+	db.mux.Unlock()
+}
+
+// Check wasted ratio and compact storages if needed.
+func (db *DB) autoCompactLF() {
+	if db.ctr == 0 || db.wbuf+db.wkbuf == 0 {
+		return
+	}
+	if db.statsLF().WastedRatio() > db.ctr {
+		db.compactLF()
+	}
+}
+
+// Mark rule i as dead.
+func (db *DB) markDead(i uint32) {
+	for int(i/64) >= len(db.dead) {
+		db.dead = append(db.dead, 0)
+	}
+	db.dead[i/64] |= 1 << (i % 64)
+}
+
+// Check if rule i is dead.
+func (db *DB) isDead(i uint32) bool {
+	return int(i/64) < len(db.dead) && db.dead[i/64]&(1<<(i%64)) != 0
+}
+
+// Lock-free inner compaction.
+func (db *DB) compactLF() {
+	if db.wbuf == 0 && db.wrules == 0 && db.wkbuf == 0 {
+		return
+	}
+
+	buf := make([]byte, 0, len(db.buf)-db.wbuf)
+	rules := make([]rule, 0, len(db.rules)-db.wrules)
+	db.index.each(func(hkey uint64, e entry.Entry64) {
+		if e == entryTomb {
+			return
+		}
+		lo, hi := e.Decode()
+		rs := db.rules[lo:hi]
+		if len(rs) == 0 {
+			return
+		}
+		// Copy raw translation and shift offsets of its rules.
+		_ = rs[len(rs)-1]
+		rawOff, rawLen := rs[0].rp.offset(), 0
+		for i := 0; i < len(rs); i++ {
+			rawLen += rs[i].rp.len()
+		}
+		off := len(buf)
+		buf = append(buf, db.buf[rawOff:rawOff+rawLen]...)
+		nlo := len(rules)
+		for i := 0; i < len(rs); i++ {
+			r := rs[i]
+			r.rp.off = uint32(r.rp.offset() - rawOff + off)
+			r.bp.off = uint32(r.bp.offset() - rawOff + off)
+			rules = append(rules, r)
+		}
+		db.index.set(hkey, uint32(nlo), uint32(len(rules)))
+	})
+
+	kbuf := make([]byte, 0, len(db.kbuf)-db.wkbuf)
+	db.keys.each(func(hkey uint64, e entry.Entry64) {
+		lo, hi := e.Decode()
+		off := len(kbuf)
+		kbuf = append(kbuf, db.kbuf[lo:hi]...)
+		db.keys.set(hkey, uint32(off), uint32(len(kbuf)))
+	})
+
+	db.buf, db.rules, db.kbuf = buf, rules, kbuf
+	db.wbuf, db.wrules, db.wkbuf = 0, 0, 0
+	db.dead = db.dead[:0]
+	db.prules, db.pbuf = 0, 0
+}
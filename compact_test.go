@@ -0,0 +1,56 @@
+package i18n
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/koykov/byteconv"
+	"github.com/koykov/hash/xxhash"
+)
+
+func TestCompact(t *testing.T) {
+	t.Run("manual", func(t *testing.T) {
+		db, _ := New(xxhash.Hasher64[string]{})
+		_ = db.Set("en.key1", "foo")
+		_ = db.Set("en.key2", "There is one apple|There are many apples")
+		_ = db.Set("en.key3", "bar")
+		_ = db.Set("en.key1", "foobar")
+		_ = db.Delete("en.key3")
+
+		st := db.Stats()
+		if st.WastedBytes != 6 || st.DeadRules != 2 || st.WastedKeyBytes != 7 {
+			t.Errorf("stats mismatch, got %+v", st)
+		}
+
+		db.Compact()
+		st = db.Stats()
+		if st.WastedBytes != 0 || st.DeadRules != 0 || st.WastedKeyBytes != 0 {
+			t.Errorf("stats mismatch after compaction, got %+v", st)
+		}
+		if st.Entries != 2 || st.Rules != 3 || st.LiveBytes != 46 || st.LiveKeyBytes != 14 {
+			t.Errorf("stats mismatch after compaction, got %+v", st)
+		}
+		assertT9n(t, db, "en.key1", "foobar")
+		assertT9nPlural(t, db, "en.key2", "There is one apple", 1)
+		assertT9nPlural(t, db, "en.key2", "There are many apples", 5)
+		assertT9n(t, db, "en.key3", "")
+	})
+	t.Run("auto", func(t *testing.T) {
+		db, _ := New(xxhash.Hasher64[string]{})
+		db.SetCompactThreshold(.5)
+		buf := []byte("en.")
+		for i := 0; i < 100; i++ {
+			buf = strconv.AppendInt(buf[:3], int64(i), 10)
+			_ = db.Set(byteconv.B2S(buf), "Hello there!")
+		}
+		for i := 0; i < 100; i++ {
+			buf = strconv.AppendInt(buf[:3], int64(i), 10)
+			_ = db.Set(byteconv.B2S(buf), "Hello there, general Kenobi!")
+		}
+		if r := db.Stats().WastedRatio(); r > .5 {
+			t.Errorf("wasted ratio must be less than threshold, got %f", r)
+		}
+		buf = strconv.AppendInt(buf[:3], 42, 10)
+		assertT9n(t, db, byteconv.B2S(buf), "Hello there, general Kenobi!")
+	})
+}
@@ -0,0 +1,98 @@
+package i18n
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+)
+
+// LoadCSV loads translations table in CSV format to db, eg spreadsheet export:
+//
+//	key,en,ru
+//	welcome,Hello,Привет
+//	files,"{one} file|{other} files","файл|файла|файлов"
+//
+// The first column contains keys, header of other columns defines their locales. Map locales converts headers to
+// locales, eg {"English": "en", "Russian": "ru"}, columns absent in non-nil map (eg comments) are skipped. Nil map means
+// headers are locales. Comma is a field delimiter, zero means ','.
+//
+// Cells keep translations in DB syntax, so plural forms may be set in spreadsheet directly, empty cells are skipped.
+// Rows are streamed into single transaction, so nothing saves if table is malformed. Errors contain line number, see
+// ParseError.
+func LoadCSV(db *DB, r io.Reader, comma rune, locales map[string]string) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	cr := csv.NewReader(r)
+	if comma != 0 {
+		cr.Comma = comma
+	}
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return csvError(err)
+	}
+	if len(header) < 2 {
+		return &ParseError{Line: 1, Err: ErrBadCSV}
+	}
+	// Locale prefixes of columns, empty prefix means skipped column.
+	prefixes := make([]string, len(header))
+	for i := 1; i < len(header); i++ {
+		loc := header[i]
+		if locales != nil {
+			loc = locales[loc]
+		}
+		if len(loc) > 0 {
+			prefixes[i] = loc + "."
+		}
+	}
+
+	tx := db.Begin()
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			tx.Rollback()
+			return csvError(err)
+		}
+		line, _ := cr.FieldPos(0)
+		key := row[0]
+		if len(key) == 0 {
+			empty := true
+			for i := 1; i < len(row) && empty; i++ {
+				empty = len(row[i]) == 0
+			}
+			if empty {
+				// Blank row.
+				continue
+			}
+			tx.Rollback()
+			return &ParseError{Line: line, Err: ErrBadCSV}
+		}
+		for i := 1; i < len(row); i++ {
+			if len(prefixes[i]) == 0 || len(row[i]) == 0 {
+				continue
+			}
+			if err = tx.Set(prefixes[i]+key, row[i]); err != nil {
+				tx.Rollback()
+				line, _ = cr.FieldPos(i)
+				return &ParseError{Line: line, Err: err}
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// Convert CSV parsing error to ParseError.
+func csvError(err error) error {
+	var perr *csv.ParseError
+	if errors.As(err, &perr) {
+		return &ParseError{Line: perr.Line, Err: perr.Err}
+	}
+	if err == io.EOF {
+		return &ParseError{Line: 1, Err: ErrBadCSV}
+	}
+	return err
+}
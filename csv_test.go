@@ -0,0 +1,67 @@
+package i18n
+
+import (
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+const testCSV = `key,English,Russian,Comment
+welcome,Hello,Привет,Main page
+files,"{one} file|{other} files",файл|файла|файлов,
+"multi
+line",first,"первая
+вторая",
+,,,
+later,Later,,Not translated yet
+`
+
+func TestCSV(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	locales := map[string]string{"English": "en", "Russian": "ru"}
+	if err := LoadCSV(db, strings.NewReader(testCSV), 0, locales); err != nil {
+		t.Fatal(err)
+	}
+	assertT9n(t, db, "en.welcome", "Hello")
+	assertT9n(t, db, "ru.welcome", "Привет")
+	assertT9nPlural(t, db, "en.files", "files", 5)
+	assertT9nPlural(t, db, "ru.files", "файла", 3)
+	assertT9n(t, db, "ru.multi\nline", "первая\nвторая")
+	assertT9n(t, db, "en.later", "Later")
+	assertT9n(t, db, "ru.later", "")
+	assertT9n(t, db, "Comment.welcome", "")
+
+	t.Run("headers", func(t *testing.T) {
+		db, _ := New(xxhash.Hasher64[string]{})
+		if err := LoadCSV(db, strings.NewReader("key;de\nopen;Öffnen\n"), ';', nil); err != nil {
+			t.Fatal(err)
+		}
+		assertT9n(t, db, "de.open", "Öffnen")
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, c := range []struct {
+			src  string
+			line int
+			err  error
+		}{
+			{"key,en\na,x\nb,y,z\n", 3, csv.ErrFieldCount},
+			{"key,en\na,x\n\n,y\n", 4, ErrBadCSV},
+			{"key,en\na,x\nb,\"y\n", 3, csv.ErrQuote},
+			{"key,en\na,x\nb,\"[0,99999999999999999999] y\"\n", 3, ErrBadRange},
+			{"key\na\n", 1, ErrBadCSV},
+		} {
+			db, _ := New(xxhash.Hasher64[string]{})
+			err := LoadCSV(db, strings.NewReader(c.src), 0, nil)
+			var perr *ParseError
+			if !errors.As(err, &perr) || perr.Line != c.line || !errors.Is(err, c.err) {
+				t.Errorf("%q: need %v at line %d, got %v", c.src, c.err, c.line, err)
+			}
+			if db.Get("en.a", "") != "" {
+				t.Errorf("%q: translations saved despite error", c.src)
+			}
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package i18n
+
+import "strings"
+
+// Separator of message context in stored keys, the same as gettext uses in MO files.
+//
+// Key with context stores as "en.menu.open\x04verb", so prefix and locale removals cover contextual keys as well.
+const ctxSep = '\x04'
+
+// SetCtx sets translation of key in message context ctx, eg:
+//
+//	db.SetCtx("verb", "en.menu.open", "Open")
+//	db.SetCtx("adjective", "en.door.open", "Opened")
+//
+// Context hashes separately and combines with key hash, so the same key may have different translations in different
+// contexts. Empty context means no context.
+func (db *DB) SetCtx(ctx, key, translation string) error {
+	return db.set(key, ctx, translation)
+}
+
+// DeleteCtx removes translation of key in message context ctx.
+func (db *DB) DeleteCtx(ctx, key string) error {
+	return db.delete(key, ctx)
+}
+
+// GetCtx returns a translation of key in message context ctx.
+//
+// If translation doesn't exist, def will be used instead.
+func (db *DB) GetCtx(ctx, key, def string) string {
+	return db.GetCtxPluralWR(ctx, key, def, 1, nil)
+}
+
+// GetCtxWR returns a translation of key in message context ctx with replacer.
+//
+// See GetWR().
+func (db *DB) GetCtxWR(ctx, key, def string, repl *PlaceholderReplacer) string {
+	return db.GetCtxPluralWR(ctx, key, def, 1, repl)
+}
+
+// GetCtxPlural returns a translation of key in message context ctx using plural formula.
+func (db *DB) GetCtxPlural(ctx, key, def string, count int) string {
+	return db.GetCtxPluralWR(ctx, key, def, count, nil)
+}
+
+// GetCtxPluralWR returns a translation of key in message context ctx using plural formula with replacer.
+//
+// See GetWR().
+func (db *DB) GetCtxPluralWR(ctx, key, def string, count int, repl *PlaceholderReplacer) string {
+	return db.get(key, def, &query{count: int64(count), hctx: db.hctx(ctx)}, repl)
+}
+
+// SetCtx sets translation of key in message context ctx in transaction.
+//
+// See DB.SetCtx().
+func (tx *Txn) SetCtx(ctx, key, translation string) error {
+	return tx.set(key, ctx, translation)
+}
+
+// DeleteCtx removes translation of key in message context ctx in transaction.
+func (tx *Txn) DeleteCtx(ctx, key string) error {
+	return tx.delete(key, ctx)
+}
+
+// GetCtx returns a translation of key in message context ctx.
+//
+// See DB.GetCtx().
+func (l Localizer) GetCtx(ctx, key, def string) string {
+	return l.GetCtxPluralWR(ctx, key, def, 1, nil)
+}
+
+// GetCtxWR returns a translation of key in message context ctx with replacer.
+//
+// See DB.GetWR().
+func (l Localizer) GetCtxWR(ctx, key, def string, repl *PlaceholderReplacer) string {
+	return l.GetCtxPluralWR(ctx, key, def, 1, repl)
+}
+
+// GetCtxPlural returns a translation of key in message context ctx using plural formula.
+func (l Localizer) GetCtxPlural(ctx, key, def string, count int) string {
+	return l.GetCtxPluralWR(ctx, key, def, count, nil)
+}
+
+// GetCtxPluralWR returns a translation of key in message context ctx using plural formula with replacer.
+//
+// See DB.GetWR().
+func (l Localizer) GetCtxPluralWR(ctx, key, def string, count int, repl *PlaceholderReplacer) string {
+	if l.db == nil {
+		return def
+	}
+	return l.get(key, def, &query{count: int64(count), hctx: l.db.hctx(ctx)}, repl)
+}
+
+// Compose stored key of key and context.
+func ctxKey(ctx, key string) string {
+	if len(ctx) == 0 || len(key) == 0 {
+		return key
+	}
+	return key + string(ctxSep) + ctx
+}
+
+// Append stored key of key and context to dst without intermediate string.
+func appendCtxKey(dst []byte, key, ctx string) []byte {
+	dst = append(dst, key...)
+	if len(ctx) > 0 {
+		dst = append(dst, ctxSep)
+		dst = append(dst, ctx...)
+	}
+	return dst
+}
+
+// Split stored key to key and context.
+func splitCtx(key string) (string, string) {
+	if i := strings.IndexByte(key, ctxSep); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return key, ""
+}
+
+// Get hash of message context, zero means no context.
+func (db *DB) hctx(ctx string) uint64 {
+	if len(ctx) == 0 {
+		return 0
+	}
+	return db.hasher.Sum64(ctx)
+}
+
+// Combine hash of key with hash of message context.
+func withCtx(hkey, hctx uint64) uint64 {
+	if hctx == 0 {
+		return hkey
+	}
+	return hcombine(hctx, hkey)
+}
+
+// Get hash of key in message context ctx, the same as hkey() of composed key.
+func (db *DB) hkeyCtx(key, ctx string) uint64 {
+	return withCtx(db.hkey(key), db.hctx(ctx))
+}
+
+// Cut context of key with separator and save its hash to query q.
+func (db *DB) splitCtx(key string, q *query) string {
+	key, ctx := splitCtx(key)
+	if len(ctx) > 0 {
+		q.hctx = db.hctx(ctx)
+	}
+	return key
+}
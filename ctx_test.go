@@ -0,0 +1,87 @@
+package i18n
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+func TestCtx(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	_ = db.Set("de.open", "Offen")
+	_ = db.SetCtx("verb", "de.open", "Öffnen")
+	_ = db.SetCtx("verb", "de.files", "Datei öffnen|Dateien öffnen")
+	_ = db.SetCtx("menu", "global", "Menu")
+
+	assertCtx := func(t *testing.T, db *DB, ctx, key, expect string) {
+		if s := db.GetCtx(ctx, key, "N/D"); s != expect {
+			t.Errorf("%s/%s: need %q got %q", ctx, key, expect, s)
+		}
+	}
+	assertCtx(t, db, "verb", "de.open", "Öffnen")
+	assertCtx(t, db, "", "de.open", "Offen")
+	assertCtx(t, db, "adjective", "de.open", "N/D")
+	assertCtx(t, db, "menu", "global", "Menu")
+	assertT9n(t, db, "global", "")
+	if s := db.Get("de.open\x04verb", ""); s != "Öffnen" {
+		t.Errorf("stored key mismatch, got %q", s)
+	}
+	if s := db.GetCtxPlural("verb", "de.files", "", 2); s != "Dateien öffnen" {
+		t.Errorf("plural mismatch, got %q", s)
+	}
+
+	t.Run("localizer", func(t *testing.T) {
+		db.SetFallback("de-AT", "de")
+		l := db.Localizer("de-AT")
+		if s := l.GetCtx("verb", "open", ""); s != "Öffnen" {
+			t.Errorf("localizer mismatch, got %q", s)
+		}
+		if s := l.GetCtxPlural("verb", "files", "", 1); s != "Datei öffnen" {
+			t.Errorf("localizer plural mismatch, got %q", s)
+		}
+		if s := l.Get("open", ""); s != "Offen" {
+			t.Errorf("localizer mismatch, got %q", s)
+		}
+	})
+	t.Run("txn", func(t *testing.T) {
+		tx := db.Begin()
+		_ = tx.SetCtx("verb", "de.close", "Schließen")
+		_ = tx.DeleteCtx("verb", "de.open")
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		assertCtx(t, db, "verb", "de.close", "Schließen")
+		assertCtx(t, db, "verb", "de.open", "N/D")
+		assertCtx(t, db, "", "de.open", "Offen")
+	})
+	t.Run("dump", func(t *testing.T) {
+		var buf bytes.Buffer
+		if _, err := db.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		db1, _ := New(xxhash.Hasher64[string]{})
+		if _, err := db1.ReadFrom(&buf); err != nil {
+			t.Fatal(err)
+		}
+		assertCtx(t, db1, "verb", "de.close", "Schließen")
+	})
+	t.Run("allocs", func(t *testing.T) {
+		// Contextual key must not compose in a new string on write.
+		_ = db.Set("en.plain", "Plain")
+		_ = db.SetCtx("verb", "en.plain", "Plain")
+		a := testing.AllocsPerRun(100, func() { _ = db.Set("en.plain", "Plain") })
+		b := testing.AllocsPerRun(100, func() { _ = db.SetCtx("verb", "en.plain", "Plain") })
+		c := testing.AllocsPerRun(100, func() { _ = db.DeleteCtx("verb", "en.missing") })
+		d := testing.AllocsPerRun(100, func() { _ = db.Delete("en.missing") })
+		if b > a || c > d {
+			t.Errorf("allocs mismatch, set %v/%v, delete %v/%v", a, b, d, c)
+		}
+	})
+	t.Run("delete", func(t *testing.T) {
+		_ = db.DeleteLocale("de")
+		assertCtx(t, db, "verb", "de.close", "N/D")
+		assertCtx(t, db, "verb", "de.files", "N/D")
+		assertCtx(t, db, "menu", "global", "Menu")
+	})
+}
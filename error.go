@@ -1,8 +1,65 @@
 package i18n
 
-import "errors"
+import (
+	"errors"
+	"strconv"
+)
 
 var (
 	ErrBadDB    = errors.New("cache uninitialized, use New()")
 	ErrNoHasher = errors.New("no hasher provided")
+
+	ErrTxnDone     = errors.New("transaction has already been committed or rolled back")
+	ErrTxnConflict = errors.New("transaction conflicts with concurrent changes")
+
+	ErrBadDump        = errors.New("malformed binary dump")
+	ErrDumpVersion    = errors.New("unsupported binary dump version")
+	ErrHasherMismatch = errors.New("binary dump was made using another hasher")
+	ErrChecksum       = errors.New("binary dump checksum mismatch")
+
+	ErrReadOnly    = errors.New("database is read-only")
+	ErrUnsupported = errors.New("memory mapping isn't supported on this platform")
+	ErrBadRange    = errors.New("plural rule range doesn't fit int64")
+
+	ErrBadPluralForms = errors.New("malformed plural forms expression")
+	ErrBadICU         = errors.New("malformed ICU message")
+
+	ErrBadPO = errors.New("malformed PO file")
+	ErrBadMO = errors.New("malformed MO file")
+
+	ErrBadJSON     = errors.New("malformed JSON translations")
+	ErrBadYAML     = errors.New("malformed or unsupported YAML")
+	ErrKeyConflict = errors.New("key is both translation and group of keys")
+
+	ErrUnrepresentable = errors.New("translation can't be represented in the file format")
+
+	ErrBadXLIFF     = errors.New("malformed XLIFF file")
+	ErrXLIFFVersion = errors.New("unsupported XLIFF version")
+
+	ErrBadAndroid = errors.New("malformed Android string resources")
+	ErrBadStrings = errors.New("malformed strings or stringsdict file")
+
+	ErrBadProperties = errors.New("malformed properties file")
+	ErrBadCSV        = errors.New("malformed CSV translations")
+
+	ErrBadFluent   = errors.New("malformed Fluent resource")
+	ErrFluentFunc  = errors.New("unsupported Fluent function")
+	ErrFluentCycle = errors.New("cyclic reference of Fluent message")
+
+	ErrBadARB    = errors.New("malformed ARB file")
+	ErrBadChrome = errors.New("malformed messages.json file")
 )
+
+// ParseError describes malformed line of translations file.
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return "line " + strconv.Itoa(e.Line) + ": " + e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
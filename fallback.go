@@ -0,0 +1,197 @@
+package i18n
+
+import (
+	"strings"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Max length of locales chain.
+const fallbackDepth = 16
+
+// Locale fallback configuration.
+//
+// Configuration is immutable, any change makes a new copy and publishes it atomically.
+type fallback struct {
+	// Default locale, the last resort of any chain.
+	def string
+	// Explicit chains.
+	chains map[string][]string
+}
+
+// EnableFallback enables locale fallback for lookups.
+//
+// Missing translation of key will be searched in parent locales obtained by truncation of the locale subtags, eg:
+// "zh-Hant-TW.messages.welcome" -> "zh-Hant.messages.welcome" -> "zh.messages.welcome".
+func (db *DB) EnableFallback() {
+	db.setFallback(func(*fallback) {})
+}
+
+// SetDefaultLocale enables locale fallback and sets the locale to check after all others.
+func (db *DB) SetDefaultLocale(locale string) {
+	db.setFallback(func(fb *fallback) {
+		fb.def = locale
+	})
+}
+
+// SetFallback enables locale fallback and sets explicit chain of locale.
+//
+// Explicit chain replaces implicit parent of the locale, eg: SetFallback("es-MX", "es-419") makes chain
+// "es-MX" -> "es-419" -> "es".
+func (db *DB) SetFallback(locale string, chain ...string) {
+	db.setFallback(func(fb *fallback) {
+		fb.chains[locale] = append([]string(nil), chain...)
+	})
+}
+
+// Copy current fallback configuration, apply fn to it and publish.
+func (db *DB) setFallback(fn func(*fallback)) {
+	if err := db.checkStatus(); err != nil {
+		return
+	}
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	fb := &fallback{chains: make(map[string][]string)}
+	if old := db.fallback(); old != nil {
+		fb.def = old.def
+		for k, v := range old.chains {
+			fb.chains[k] = v
+		}
+	}
+	fn(fb)
+	atomic.StorePointer(&db.fb, unsafe.Pointer(fb))
+}
+
+// Load current fallback configuration.
+func (db *DB) fallback() *fallback {
+	return (*fallback)(atomic.LoadPointer(&db.fb))
+}
+
+// Lookup returns raw translation of key using plural formula and the locale of found translation.
+//
+// Unlike Get* methods, Lookup doesn't use default translation and reports if translation was found instead.
+func (db *DB) Lookup(key string, count int) (t9n, locale string, ok bool) {
+	if err := db.checkStatus(); err != nil {
+		return
+	}
+	if len(key) == 0 {
+		return
+	}
+	return db.lookup(key, &query{count: int64(count)})
+}
+
+// Inner lookup considering locale fallback.
+func (db *DB) lookup(key string, q *query) (t9n, locale string, ok bool) {
+	s := db.snap()
+	key = db.splitCtx(key, q)
+	locale, rest := splitKey(key)
+	if len(locale) == 0 {
+		t9n, ok = s.get(withCtx(db.hasher.Sum64(key), q.hctx), q, nil)
+		return
+	}
+	hrest := db.hasher.Sum64(rest)
+	if t9n, ok = s.get(withCtx(hcombine(db.hasher.Sum64(locale), hrest), q.hctx), q, db.plural(locale)); ok {
+		return
+	}
+	fb := db.fallback()
+	if fb == nil || !fb.known(locale) {
+		// The first segment of locale-less key isn't a locale.
+		return "", "", false
+	}
+
+	var a [fallbackDepth]string
+	chain := fb.chain(a[:0], locale)
+	for i := 1; i < len(chain); i++ {
+		if t9n, ok = s.get(withCtx(hcombine(db.hasher.Sum64(chain[i]), hrest), q.hctx), q, db.plural(chain[i])); ok {
+			return t9n, chain[i], true
+		}
+	}
+	return "", "", false
+}
+
+// Check if locale may take part in fallback: it has explicit chain, is default or looks like language tag.
+func (fb *fallback) known(locale string) bool {
+	if _, ok := fb.chains[locale]; ok || locale == fb.def {
+		return true
+	}
+	return isLocaleTag(locale)
+}
+
+// Build chain of locales to check, starting from locale itself.
+func (fb *fallback) chain(dst []string, locale string) []string {
+	dst = fb.expand(dst, locale)
+	if len(fb.def) > 0 {
+		dst = appendLocale(dst, fb.def)
+	}
+	return dst
+}
+
+// Add locale and its parents to dst.
+func (fb *fallback) expand(dst []string, locale string) []string {
+	for len(locale) > 0 && len(dst) < fallbackDepth {
+		n := len(dst)
+		if dst = appendLocale(dst, locale); len(dst) == n {
+			// Locale already in chain.
+			return dst
+		}
+		if chain, ok := fb.chains[locale]; ok {
+			for i := 0; i < len(chain); i++ {
+				dst = fb.expand(dst, chain[i])
+			}
+			return dst
+		}
+		locale = parentLocale(locale)
+	}
+	return dst
+}
+
+// Add locale to dst if it's not present yet.
+func appendLocale(dst []string, locale string) []string {
+	for i := 0; i < len(dst); i++ {
+		if dst[i] == locale {
+			return dst
+		}
+	}
+	if len(dst) == fallbackDepth {
+		return dst
+	}
+	return append(dst, locale)
+}
+
+// Get parent of locale by truncating the last subtag, eg: "zh-Hant-TW" -> "zh-Hant".
+func parentLocale(locale string) string {
+	if i := strings.LastIndexAny(locale, "-_"); i > 0 {
+		return locale[:i]
+	}
+	return ""
+}
+
+// Check if s is well-formed language tag: language of 2-3 letters and alphanumeric subtags of 1-8 chars separated by
+// "-" or "_", eg: "en", "zh-Hant-TW", "sr_Latn".
+func isLocaleTag(s string) bool {
+	// Offset of current subtag.
+	off := 0
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) && s[i] != '-' && s[i] != '_' {
+			c := s[i]
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' && off > 0) {
+				return false
+			}
+			continue
+		}
+		n := i - off
+		if off == 0 && (n < 2 || n > 3) || n < 1 || n > 8 {
+			return false
+		}
+		off = i + 1
+	}
+	return true
+}
+
+// Split key to locale prefix and the rest.
+func splitKey(key string) (locale, rest string) {
+	if i := strings.IndexByte(key, '.'); i > 0 {
+		return key[:i], key[i+1:]
+	}
+	return "", key
+}
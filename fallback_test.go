@@ -0,0 +1,64 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+func TestFallback(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	_ = db.Set("en.messages.welcome", "Hello there!")
+	_ = db.Set("en.messages.bye", "Goodbye!")
+	_ = db.Set("ru.messages.welcome", "Привет!")
+	_ = db.Set("es-419.messages.welcome", "¡Hola!")
+	_ = db.Set("es.messages.bye", "¡Adiós!")
+
+	assertLookup := func(t *testing.T, key, expect, expectLocale string) {
+		t9n, locale, _ := db.Lookup(key, 1)
+		if t9n != expect || locale != expectLocale {
+			t.Errorf("lookup mismatch, need %s/%s got %s/%s", expect, expectLocale, t9n, locale)
+		}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		assertT9n(t, db, "ru-RU.messages.welcome", "")
+		assertLookup(t, "ru.messages.welcome", "Привет!", "ru")
+	})
+
+	db.EnableFallback()
+	t.Run("parent", func(t *testing.T) {
+		assertT9n(t, db, "ru-RU.messages.welcome", "Привет!")
+		assertLookup(t, "ru-Cyrl-RU.messages.welcome", "Привет!", "ru")
+		assertT9n(t, db, "ru-RU.messages.bye", "")
+	})
+
+	db.SetDefaultLocale("en")
+	t.Run("default", func(t *testing.T) {
+		assertLookup(t, "ru-RU.messages.bye", "Goodbye!", "en")
+		assertLookup(t, "de.messages.welcome", "Hello there!", "en")
+		if s := db.Get("de.messages.unknown", "N/D"); s != "N/D" {
+			t.Error("default translation mismatch, need N/D got", s)
+		}
+
+		// Locale-less key doesn't fall back to default locale.
+		_ = db.Set("en.welcome", "Welcome aboard!")
+		assertLookup(t, "messages.welcome", "", "")
+		assertLookup(t, "x.welcome", "", "")
+		for tag, expect := range map[string]bool{
+			"en": true, "zh-Hant-TW": true, "sr_Latn": true, "es-419": true, "de-CH-1996": true,
+			"messages": false, "x": false, "1en": false, "en-": false, "en-toolongtag": false, "e1": false,
+		} {
+			if isLocaleTag(tag) != expect {
+				t.Errorf("%s: locale tag check mismatch, need %t", tag, expect)
+			}
+		}
+	})
+
+	db.SetFallback("es-MX", "es-419")
+	t.Run("explicit", func(t *testing.T) {
+		assertLookup(t, "es-MX.messages.welcome", "¡Hola!", "es-419")
+		assertLookup(t, "es-MX.messages.bye", "¡Adiós!", "es")
+		assertLookup(t, "es-ES.messages.welcome", "Hello there!", "en")
+	})
+}
@@ -0,0 +1,921 @@
+package i18n
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LoadFluent loads Project Fluent resource (.ftl) of locale to db, eg:
+//
+//	-brand = Firefox
+//	welcome = Welcome to { -brand }, { $user }!
+//	emails = { $count ->
+//	    [0] No new emails
+//	    [one] One new email
+//	   *[other] { $count } new emails
+//	}
+//	login = Log in
+//	    .title = Log in to { -brand }
+//
+// Key of translation is locale and message id: "en.welcome", attributes get their names: "en.login.title". Terms and
+// references to other messages of the resource inline on load, so the resource must be loaded at once.
+//
+// Messages without variables save as regular translations. The rest compile to ICU messages (see DB.SetICU()) and
+// render at lookup time by GetICU() with Args: variables become arguments, selectors on numbers and plural categories
+// become "plural" (or "selectordinal" for NUMBER($var, type: "ordinal")), other selectors become "select". Functions
+// NUMBER() and DATETIME() render value of their argument as is.
+//
+// All messages save in single transaction, so nothing saves if resource is malformed. Errors contain line number, see
+// ParseError.
+func LoadFluent(db *DB, locale string, r io.Reader) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	src, err := readUTF(r)
+	if err != nil {
+		return err
+	}
+	p := ftlParser{src: strings.ReplaceAll(src, "\r\n", "\n")}
+	if err = p.parse(); err != nil {
+		return err
+	}
+	c := ftlCompiler{
+		msgs:  make(map[string]*ftlEntry),
+		terms: make(map[string]*ftlEntry),
+		pr:    db.plural(locale),
+	}
+	for i := 0; i < len(p.entries); i++ {
+		e := &p.entries[i]
+		if e.term {
+			c.terms[e.id] = e
+		} else {
+			c.msgs[e.id] = e
+		}
+	}
+
+	tx := db.Begin()
+	var o ftlOut
+	set := func(e *ftlEntry, key string, pat ftlPattern) error {
+		o.reset()
+		if err := c.pattern(&o, pat, nil, false); err != nil {
+			return &ParseError{Line: e.line, Err: err}
+		}
+		key = locale + "." + key
+		if o.dynamic {
+			err = tx.SetICU(key, string(o.icu))
+		} else {
+			o.icu = escT9n(o.icu[:0], string(o.text))
+			err = tx.Set(key, string(o.icu))
+		}
+		if err != nil {
+			return &ParseError{Line: e.line, Err: err}
+		}
+		return nil
+	}
+	for i := 0; i < len(p.entries); i++ {
+		e := &p.entries[i]
+		if e.term {
+			continue
+		}
+		if e.value != nil {
+			if err = set(e, e.id, e.value); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		for j := 0; j < len(e.attrs); j++ {
+			if err = set(e, e.id+"."+e.attrs[j].id, e.attrs[j].value); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// Fluent message or term.
+type ftlEntry struct {
+	id    string
+	term  bool
+	value ftlPattern
+	attrs []ftlAttr
+	line  int
+}
+
+type ftlAttr struct {
+	id    string
+	value ftlPattern
+}
+
+// Pattern is a sequence of text and placeables.
+type ftlPattern []ftlElem
+
+// Text or placeable expression if expr isn't nil.
+type ftlElem struct {
+	text string
+	expr *ftlExpr
+}
+
+// Kinds of expressions.
+const (
+	ftlString = iota
+	ftlNumber
+	ftlVar
+	ftlMsgRef
+	ftlTermRef
+	ftlFunc
+	ftlSelect
+)
+
+// Fluent expression.
+type ftlExpr struct {
+	kind int
+	// Value of literal or name of variable, message, term or function.
+	value string
+	// Attribute of message or term reference.
+	attr string
+	// Call arguments of function or term reference.
+	args  []*ftlExpr
+	named map[string]*ftlExpr
+	// Selector and variants of select expression.
+	sel      *ftlExpr
+	variants []ftlVariant
+}
+
+type ftlVariant struct {
+	key     string
+	numeric bool
+	def     bool
+	value   ftlPattern
+}
+
+// Parser of Fluent resource.
+type ftlParser struct {
+	src     string
+	pos     int
+	entries []ftlEntry
+}
+
+// Make error at current position.
+func (p *ftlParser) error() error {
+	return &ParseError{Line: strings.Count(p.src[:p.pos], "\n") + 1, Err: ErrBadFluent}
+}
+
+func (p *ftlParser) parse() error {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == '#':
+			p.skipLine()
+		case c == '\n':
+			p.pos++
+		case c == ' ':
+			p.skipInline()
+			if p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				return p.error()
+			}
+		case c == '-' || isFtlAlpha(c):
+			if err := p.entry(); err != nil {
+				return err
+			}
+		default:
+			return p.error()
+		}
+	}
+	return nil
+}
+
+// Parse message or term.
+func (p *ftlParser) entry() error {
+	e := ftlEntry{line: strings.Count(p.src[:p.pos], "\n") + 1}
+	if p.src[p.pos] == '-' {
+		e.term = true
+		p.pos++
+	}
+	if e.id = p.ident(); len(e.id) == 0 {
+		return p.error()
+	}
+	p.skipInline()
+	if !p.accept('=') {
+		return p.error()
+	}
+	var err error
+	if e.value, err = p.pattern(); err != nil {
+		return err
+	}
+	for {
+		// Attributes start from indented dot on the next lines.
+		save := p.pos
+		p.skipBlank()
+		if p.pos == len(p.src) || p.src[p.pos] != '.' || p.lineIndent() == 0 {
+			p.pos = save
+			break
+		}
+		p.pos++
+		var a ftlAttr
+		if a.id = p.ident(); len(a.id) == 0 {
+			return p.error()
+		}
+		p.skipInline()
+		if !p.accept('=') {
+			return p.error()
+		}
+		if a.value, err = p.pattern(); err != nil {
+			return err
+		}
+		if a.value == nil {
+			return p.error()
+		}
+		e.attrs = append(e.attrs, a)
+	}
+	if p.pos < len(p.src) && p.src[p.pos] != '\n' {
+		return p.error()
+	}
+	if e.value == nil && (e.term || len(e.attrs) == 0) {
+		return p.error()
+	}
+	p.entries = append(p.entries, e)
+	return nil
+}
+
+// Element of pattern before removing of common indent.
+type ftlRawElem struct {
+	ftlElem
+	// Indent at line start, -1 means regular element.
+	indent int
+}
+
+// Parse pattern after equal sign or variant key. Returns nil if pattern is empty.
+//
+// Pattern ends before newline which isn't followed by indented text line, position stays at the newline.
+func (p *ftlParser) pattern() (ftlPattern, error) {
+	var raw []ftlRawElem
+	p.skipInline()
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case '{':
+			e, err := p.placeable()
+			if err != nil {
+				return nil, err
+			}
+			raw = append(raw, ftlRawElem{ftlElem: ftlElem{expr: e}, indent: -1})
+			continue
+		case '}':
+			// Closing bracket of select expression.
+			return p.dedent(raw), nil
+		case '\n':
+			// Check if the next non-blank line continues the pattern.
+			i, nl := p.pos, 0
+			for {
+				for i < len(p.src) && p.src[i] == '\n' {
+					i++
+					nl++
+				}
+				j := i
+				for j < len(p.src) && p.src[j] == ' ' {
+					j++
+				}
+				if j < len(p.src) && p.src[j] == '\n' {
+					i = j
+					continue
+				}
+				if j == i || j == len(p.src) || strings.IndexByte("[*.}", p.src[j]) != -1 {
+					return p.dedent(raw), nil
+				}
+				raw = append(raw, ftlRawElem{ftlElem: ftlElem{text: strings.Repeat("\n", nl)}, indent: -1})
+				raw = append(raw, ftlRawElem{ftlElem: ftlElem{text: p.src[i:j]}, indent: j - i})
+				p.pos = j
+				break
+			}
+			continue
+		}
+		i := p.pos
+		for i < len(p.src) && p.src[i] != '{' && p.src[i] != '}' && p.src[i] != '\n' {
+			i++
+		}
+		raw = append(raw, ftlRawElem{ftlElem: ftlElem{text: p.src[p.pos:i]}, indent: -1})
+		p.pos = i
+	}
+	return p.dedent(raw), nil
+}
+
+// Remove common indent, leading newlines and trailing blanks of pattern and join its texts.
+func (p *ftlParser) dedent(raw []ftlRawElem) ftlPattern {
+	common := -1
+	for i := 0; i < len(raw); i++ {
+		if n := raw[i].indent; n >= 0 && (common == -1 || n < common) {
+			common = n
+		}
+	}
+	var (
+		pat ftlPattern
+		sb  strings.Builder
+	)
+	flush := func() {
+		if sb.Len() > 0 {
+			pat = append(pat, ftlElem{text: sb.String()})
+			sb.Reset()
+		}
+	}
+	for i := 0; i < len(raw); i++ {
+		e := &raw[i]
+		switch {
+		case e.expr != nil:
+			flush()
+			pat = append(pat, e.ftlElem)
+		case e.indent >= 0:
+			sb.WriteString(e.text[common:])
+		case len(pat) == 0 && sb.Len() == 0 && e.text[0] == '\n':
+			// Leading newlines of block pattern.
+		default:
+			sb.WriteString(e.text)
+		}
+	}
+	flush()
+	if n := len(pat); n > 0 && pat[n-1].expr == nil {
+		if pat[n-1].text = strings.TrimRight(pat[n-1].text, " \n"); len(pat[n-1].text) == 0 {
+			pat = pat[:n-1]
+		}
+	}
+	return pat
+}
+
+// Parse placeable starting with opening bracket.
+func (p *ftlParser) placeable() (*ftlExpr, error) {
+	p.pos++
+	p.skipBlank()
+	e, err := p.inlineExpr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if strings.HasPrefix(p.src[p.pos:], "->") {
+		p.pos += 2
+		if e, err = p.selectExpr(e); err != nil {
+			return nil, err
+		}
+		p.skipBlank()
+	}
+	if !p.accept('}') {
+		return nil, p.error()
+	}
+	return e, nil
+}
+
+// Parse variants of select expression.
+func (p *ftlParser) selectExpr(sel *ftlExpr) (*ftlExpr, error) {
+	if sel.kind == ftlMsgRef || sel.kind == ftlTermRef && len(sel.attr) == 0 || sel.kind == ftlSelect {
+		// Messages and term values can't be selectors.
+		return nil, p.error()
+	}
+	e := &ftlExpr{kind: ftlSelect, sel: sel}
+	start := p.pos
+	var ndef int
+	for {
+		p.skipBlank()
+		if p.pos == len(p.src) || p.src[p.pos] == '}' {
+			break
+		}
+		var v ftlVariant
+		if v.def = p.accept('*'); v.def {
+			ndef++
+		}
+		if !p.accept('[') {
+			return nil, p.error()
+		}
+		p.skipBlank()
+		if p.pos < len(p.src) && (p.src[p.pos] == '-' || isFtlDigit(p.src[p.pos])) {
+			v.key, v.numeric = p.number(), true
+		} else {
+			v.key = p.ident()
+		}
+		p.skipBlank()
+		if len(v.key) == 0 || !p.accept(']') {
+			return nil, p.error()
+		}
+		var err error
+		if v.value, err = p.pattern(); err != nil {
+			return nil, err
+		}
+		e.variants = append(e.variants, v)
+	}
+	if ndef != 1 {
+		// Report the selector line rather than the end of variants.
+		p.pos = start
+		return nil, p.error()
+	}
+	return e, nil
+}
+
+// Parse inline expression.
+func (p *ftlParser) inlineExpr() (*ftlExpr, error) {
+	if p.pos == len(p.src) {
+		return nil, p.error()
+	}
+	switch c := p.src[p.pos]; {
+	case c == '"':
+		s, err := p.stringLit()
+		if err != nil {
+			return nil, err
+		}
+		return &ftlExpr{kind: ftlString, value: s}, nil
+	case isFtlDigit(c) || c == '-' && p.pos+1 < len(p.src) && isFtlDigit(p.src[p.pos+1]):
+		n := p.number()
+		if len(n) == 0 {
+			return nil, p.error()
+		}
+		return &ftlExpr{kind: ftlNumber, value: n}, nil
+	case c == '$':
+		p.pos++
+		id := p.ident()
+		if len(id) == 0 {
+			return nil, p.error()
+		}
+		return &ftlExpr{kind: ftlVar, value: id}, nil
+	case c == '{':
+		return p.placeable()
+	case c == '-':
+		p.pos++
+		e := &ftlExpr{kind: ftlTermRef, value: p.ident()}
+		if len(e.value) == 0 {
+			return nil, p.error()
+		}
+		if p.accept('.') {
+			if e.attr = p.ident(); len(e.attr) == 0 {
+				return nil, p.error()
+			}
+		}
+		if p.peekCall() {
+			if err := p.callArgs(e); err != nil {
+				return nil, err
+			}
+		}
+		return e, nil
+	case isFtlAlpha(c):
+		id := p.ident()
+		if p.peekCall() {
+			e := &ftlExpr{kind: ftlFunc, value: id}
+			if err := p.callArgs(e); err != nil {
+				return nil, err
+			}
+			return e, nil
+		}
+		e := &ftlExpr{kind: ftlMsgRef, value: id}
+		if p.accept('.') {
+			if e.attr = p.ident(); len(e.attr) == 0 {
+				return nil, p.error()
+			}
+		}
+		return e, nil
+	}
+	return nil, p.error()
+}
+
+// Check if call arguments follow.
+func (p *ftlParser) peekCall() bool {
+	i := p.pos
+	for i < len(p.src) && p.src[i] == ' ' {
+		i++
+	}
+	if i < len(p.src) && p.src[i] == '(' {
+		p.pos = i
+		return true
+	}
+	return false
+}
+
+// Parse call arguments starting with opening parenthesis.
+func (p *ftlParser) callArgs(e *ftlExpr) error {
+	p.pos++
+	for {
+		p.skipBlank()
+		if p.accept(')') {
+			return nil
+		}
+		arg, err := p.inlineExpr()
+		if err != nil {
+			return err
+		}
+		p.skipBlank()
+		if p.accept(':') {
+			// Named argument, value must be literal.
+			if arg.kind != ftlMsgRef || len(arg.attr) > 0 {
+				return p.error()
+			}
+			p.skipBlank()
+			val, err := p.inlineExpr()
+			if err != nil {
+				return err
+			}
+			if val.kind != ftlString && val.kind != ftlNumber {
+				return p.error()
+			}
+			if e.named == nil {
+				e.named = make(map[string]*ftlExpr)
+			}
+			e.named[arg.value] = val
+			p.skipBlank()
+		} else {
+			e.args = append(e.args, arg)
+		}
+		if !p.accept(',') && (p.pos == len(p.src) || p.src[p.pos] != ')') {
+			return p.error()
+		}
+	}
+}
+
+// Parse quoted string literal with escapes.
+func (p *ftlParser) stringLit() (string, error) {
+	p.pos++
+	var buf []byte
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return string(buf), nil
+		case '\n':
+			p.pos--
+			return "", p.error()
+		case '\\':
+			if p.pos == len(p.src) {
+				return "", p.error()
+			}
+			c = p.src[p.pos]
+			p.pos++
+			switch c {
+			case '"', '\\':
+				buf = append(buf, c)
+			case 'u', 'U':
+				n := 4
+				if c == 'U' {
+					n = 6
+				}
+				if p.pos+n > len(p.src) {
+					return "", p.error()
+				}
+				r, err := strconv.ParseUint(p.src[p.pos:p.pos+n], 16, 32)
+				if err != nil {
+					return "", p.error()
+				}
+				buf = utf8.AppendRune(buf, rune(r))
+				p.pos += n
+			default:
+				return "", p.error()
+			}
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return "", p.error()
+}
+
+// Read number literal.
+func (p *ftlParser) number() string {
+	start := p.pos
+	p.accept('-')
+	for p.pos < len(p.src) && isFtlDigit(p.src[p.pos]) {
+		p.pos++
+	}
+	if p.accept('.') {
+		for p.pos < len(p.src) && isFtlDigit(p.src[p.pos]) {
+			p.pos++
+		}
+	}
+	if s := p.src[start:p.pos]; len(s) > 0 && isFtlDigit(s[len(s)-1]) {
+		return s
+	}
+	p.pos = start
+	return ""
+}
+
+// Read identifier: [a-zA-Z][a-zA-Z0-9_-]*.
+func (p *ftlParser) ident() string {
+	start := p.pos
+	if p.pos == len(p.src) || !isFtlAlpha(p.src[p.pos]) {
+		return ""
+	}
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if !isFtlAlpha(c) && !isFtlDigit(c) && c != '_' && c != '-' {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// Get indentation of the line containing current position.
+func (p *ftlParser) lineIndent() int {
+	i := strings.LastIndexByte(p.src[:p.pos], '\n') + 1
+	return p.pos - i
+}
+
+func (p *ftlParser) accept(c byte) bool {
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// Skip spaces.
+func (p *ftlParser) skipInline() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// Skip spaces and newlines.
+func (p *ftlParser) skipBlank() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func (p *ftlParser) skipLine() {
+	if i := strings.IndexByte(p.src[p.pos:], '\n'); i != -1 {
+		p.pos += i + 1
+	} else {
+		p.pos = len(p.src)
+	}
+}
+
+func isFtlAlpha(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isFtlDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Compiler of Fluent patterns to ICU messages.
+type ftlCompiler struct {
+	msgs, terms map[string]*ftlEntry
+	pr          *pluralRule
+	// References being compiled to detect cycles.
+	stack []string
+}
+
+// Compiled pattern: plain text and ICU message, the latter is required if pattern is dynamic.
+type ftlOut struct {
+	text, icu []byte
+	dynamic   bool
+}
+
+func (o *ftlOut) reset() {
+	o.text, o.icu, o.dynamic = o.text[:0], o.icu[:0], false
+}
+
+// Append literal text, plural means text belongs to plural branch where "#" is special.
+func (o *ftlOut) literal(s string, plural bool) {
+	o.text = append(o.text, s...)
+	o.icu = quoteICUText(o.icu, s, plural)
+}
+
+// Append ICU argument.
+func (o *ftlOut) arg(name string) {
+	o.icu = append(o.icu, '{')
+	o.icu = append(o.icu, name...)
+	o.icu = append(o.icu, '}')
+	o.dynamic = true
+}
+
+// Compile pattern to o.
+//
+// Scope isn't nil inside of terms and contains term arguments, plural means pattern belongs to plural branch.
+func (c *ftlCompiler) pattern(o *ftlOut, pat ftlPattern, scope map[string]*ftlExpr, plural bool) error {
+	for i := 0; i < len(pat); i++ {
+		if e := &pat[i]; e.expr == nil {
+			o.literal(e.text, plural)
+		} else if err := c.expr(o, e.expr, scope, plural); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ftlCompiler) expr(o *ftlOut, e *ftlExpr, scope map[string]*ftlExpr, plural bool) error {
+	switch e.kind {
+	case ftlString, ftlNumber:
+		o.literal(e.value, plural)
+	case ftlVar:
+		if scope == nil {
+			o.arg(e.value)
+		} else if v, ok := scope[e.value]; ok {
+			o.literal(v.value, plural)
+		} else {
+			// Terms don't see variables of messages.
+			o.literal("{$"+e.value+"}", plural)
+		}
+	case ftlFunc:
+		if (e.value != "NUMBER" && e.value != "DATETIME") || len(e.args) != 1 {
+			return ErrFluentFunc
+		}
+		return c.expr(o, e.args[0], scope, plural)
+	case ftlMsgRef, ftlTermRef:
+		return c.ref(o, e, plural)
+	case ftlSelect:
+		return c.selectExpr(o, e, scope, plural)
+	}
+	return nil
+}
+
+// Inline referenced message or term.
+func (c *ftlCompiler) ref(o *ftlOut, e *ftlExpr, plural bool) error {
+	id, ent := e.value, c.msgs[e.value]
+	var scope map[string]*ftlExpr
+	if e.kind == ftlTermRef {
+		id, ent = "-"+e.value, c.terms[e.value]
+		scope = e.named
+		if scope == nil {
+			scope = map[string]*ftlExpr{}
+		}
+	}
+	if len(e.attr) > 0 {
+		id += "." + e.attr
+	}
+	pat := c.lookup(ent, e.attr)
+	if pat == nil {
+		// Missing reference renders as is like Fluent does.
+		o.literal("{"+id+"}", plural)
+		return nil
+	}
+	for _, s := range c.stack {
+		if s == id {
+			return ErrFluentCycle
+		}
+	}
+	c.stack = append(c.stack, id)
+	err := c.pattern(o, pat, scope, plural)
+	c.stack = c.stack[:len(c.stack)-1]
+	return err
+}
+
+// Get value or attribute pattern of entry.
+func (c *ftlCompiler) lookup(ent *ftlEntry, attr string) ftlPattern {
+	if ent == nil {
+		return nil
+	}
+	if len(attr) == 0 {
+		return ent.value
+	}
+	for i := 0; i < len(ent.attrs); i++ {
+		if ent.attrs[i].id == attr {
+			return ent.attrs[i].value
+		}
+	}
+	return nil
+}
+
+// Compile select expression: static selectors resolve at once, variables become ICU plural or select arguments.
+func (c *ftlCompiler) selectExpr(o *ftlOut, e *ftlExpr, scope map[string]*ftlExpr, plural bool) error {
+	sel, typ := e.sel, "plural"
+	if sel.kind == ftlFunc {
+		if sel.value != "NUMBER" || len(sel.args) != 1 {
+			return ErrFluentFunc
+		}
+		if t, ok := sel.named["type"]; ok && t.value == "ordinal" {
+			typ = "selectordinal"
+		}
+		sel = sel.args[0]
+	}
+	if sel.kind == ftlVar && scope == nil {
+		return c.dynSelect(o, e, sel.value, typ)
+	}
+
+	// Static selector.
+	var (
+		val string
+		ok  bool
+	)
+	switch sel.kind {
+	case ftlString, ftlNumber:
+		val, ok = sel.value, true
+	case ftlVar:
+		var v *ftlExpr
+		if v, ok = scope[sel.value]; ok {
+			val = v.value
+		}
+	case ftlTermRef:
+		var t ftlOut
+		if err := c.ref(&t, sel, false); err != nil {
+			return err
+		}
+		val, ok = string(t.text), !t.dynamic
+	}
+	v := ftlDefault(e.variants)
+	if ok {
+		v = ftlMatch(e.variants, val, c.pr, typ == "selectordinal")
+	}
+	return c.pattern(o, v.value, scope, plural)
+}
+
+// Compile select on variable to ICU argument.
+func (c *ftlCompiler) dynSelect(o *ftlOut, e *ftlExpr, name, typ string) error {
+	// Selector is plural if keys are integers or plural categories.
+	for i := 0; i < len(e.variants) && typ != "select"; i++ {
+		v := &e.variants[i]
+		if _, err := strconv.ParseInt(v.key, 10, 64); v.numeric && err != nil {
+			typ = "select"
+		} else if _, ok := ParsePluralCategory(v.key); !v.numeric && !ok {
+			typ = "select"
+		}
+	}
+	o.dynamic = true
+	o.icu = append(o.icu, '{')
+	o.icu = append(o.icu, name...)
+	o.icu = append(o.icu, ", "...)
+	o.icu = append(o.icu, typ...)
+	o.icu = append(o.icu, ',')
+	sub := typ != "select"
+	variant := func(key string, v *ftlVariant) error {
+		o.icu = append(o.icu, ' ')
+		if sub && v.numeric && key == v.key {
+			o.icu = append(o.icu, '=')
+		}
+		o.icu = append(o.icu, key...)
+		o.icu = append(o.icu, " {"...)
+		if err := c.pattern(o, v.value, nil, sub); err != nil {
+			return err
+		}
+		o.icu = append(o.icu, '}')
+		return nil
+	}
+	for i := 0; i < len(e.variants); i++ {
+		if err := variant(e.variants[i].key, &e.variants[i]); err != nil {
+			return err
+		}
+	}
+	// Default variant serves all missing categories, ICU falls back to "other" only.
+	def := ftlDefault(e.variants)
+	if def.key != "other" || def.numeric {
+		for cat := PluralZero; cat <= PluralOther; cat++ {
+			if !sub && cat != PluralOther {
+				continue
+			}
+			if ftlHasKey(e.variants, cat.String()) {
+				continue
+			}
+			if err := variant(cat.String(), def); err != nil {
+				return err
+			}
+		}
+	}
+	o.icu = append(o.icu, '}')
+	return nil
+}
+
+// Get default variant.
+func ftlDefault(variants []ftlVariant) *ftlVariant {
+	for i := 0; i < len(variants); i++ {
+		if variants[i].def {
+			return &variants[i]
+		}
+	}
+	return &variants[0]
+}
+
+// Check if variants contain non-numeric key.
+func ftlHasKey(variants []ftlVariant, key string) bool {
+	for i := 0; i < len(variants); i++ {
+		if !variants[i].numeric && variants[i].key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Find variant matching static value: exact key, then plural category of number.
+func ftlMatch(variants []ftlVariant, val string, pr *pluralRule, ordinal bool) *ftlVariant {
+	for i := 0; i < len(variants); i++ {
+		if variants[i].key == val {
+			return &variants[i]
+		}
+	}
+	var q query
+	if q.parseDecimal(val) {
+		f, _ := strconv.ParseFloat(val, 64)
+		for i := 0; i < len(variants); i++ {
+			v := &variants[i]
+			if n, err := strconv.ParseFloat(v.key, 64); v.numeric && err == nil && n == f {
+				return v
+			}
+		}
+		form := formOther
+		if pr != nil {
+			if form = pr.card; ordinal {
+				form = pr.ord
+			}
+		}
+		cat, _ := form.category(q.operands())
+		for i := 0; i < len(variants); i++ {
+			if !variants[i].numeric && variants[i].key == cat.String() {
+				return &variants[i]
+			}
+		}
+	}
+	return ftlDefault(variants)
+}
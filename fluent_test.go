@@ -0,0 +1,124 @@
+package i18n
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+const testFluent = `### Resource comment
+
+# Terms.
+-brand = Firefox
+    .gender = masculine
+-product = { $case ->
+   *[nominative] Браузер
+    [genitive] Браузера
+}
+
+## Messages.
+hello = Hello, world!
+pipe = a|b {"{"}literal{"}"}
+welcome = Welcome to { -brand }, { $user }!
+about = О программе { -product(case: "genitive") }
+ref = { hello } And { welcome }
+multiline =
+    First line
+      indented
+
+    after blank
+inline = Text
+    continued
+emails = { $count ->
+    [0] No new emails
+    [one] One new email
+   *[other] { $count } new emails #{ $count }
+}
+place = { NUMBER($pos, type: "ordinal") ->
+    [one] { $pos }st
+    [two] { $pos }nd
+    [few] { $pos }rd
+   *[other] { $pos }th
+}
+gender = { $gender ->
+    [male] He said
+    [female] She said
+   *[other] They said
+} it's fine
+static = { -brand.gender ->
+    [masculine] Он
+   *[other] Оно
+}
+many = { $n ->
+    [one] one
+   *[many] many
+}
+login =
+    .title = Log in to { -brand }
+    .label = Log in
+missing = { unknown } { -unknown }
+`
+
+func TestFluent(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	if err := LoadFluent(db, "en", strings.NewReader(testFluent)); err != nil {
+		t.Fatal(err)
+	}
+	icu := func(key, expect string, args *Args) {
+		t.Helper()
+		if s := db.GetICU(key, "", args); s != expect {
+			t.Errorf("%s: need %q got %q", key, expect, s)
+		}
+	}
+	assertT9n(t, db, "en.hello", "Hello, world!")
+	assertT9n(t, db, "en.pipe", "a|b {literal}")
+	icu("en.welcome", "Welcome to Firefox, John!", (&Args{}).Str("user", "John"))
+	assertT9n(t, db, "en.about", "О программе Браузера")
+	icu("en.ref", "Hello, world! And Welcome to Firefox, Ann!", (&Args{}).Str("user", "Ann"))
+	assertT9n(t, db, "en.multiline", "First line\n  indented\n\nafter blank")
+	assertT9n(t, db, "en.inline", "Text\ncontinued")
+	icu("en.emails", "No new emails", (&Args{}).Int("count", 0))
+	icu("en.emails", "One new email", (&Args{}).Int("count", 1))
+	icu("en.emails", "5 new emails #5", (&Args{}).Int("count", 5))
+	icu("en.place", "22nd", (&Args{}).Int("pos", 22))
+	icu("en.place", "13th", (&Args{}).Int("pos", 13))
+	icu("en.gender", "She said it's fine", (&Args{}).Str("gender", "female"))
+	icu("en.gender", "They said it's fine", (&Args{}).Str("gender", "robot"))
+	assertT9n(t, db, "en.static", "Он")
+	icu("en.many", "one", (&Args{}).Int("n", 1))
+	icu("en.many", "many", (&Args{}).Int("n", 3))
+	assertT9n(t, db, "en.login", "")
+	assertT9n(t, db, "en.login.title", "Log in to Firefox")
+	assertT9n(t, db, "en.login.label", "Log in")
+	assertT9n(t, db, "en.missing", "{unknown} {-unknown}")
+	assertT9n(t, db, "en.-brand", "")
+
+	t.Run("errors", func(t *testing.T) {
+		for _, c := range []struct {
+			src  string
+			line int
+			err  error
+		}{
+			{"a = x\nb = { $n ->\n    [one] x\n}\n", 2, ErrBadFluent},
+			{"a = x\n\nb = x }\n", 3, ErrBadFluent},
+			{"a = x\n= y\n", 2, ErrBadFluent},
+			{"a = x\nb\n", 2, ErrBadFluent},
+			{"a = x\n-t =\n    .attr = y\n", 3, ErrBadFluent},
+			{"a = x\nb = { \"unterminated }\n", 2, ErrBadFluent},
+			{"a = x\nb = { c }\nc = { b }\n", 2, ErrFluentCycle},
+			{"a = x\nb = { UPPER($x) }\n", 2, ErrFluentFunc},
+		} {
+			db, _ := New(xxhash.Hasher64[string]{})
+			err := LoadFluent(db, "en", strings.NewReader(c.src))
+			var perr *ParseError
+			if !errors.As(err, &perr) || perr.Line != c.line || !errors.Is(err, c.err) {
+				t.Errorf("%q: need %v at line %d, got %v", c.src, c.err, c.line, err)
+			}
+			if db.Get("en.a", "") != "" {
+				t.Errorf("%q: translations saved despite error", c.src)
+			}
+		}
+	})
+}
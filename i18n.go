@@ -4,12 +4,12 @@ import (
 	"bytes"
 	"math"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"unsafe"
 
 	"github.com/koykov/byteconv"
-	"github.com/koykov/byteptr"
 	"github.com/koykov/entry"
 	"github.com/koykov/hash"
 )
@@ -25,13 +25,38 @@ type DB struct {
 	// Keys hasher.
 	hasher hash.Hasher[string]
 
-	mux sync.RWMutex
+	// Writers mutex, readers use published snapshot instead.
+	mux sync.Mutex
+	// Current snapshot pointer.
+	sp unsafe.Pointer
+	// Lengths of rules and translations storages shared with published snapshot, they must not be modified in place.
+	prules, pbuf int
 	// Translations index.
 	index index
 	// Rules storage.
 	rules []rule
 	// Translations storage.
 	buf []byte
+	// Keys index and storage.
+	//
+	// Original keys are required to perform prefix-based operations, since translations index contains only hashes.
+	keys index
+	kbuf []byte
+	// Wasted space counters: bytes of buf, count of rules and bytes of kbuf.
+	wbuf, wrules, wkbuf int
+	// Dead rules bitset.
+	dead []uint64
+	// Read-only flag and mapped dump of read-only DB.
+	ro   bool
+	mmap []byte
+	// Underlying snapshot of overlay DB.
+	base *snapshot
+	// Locale fallback configuration pointer.
+	fb unsafe.Pointer
+	// Custom plural rules pointer, see SetPluralForms().
+	pf unsafe.Pointer
+	// Auto compaction threshold.
+	ctr float64
 	// Transaction pointer.
 	txn unsafe.Pointer
 }
@@ -44,8 +69,8 @@ func New(hasher hash.Hasher[string]) (*DB, error) {
 	db := &DB{
 		status: statusActive,
 		hasher: hasher,
-		index:  make(index),
 	}
+	db.publishLF()
 	return db, nil
 }
 
@@ -53,43 +78,177 @@ func New(hasher hash.Hasher[string]) (*DB, error) {
 //
 // If locale needed, the key must contain it as a prefix, eg: "en.messages.accessDenied" or "ru-RU.messages.welcome".
 func (db *DB) Set(key, translation string) error {
-	if err := db.checkStatus(); err != nil {
+	return db.set(key, "", translation)
+}
+
+// Set translation of key in message context ctx.
+func (db *DB) set(key, ctx, translation string) error {
+	if err := db.checkWrite(); err != nil {
 		return err
 	}
 	if len(key) == 0 || len(translation) == 0 {
 		return nil
 	}
+	if err := db.checkRules(translation); err != nil {
+		return err
+	}
 
 	db.mux.Lock()
 	defer db.mux.Unlock()
 	if txn := db.txnIndir(); txn != nil {
 		// Save translation to transaction.
-		txn.set(key, translation)
+		txn.set(key, ctx, translation, txnOpSet)
 	} else {
 		// Set transaction immediately.
-		hkey := db.hasher.Sum64(key)
+		hkey := db.hkeyCtx(key, ctx)
 		db.setLF(hkey, translation)
+		db.setKeyLF(hkey, key, ctx)
+		db.autoCompactLF()
+		db.publishLF()
 	}
 	return nil
 }
 
+// Delete removes translation of key.
+func (db *DB) Delete(key string) error {
+	return db.delete(key, "")
+}
+
+// Remove translation of key in message context ctx.
+func (db *DB) delete(key, ctx string) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return nil
+	}
+
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	if txn := db.txnIndir(); txn != nil {
+		txn.del(key, ctx)
+	} else {
+		db.deleteLF(db.hkeyCtx(key, ctx))
+		db.autoCompactLF()
+		db.publishLF()
+	}
+	return nil
+}
+
+// DeletePrefix removes all translations which keys start with prefix.
+func (db *DB) DeletePrefix(prefix string) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	if len(prefix) == 0 {
+		return nil
+	}
+
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	if txn := db.txnIndir(); txn != nil {
+		txn.delPrefix(prefix)
+	} else {
+		db.deletePrefixLF(prefix)
+		db.autoCompactLF()
+		db.publishLF()
+	}
+	return nil
+}
+
+// DeleteLocale removes all translations of given locale, eg: "en" or "ru-RU".
+func (db *DB) DeleteLocale(locale string) error {
+	if len(locale) == 0 {
+		return nil
+	}
+	return db.DeletePrefix(locale + ".")
+}
+
 // Lock-free inner setter.
 func (db *DB) setLF(hkey uint64, t9n string) entry.Entry64 {
 	var e entry.Entry64
-	if e = db.index.get(hkey); e == 0 {
+	if e = db.index.get(hkey); e == 0 || e == entryTomb {
 		// Save new translation.
 		offset := len(db.buf)
 		db.buf = append(db.buf, t9n...)
 		e = db.makeEntry(offset, len(t9n))
-		db.index[hkey] = e
+		db.index.put(hkey, e)
+	} else if lo, hi := e.Decode(); isICU(db.rules[lo:hi]) {
+		// ICU message can't be updated in place.
+		db.killRules(e)
+		offset := len(db.buf)
+		db.buf = append(db.buf, t9n...)
+		e = db.makeEntry(offset, len(t9n))
+		db.index.put(hkey, e)
 	} else {
 		// Update existing translation.
 		e = db.updateEntry(&e, t9n)
-		db.index[hkey] = e
+		db.index.put(hkey, e)
 	}
 	return e
 }
 
+// Lock-free inner key saver, non-empty ctx stores after separator.
+func (db *DB) setKeyLF(hkey uint64, key, ctx string) {
+	if e := db.keys.get(hkey); e != 0 {
+		return
+	}
+	offset := len(db.kbuf)
+	db.kbuf = appendCtxKey(db.kbuf, key, ctx)
+	db.keys.set(hkey, uint32(offset), uint32(len(db.kbuf)))
+}
+
+// Lock-free inner remover.
+func (db *DB) deleteLF(hkey uint64) {
+	e := db.index.get(hkey)
+	if e == entryTomb {
+		return
+	}
+	if e != 0 {
+		db.killRules(e)
+		db.index.del(hkey)
+		if ke := db.keys.get(hkey); ke != 0 {
+			lo, hi := ke.Decode()
+			db.wkbuf += int(hi - lo)
+			db.keys.del(hkey)
+		}
+	}
+	if l, _ := db.base.find(hkey); l != nil {
+		// Hide translation of underlying DB.
+		db.index.put(hkey, entryTomb)
+	}
+}
+
+// Mark all rules of the entry as dead and account wasted space.
+func (db *DB) killRules(e entry.Entry64) {
+	lo, hi := e.Decode()
+	for i := lo; i < hi; i++ {
+		db.markDead(i)
+		db.wbuf += db.rules[i].rp.len()
+		db.wrules++
+	}
+}
+
+// Lock-free inner prefix remover.
+func (db *DB) deletePrefixLF(prefix string) {
+	p := byteconv.S2B(prefix)
+	db.keys.each(func(hkey uint64, e entry.Entry64) {
+		lo, hi := e.Decode()
+		if bytes.HasPrefix(db.kbuf[lo:hi], p) {
+			db.deleteLF(hkey)
+		}
+	})
+	if db.base != nil {
+		db.base.each(func(hkey uint64, l *snapshot, _ entry.Entry64) {
+			ke := l.key(hkey)
+			lo, hi := ke.Decode()
+			if bytes.HasPrefix(l.kbuf[lo:hi], p) {
+				db.deleteLF(hkey)
+			}
+		})
+	}
+}
+
 // Get returns a translation of key.
 //
 // If translation doesn't exist, def will be used instead.
@@ -114,18 +273,73 @@ func (db *DB) GetPlural(key, def string, count int) string {
 //
 // See GetWR().
 func (db *DB) GetPluralWR(key, def string, count int, repl *PlaceholderReplacer) string {
+	return db.get(key, def, &query{count: int64(count)}, repl)
+}
+
+// GetOrdinal returns a translation using ordinal plural rules, eg: "1st", "2nd".
+func (db *DB) GetOrdinal(key, def string, n int) string {
+	return db.GetOrdinalWR(key, def, n, nil)
+}
+
+// GetOrdinalWR returns a translation using ordinal plural rules with replacer.
+//
+// See GetWR().
+func (db *DB) GetOrdinalWR(key, def string, n int, repl *PlaceholderReplacer) string {
+	return db.get(key, def, &query{count: int64(n), ord: true}, repl)
+}
+
+// GetPluralFloat returns a translation using plural formula with fractional count, eg: 1.5 hours.
+//
+// Float formats using the shortest representation, so 1.0 is the same as 1. Use GetPluralDecimal to keep trailing
+// zeros significant.
+func (db *DB) GetPluralFloat(key, def string, count float64) string {
+	return db.GetPluralFloatWR(key, def, count, nil)
+}
+
+// GetPluralFloatWR returns a translation using plural formula with fractional count and replacer.
+//
+// See GetWR().
+func (db *DB) GetPluralFloatWR(key, def string, count float64, repl *PlaceholderReplacer) string {
+	var q query
+	if !q.parseFloat(count) {
+		return db.get(key, def, nil, repl)
+	}
+	return db.get(key, def, &q, repl)
+}
+
+// GetPluralDecimal returns a translation using plural formula with decimal count given as string, eg: "1.50".
+//
+// Visible fraction digits are significant, eg: "1" and "1.0" may select different forms. If count isn't a valid
+// decimal number, def will be used.
+func (db *DB) GetPluralDecimal(key, def, count string) string {
+	return db.GetPluralDecimalWR(key, def, count, nil)
+}
+
+// GetPluralDecimalWR returns a translation using plural formula with decimal count and replacer.
+//
+// See GetWR().
+func (db *DB) GetPluralDecimalWR(key, def, count string, repl *PlaceholderReplacer) string {
+	var q query
+	if !q.parseDecimal(count) {
+		return db.get(key, def, nil, repl)
+	}
+	return db.get(key, def, &q, repl)
+}
+
+// Inner getter of translation.
+//
+// Nil query means invalid count, def will be used.
+func (db *DB) get(key, def string, q *query, repl *PlaceholderReplacer) string {
 	if err := db.checkStatus(); err != nil {
 		return ""
 	}
 	if len(key) == 0 {
 		return ""
 	}
-	hkey := db.hasher.Sum64(key)
-
-	db.mux.RLock()
-	raw := db.getLF(hkey, count)
-	db.mux.RUnlock()
-
+	var raw string
+	if q != nil {
+		raw, _, _ = db.lookup(key, q)
+	}
 	if len(raw) == 0 {
 		raw = def
 	}
@@ -140,77 +354,99 @@ func (db *DB) GetPluralWR(key, def string, count int, repl *PlaceholderReplacer)
 	return raw
 }
 
-// Lock-free inner getter.
-func (db *DB) getLF(hkey uint64, count int) string {
-	var e entry.Entry64
-	if e = db.index.get(hkey); e == 0 {
-		return ""
-	}
-	lo, hi := e.Decode()
-	if rules := db.rules[lo:hi]; len(rules) > 0 {
-		_ = rules[len(rules)-1]
-		for i := 0; i < len(rules); i++ {
-			r := rules[i]
-			if r.check(count) {
-				return r.bp.TakeAddress(db.buf).String()
-			}
-		}
+// Get raw translation including all plural formula rules.
+func (db *DB) getRawLF(hkey uint64) string {
+	e := db.index.get(hkey)
+	if e == 0 && db.base != nil {
+		return db.base.getRaw(hkey)
 	}
-	return ""
+	return rawT9n(e, db.rules, db.buf)
 }
 
-// Get raw translation including all plural formula rules.
-func (db *DB) getRawLF(hkey uint64) string {
-	var e entry.Entry64
-	if e = db.index.get(hkey); e == 0 {
-		return ""
+// Lock-free inner check if translation of hkey is ICU message.
+func (db *DB) isICULF(hkey uint64) bool {
+	e := db.index.get(hkey)
+	if e == 0 && db.base != nil {
+		return db.base.isICU(hkey)
+	}
+	if e == 0 || e == entryTomb {
+		return false
 	}
 	lo, hi := e.Decode()
-	if rules := db.rules[lo:hi]; len(rules) > 0 {
-		bp := byteptr.Byteptr{}
-		bp.TakeAddress(db.buf).SetOffset(rules[0].rp.Offset())
-		var l int
-		_ = rules[len(rules)-1]
-		for i := 0; i < len(rules); i++ {
-			r := rules[i]
-			l += r.rp.Len()
-		}
-		bp.SetLen(l)
-		return bp.TakeAddress(db.buf).String()
+	return isICU(db.rules[lo:hi])
+}
+
+// Begin starts new independent transaction.
+//
+// Concurrent transactions apply in last-writer-wins manner.
+func (db *DB) Begin() *Txn {
+	return db.begin(false)
+}
+
+// BeginStrict starts new independent transaction with conflicts detection.
+//
+// Commit of such transaction fails with ErrTxnConflict if any of its keys was modified after transaction begin.
+func (db *DB) BeginStrict() *Txn {
+	return db.begin(true)
+}
+
+func (db *DB) begin(strict bool) *Txn {
+	tx := &Txn{strict: strict}
+	if tx.err = db.checkWrite(); tx.err != nil {
+		return tx
 	}
-	return ""
+	tx.t = txnP.get()
+	tx.t.init(db)
+	return tx
 }
 
 // BeginTXN starts new transaction.
 //
 // All update calls will collect in the transaction until commit.
+//
+// Deprecated: DB may have only one such transaction at once, use Begin() instead.
 func (db *DB) BeginTXN() {
-	if err := db.checkStatus(); err != nil {
+	if err := db.checkWrite(); err != nil {
 		return
 	}
 	txn := txnP.get()
-	txn.db = db
+	txn.init(db)
+	db.mux.Lock()
+	if old := db.txnIndir(); old != nil {
+		txnP.put(old)
+	}
 	db.txn = unsafe.Pointer(txn)
+	db.mux.Unlock()
 }
 
 // Rollback transaction.
+//
+// Deprecated: use Begin() and Txn.Rollback() instead.
 func (db *DB) Rollback() {
 	if err := db.checkStatus(); err != nil {
 		return
 	}
-	if txn := db.txnIndir(); txn != nil {
+	db.mux.Lock()
+	txn := db.txnIndir()
+	db.txn = nil
+	db.mux.Unlock()
+	if txn != nil {
 		txnP.put(txn)
 	}
 }
 
 // Commit transaction.
+//
+// Deprecated: use Begin() and Txn.Commit() instead.
 func (db *DB) Commit() {
 	if txn := db.txnIndir(); txn != nil {
-		if err := db.checkStatus(); err != nil {
+		if err := db.checkWrite(); err != nil {
 			return
 		}
 		db.mux.Lock()
 		txn.commit()
+		db.autoCompactLF()
+		db.publishLF()
 		db.txn = nil
 		db.mux.Unlock()
 		txnP.put(txn)
@@ -219,13 +455,18 @@ func (db *DB) Commit() {
 
 // Reset all DB data.
 func (db *DB) Reset() {
-	if err := db.checkStatus(); err != nil {
+	if err := db.checkWrite(); err != nil {
 		return
 	}
 	db.mux.Lock()
 	db.index.reset()
-	db.rules = db.rules[:0]
-	db.buf = db.buf[:0]
+	db.keys.reset()
+	// Storages may be in use by readers, so drop them instead of truncating.
+	db.rules, db.buf, db.kbuf = nil, nil, nil
+	db.prules, db.pbuf = 0, 0
+	db.wbuf, db.wrules, db.wkbuf = 0, 0, 0
+	db.dead = db.dead[:0]
+	db.publishLF()
 	db.mux.Unlock()
 }
 
@@ -242,7 +483,7 @@ func (db *DB) makeEntry(off, ln int) entry.Entry64 {
 	lo, hi := len(db.rules), len(db.rules)
 	s := db.buf[off : off+ln]
 	var nextPipe, offPipe, offFormula, lenPipe int
-	for i := 0; ; i++ {
+	for {
 		var (
 			r      rule
 			cb, qb bool
@@ -253,29 +494,50 @@ func (db *DB) makeEntry(off, ln int) entry.Entry64 {
 			nextPipe = len(s)
 		}
 		chunk := s[offPipe:nextPipe]
-		if chunk[0] == '{' {
-			if lo, offCBE, ok := db.checkCB(chunk, 1); ok {
-				offFormula = offCBE
-				r.encode(lo, lo+1)
+		// Select keyword precedes plural formula.
+		offFormula = db.checkSel(chunk)
+		sel := selKeyword(chunk, offFormula)
+		if f := chunk[offFormula:]; len(f) > 0 && f[0] == '{' {
+			// Range errors are checked before saving translation.
+			if lo, offCBE, ok, _ := db.checkCB(f, 1); ok {
+				offFormula += offCBE
+				r.lo, r.hi = lo, lo
+				r.kind = ruleExact
+				cb = true
+			} else if c, offKWE, ok := db.checkKW(f, 1); ok {
+				offFormula += offKWE
+				r.kind, r.arg = ruleCategory, uint32(c)
 				cb = true
 			}
 		}
-		if !cb && chunk[0] == '[' {
-			if lo, hi, offFPE, ok := db.checkQB(chunk, 1); ok {
-				offFormula = offFPE
-				r.encode(lo, hi)
+		if f := chunk[offFormula:]; !cb && len(f) > 0 && f[0] == '[' {
+			if lo, hi, offFPE, ok, _ := db.checkQB(f, 1); ok {
+				offFormula += offFPE
+				r.lo, r.hi = lo, hi
 				qb = true
 			}
 		}
 		if !cb && !qb {
-			if i == 0 {
-				r.encode(0, 2)
+			// Forms are numbered within the select keyword group.
+			var idx, pos int
+			for j := lo; j < len(db.rules); j++ {
+				if pr := &db.rules[j]; pr.inGroup(db.buf, byteconv.B2S(sel)) {
+					if idx++; pr.kind == ruleBare {
+						pos++
+					}
+				}
+			}
+			// Keep legacy ranges for keys without known plural rules.
+			if idx == 0 {
+				r.lo, r.hi = 0, 2
 			} else {
-				r.encode(2, math.MaxInt32)
+				r.lo, r.hi = 2, math.MaxInt64
 			}
+			r.kind, r.arg = ruleBare, uint32(pos)
 		}
-		r.bp.Init(db.buf, off+offPipe+offFormula, nextPipe-offPipe-offFormula)
-		r.rp.Init(db.buf, off+offPipe, nextPipe-offPipe+lenPipe)
+		r.arg |= uint32(len(sel)) << 16
+		r.bp.init(off+offPipe+offFormula, nextPipe-offPipe-offFormula)
+		r.rp.init(off+offPipe, nextPipe-offPipe+lenPipe)
 		db.rules = append(db.rules, r)
 		hi++
 		offPipe = nextPipe + 1
@@ -309,16 +571,17 @@ func (db *DB) updateEntry(e *entry.Entry64, t9n string) entry.Entry64 {
 	lo, hi := e.Decode()
 	if rules = db.rules[lo:hi]; len(rules) > 0 {
 		_ = rules[len(rules)-1]
-		rawOff = rules[0].rp.Offset()
+		rawOff = rules[0].rp.offset()
 		for i := 0; i < len(rules); i++ {
 			rule := rules[i]
-			rawLen += rule.rp.Len()
+			rawLen += rule.rp.len()
 		}
 	}
 
-	// Check space for new translation.
-	if len(t9n) > rawLen || pc > int(hi-lo) {
+	// Check space for new translation, published space is in use by readers.
+	if len(t9n) > rawLen || pc > int(hi-lo) || rawOff < db.pbuf || int(lo) < db.prules {
 		// No space, make new entry.
+		db.killRules(*e)
 		off := len(db.buf)
 		db.buf = append(db.buf, t9n...)
 		return db.makeEntry(off, len(t9n))
@@ -329,76 +592,270 @@ func (db *DB) updateEntry(e *entry.Entry64, t9n string) entry.Entry64 {
 		db.makeEntry(rawOff, len(t9n))
 		copy(db.rules[lo:hi], db.rules[rulesOff:])
 		db.rules = db.rules[:rulesOff]
+		// Account tail of old space and unused rules.
+		db.wbuf += rawLen - len(t9n)
+		for i := lo + uint32(pc); i < hi; i++ {
+			db.markDead(i)
+			db.wrules++
+		}
 		e.Encode(lo, lo+uint32(pc))
 		return *e
 	}
 }
 
 // Get next position of unescaped b.
+//
+// Byte is escaped if odd count of backslashes precedes it.
 func (db *DB) scanUnescByte(s []byte, b byte, offset int) int {
-	for si := bytes.IndexByte(s[offset:], b); si != -1; {
-		if si > 0 && s[si-1] == '\\' {
-			offset = si
-			continue
+	for offset < len(s) {
+		si := bytes.IndexByte(s[offset:], b)
+		if si == -1 {
+			return -1
 		}
-		return offset + si
+		pos := offset + si
+		var n int
+		for i := pos - 1; i >= 0 && s[i] == '\\'; i-- {
+			n++
+		}
+		if n%2 == 0 {
+			return pos
+		}
+		offset = pos + 1
 	}
 	return -1
 }
 
+// Unescape translation form: "\|" becomes "|", "\\" becomes "\", leading "\{" and "\[" become "{" and "[".
+//
+// Other backslashes remain as is. Form without backslashes returns without allocation.
+func unescT9n(s string) string {
+	if strings.IndexByte(s, '\\') == -1 {
+		return s
+	}
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == '\\' && i+1 < len(s) {
+			if n := s[i+1]; n == '|' || n == '\\' || (i == 0 && (n == '{' || n == '[')) {
+				buf = append(buf, n)
+				i++
+				continue
+			}
+		}
+		buf = append(buf, s[i])
+	}
+	return byteconv.B2S(buf)
+}
+
+// Join forms to translation, each form escapes using escT9n().
+func joinT9n(dst []byte, forms []string) []byte {
+	for i := 0; i < len(forms); i++ {
+		if i > 0 {
+			dst = append(dst, '|')
+		}
+		dst = escT9n(dst, forms[i])
+	}
+	return dst
+}
+
+// Escape translation form s to dst, so it keeps as is in the translation, see unescT9n().
+func escT9n(dst []byte, s string) []byte {
+	if len(s) > 0 && (s[0] == '{' || s[0] == '[') {
+		dst = append(dst, '\\')
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == '|' || c == '\\' {
+			dst = append(dst, '\\')
+		}
+		dst = append(dst, s[i])
+	}
+	return dst
+}
+
 // Check value in curly brackets.
 //
-// Returns the exact value, offset of rule payload and success flag.
-func (db *DB) checkCB(p []byte, off int) (lo int32, offCBE int, ok bool) {
+// Returns the exact value, offset of rule payload and success flag. Error returns if value doesn't fit int64.
+func (db *DB) checkCB(p []byte, off int) (lo int64, offCBE int, ok bool, err error) {
 	if offCBE = db.scanUnescByte(p, '}', off); offCBE != -1 {
 		if raw := p[off:offCBE]; len(raw) > 0 {
-			if lo64, err := strconv.ParseInt(byteconv.B2S(raw), 10, 32); err == nil {
-				if p[offCBE+1] == ' ' {
-					offCBE += 2
-				} else {
-					offCBE++
-				}
-				lo = int32(lo64)
+			if lo, err = parseBound(raw); err == nil {
+				offCBE = skipSpace(p, offCBE+1)
 				ok = true
+			} else if err != ErrBadRange {
+				err = nil
 			}
 		}
 	}
 	return
 }
 
+// Check plural category keyword in curly brackets.
+//
+// Returns the category, offset of rule payload and success flag.
+func (db *DB) checkKW(p []byte, off int) (c PluralCategory, offKWE int, ok bool) {
+	if offKWE = db.scanUnescByte(p, '}', off); offKWE != -1 {
+		if c, ok = ParsePluralCategory(byteconv.B2S(p[off:offKWE])); ok {
+			offKWE = skipSpace(p, offKWE+1)
+		}
+	}
+	return
+}
+
+// Check select keyword at the beginning of chunk, eg: "{female} She" or "{female} {one} She has one cat".
+//
+// Any word in curly brackets except numbers and plural categories is a select keyword. Plural category is a select
+// keyword only if a plural formula follows it, eg: "{other} {one} They have one cat".
+//
+// Returns offset after the keyword or zero if chunk has no select keyword.
+func (db *DB) checkSel(chunk []byte) int {
+	if len(chunk) == 0 || chunk[0] != '{' {
+		return 0
+	}
+	end := db.scanUnescByte(chunk, '}', 1)
+	if end <= 1 || end-1 > maxSelLen {
+		return 0
+	}
+	word := chunk[1:end]
+	for i := 0; i < len(word); i++ {
+		if c := word[i]; !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return 0
+		}
+	}
+	if _, err := strconv.ParseInt(byteconv.B2S(word), 10, 64); err == nil || isRangeErr(err) {
+		return 0
+	}
+	off := skipSpace(chunk, end+1)
+	if _, ok := ParsePluralCategory(byteconv.B2S(word)); ok {
+		// Check if plural formula follows the category.
+		f := chunk[off:]
+		switch {
+		case len(f) > 0 && f[0] == '{':
+			if _, _, ok, err := db.checkCB(f, 1); ok || err != nil {
+				return off
+			}
+			if _, _, ok := db.checkKW(f, 1); ok {
+				return off
+			}
+		case len(f) > 0 && f[0] == '[':
+			if _, _, _, ok, err := db.checkQB(f, 1); ok || err != nil {
+				return off
+			}
+		}
+		return 0
+	}
+	return off
+}
+
+// Get select keyword of chunk with keyword header of length n.
+func selKeyword(chunk []byte, n int) []byte {
+	if n == 0 {
+		return nil
+	}
+	return chunk[1:bytes.IndexByte(chunk, '}')]
+}
+
 // Check values in square brackets.
 //
-// Returns the low/high values of range, offset of rule payload and success flag.
-func (db *DB) checkQB(p []byte, off int) (lo int32, hi int32, offQBE int, ok bool) {
+// Returns the low/high values of range, offset of rule payload and success flag. Error returns if any value doesn't
+// fit int64.
+func (db *DB) checkQB(p []byte, off int) (lo, hi int64, offQBE int, ok bool, err error) {
 	if offQBE = db.scanUnescByte(p, ']', off); offQBE != -1 {
 		raw := p[off:offQBE]
-		if p[offQBE+1] == ' ' {
-			offQBE += 2
-		} else {
-			offQBE++
-		}
+		offQBE = skipSpace(p, offQBE+1)
 		if offComma := bytes.IndexByte(raw, ','); offComma != -1 {
 			rawLo, rawHi := raw[:offComma], raw[offComma+1:]
-			ok = true
+			var errLo, errHi error
 			if bytes.Equal(rawLo, inf) {
-				lo = math.MinInt32
-			} else if lo64, err := strconv.ParseInt(byteconv.B2S(rawLo), 10, 32); err == nil {
-				lo = int32(lo64)
+				lo = math.MinInt64
 			} else {
-				ok = false
+				lo, errLo = parseBound(rawLo)
 			}
 			if bytes.Equal(rawHi, inf) {
-				hi = math.MaxInt32
-			} else if hi64, err := strconv.ParseInt(byteconv.B2S(rawHi), 10, 32); err == nil {
-				hi = int32(hi64)
+				hi = math.MaxInt64
 			} else {
-				ok = false
+				hi, errHi = parseBound(rawHi)
+			}
+			ok = errLo == nil && errHi == nil
+			if errLo == ErrBadRange || errHi == ErrBadRange {
+				err = ErrBadRange
 			}
 		}
 	}
 	return
 }
 
+// Parse range bound.
+//
+// Returns ErrBadRange if value is a number but doesn't fit int64.
+func parseBound(p []byte) (int64, error) {
+	v, err := strconv.ParseInt(byteconv.B2S(p), 10, 64)
+	if err != nil {
+		if isRangeErr(err) {
+			return 0, ErrBadRange
+		}
+		return 0, err
+	}
+	return v, nil
+}
+
+func isRangeErr(err error) bool {
+	ne, ok := err.(*strconv.NumError)
+	return ok && ne.Err == strconv.ErrRange
+}
+
+// Skip single space after rule formula.
+func skipSpace(p []byte, off int) int {
+	if off < len(p) && p[off] == ' ' {
+		off++
+	}
+	return off
+}
+
+// Check ranges of all rules in translation.
+func (db *DB) checkRules(t9n string) error {
+	s := byteconv.S2B(t9n)
+	for off := 0; off < len(s); {
+		next := db.scanUnescByte(s, '|', off)
+		if next == -1 {
+			next = len(s)
+		}
+		if chunk := s[off:next]; len(chunk) > 0 {
+			var err error
+			chunk = chunk[db.checkSel(chunk):]
+			if len(chunk) == 0 {
+				off = next + 1
+				continue
+			}
+			switch chunk[0] {
+			case '{':
+				_, _, _, err = db.checkCB(chunk, 1)
+			case '[':
+				_, _, _, _, err = db.checkQB(chunk, 1)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		off = next + 1
+	}
+	return nil
+}
+
+// Get hash of key.
+//
+// Locale prefix and the rest of key hash separately, this allows to switch locale of key without concatenation.
+func (db *DB) hkey(key string) uint64 {
+	key, ctx := splitCtx(key)
+	if locale, rest := splitKey(key); len(locale) > 0 {
+		return withCtx(hcombine(db.hasher.Sum64(locale), db.hasher.Sum64(rest)), db.hctx(ctx))
+	}
+	return withCtx(db.hasher.Sum64(key), db.hctx(ctx))
+}
+
+// Combine hashes of locale and the rest of key.
+func hcombine(hlocale, hrest uint64) uint64 {
+	return hrest ^ (hlocale*0x9e3779b97f4a7c15 + 0x7f4a7c15 + hrest<<6 + hrest>>2)
+}
+
 func (db *DB) checkStatus() error {
 	if atomic.LoadUint32(&db.status) == statusNil {
 		return ErrBadDB
@@ -406,4 +863,14 @@ func (db *DB) checkStatus() error {
 	return nil
 }
 
+func (db *DB) checkWrite() error {
+	if err := db.checkStatus(); err != nil {
+		return err
+	}
+	if db.ro {
+		return ErrReadOnly
+	}
+	return nil
+}
+
 var inf = []byte("*")
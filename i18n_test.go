@@ -1,11 +1,13 @@
 package i18n
 
 import (
+	"math"
 	"math/rand"
 	"strconv"
 	"testing"
 
 	"github.com/koykov/byteconv"
+	"github.com/koykov/entry"
 	"github.com/koykov/hash/xxhash"
 )
 
@@ -53,43 +55,63 @@ func TestIO(t *testing.T) {
 	t.Run("100K", func(t *testing.T) { testIO(t, 100000) })
 	t.Run("overwrite", func(t *testing.T) {
 		db, _ := New(xxhash.Hasher64[string]{})
-		_ = db.Set("key1", "Lorem ipsum dolor sit amet, consectetur adipiscing elit.")
-		_ = db.Set("key2", "Aenean congue quis nisl ut vulputate. Sed lacus dolor, tempor nec elit sit amet, congue dapibus purus. Pellentesque a lectus vel leo finibus scelerisque.")
-		_ = db.Set("key3", "Aliquam blandit mauris mauris, eget bibendum lacus tempus non. Duis orci leo, sagittis sed lorem eu, pulvinar elementum leo.")
+		// Space of unpublished translations reuses in place.
+		set := func(key, t9n string) entry.Entry64 {
+			hkey := db.hkey(key)
+			db.setKeyLF(hkey, key, "")
+			return db.setLF(hkey, t9n)
+		}
+		set("key1", "Lorem ipsum dolor sit amet, consectetur adipiscing elit.")
+		set("key2", "Aenean congue quis nisl ut vulputate. Sed lacus dolor, tempor nec elit sit amet, congue dapibus purus. Pellentesque a lectus vel leo finibus scelerisque.")
+		set("key3", "Aliquam blandit mauris mauris, eget bibendum lacus tempus non. Duis orci leo, sagittis sed lorem eu, pulvinar elementum leo.")
 
 		var lo, hi uint32
 
-		hkey := db.hasher.Sum64("key1")
-		e := db.setLF(hkey, "Nunc lacinia, purus finibus consectetur ullamcorper, nisi elit laoreet augue, vitae tincidunt tellus velit sit amet arcu.")
+		e := set("key1", "Nunc lacinia, purus finibus consectetur ullamcorper, nisi elit laoreet augue, vitae tincidunt tellus velit sit amet arcu.")
 		lo, hi = e.Decode()
 		assertLH(t, lo, hi, 3, 4)
 
 		t9n := "Quisque sit amet viverra ligula. Praesent sagittis, sapien ut rutrum porttitor, dolor ligula accumsan velit, ut lacinia tellus tellus nec tortor."
-		hkey = db.hasher.Sum64("key2")
-		e = db.setLF(hkey, t9n)
+		e = set("key2", t9n)
 		lo, hi = e.Decode()
 		assertLH(t, lo, hi, 1, 2)
+		db.publishLF()
 		assertT9n(t, db, "key2", t9n)
+
+		// Published space is in use by readers and never overwrites.
+		s := db.snap()
+		e = set("key2", "Short.")
+		lo, hi = e.Decode()
+		assertLH(t, lo, hi, 4, 5)
+		if t9n1, _ := s.get(db.hkey("key2"), &query{}, nil); t9n1 != t9n {
+			t.Errorf("published translation modified, got %s", t9n1)
+		}
+		db.publishLF()
+		assertT9n(t, db, "key2", "Short.")
 	})
 	t.Run("overwrite_plural", func(t *testing.T) {
 		db, _ := New(xxhash.Hasher64[string]{})
-		_ = db.Set("key1", "There is one apple|There are many apples")
-		_ = db.Set("key2", "{0} There are none|[1,19] There are some|[20,*] There are many")
-		_ = db.Set("key3", "{1} :value minute ago|[2,*] :value minutes ago")
+		set := func(key, t9n string) entry.Entry64 {
+			hkey := db.hkey(key)
+			db.setKeyLF(hkey, key, "")
+			return db.setLF(hkey, t9n)
+		}
+		set("key1", "There is one apple|There are many apples")
+		set("key2", "{0} There are none|[1,19] There are some|[20,*] There are many")
+		set("key3", "{1} :value minute ago|[2,*] :value minutes ago")
 
 		var lo, hi uint32
 
-		hkey := db.hasher.Sum64("key1")
-		e := db.setLF(hkey, "{0} There are none|{1} There is one|[2,*] There are :count")
+		e := set("key1", "{0} There are none|{1} There is one|[2,*] There are :count")
 		lo, hi = e.Decode()
 		assertLH(t, lo, hi, 7, 10)
-		assertT9nPlural(t, db, "key1", "There is one", 1)
 
 		t9n := "{0} There are none|{1} There is one|[2,*] There are :count"
-		hkey = db.hasher.Sum64("key2")
-		e = db.setLF(hkey, t9n)
+		e = set("key2", t9n)
 		lo, hi = e.Decode()
 		assertLH(t, lo, hi, 2, 5)
+		db.publishLF()
+		assertT9nPlural(t, db, "key1", "There is one", 1)
 		assertT9nPlural(t, db, "key2", "There is one", 1)
 		assertT9nPlural(t, db, "key2", "There are :count", 10)
 	})
@@ -139,6 +161,80 @@ func TestPlural(t *testing.T) {
 	})
 }
 
+func TestPlural64(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	_ = db.Set("en.files.size", "[0,2147483648] small|[2147483648,1099511627776] big|[1099511627776,*] huge")
+	_ = db.Set("en.views", "{9223372036854775807} max|{-9223372036854775808} min|[*,0] negative|{1} one|other")
+	_ = db.Set("views", "single|many")
+
+	assertT9nPlural(t, db, "en.files.size", "small", 1<<31-1)
+	assertT9nPlural(t, db, "en.files.size", "big", 1<<31)
+	assertT9nPlural(t, db, "en.files.size", "big", 1<<32+1)
+	assertT9nPlural(t, db, "en.files.size", "huge", 1<<40)
+	assertT9nPlural(t, db, "en.files.size", "huge", math.MaxInt64)
+	assertT9nPlural(t, db, "en.views", "max", math.MaxInt64)
+	assertT9nPlural(t, db, "en.views", "min", math.MinInt64)
+	assertT9nPlural(t, db, "en.views", "negative", -1<<40)
+	assertT9nPlural(t, db, "en.views", "one", 1)
+	assertT9nPlural(t, db, "en.views", "other", 1<<32+1)
+	// Legacy ranges mustn't wrap around.
+	assertT9nPlural(t, db, "views", "many", 1<<32+1)
+	assertT9nPlural(t, db, "views", "many", math.MaxInt64)
+
+	for _, t9n := range []string{
+		"{9223372036854775808} overflow|other",
+		"[0,99999999999999999999] overflow",
+		"[-9223372036854775809,0] overflow",
+	} {
+		if err := db.Set("en.overflow", t9n); err != ErrBadRange {
+			t.Errorf("%s: need ErrBadRange got %v", t9n, err)
+		}
+		tx := db.Begin()
+		if err := tx.Set("en.overflow", t9n); err != ErrBadRange {
+			t.Errorf("%s: txn need ErrBadRange got %v", t9n, err)
+		}
+		tx.Rollback()
+	}
+	assertT9n(t, db, "en.overflow", "")
+}
+
+func TestDelete(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	_ = db.Set("en.messages.welcome", "Hello there!")
+	_ = db.Set("en.messages.bye", "Goodbye!")
+	_ = db.Set("ru.messages.welcome", "Привет!")
+	_ = db.Set("ru-RU.messages.welcome", "Здравствуйте!")
+
+	t.Run("key", func(t *testing.T) {
+		_ = db.Delete("en.messages.bye")
+		assertT9n(t, db, "en.messages.bye", "")
+		assertT9n(t, db, "en.messages.welcome", "Hello there!")
+		lo, hi := uint32(1), uint32(2)
+		for i := lo; i < hi; i++ {
+			if !db.isDead(i) {
+				t.Errorf("rule #%d must be dead", i)
+			}
+		}
+	})
+	t.Run("locale", func(t *testing.T) {
+		_ = db.DeleteLocale("ru")
+		assertT9n(t, db, "ru.messages.welcome", "")
+		assertT9n(t, db, "ru-RU.messages.welcome", "Здравствуйте!")
+		assertT9n(t, db, "en.messages.welcome", "Hello there!")
+	})
+	t.Run("prefix", func(t *testing.T) {
+		_ = db.DeletePrefix("ru-")
+		assertT9n(t, db, "ru-RU.messages.welcome", "")
+		if db.index.len() != 1 || db.keys.len() != 1 {
+			t.Errorf("index size mismatch, need 1 got %d/%d", db.index.len(), db.keys.len())
+		}
+	})
+	t.Run("reuse", func(t *testing.T) {
+		_ = db.Set("en.messages.bye", "Bye!")
+		assertT9n(t, db, "en.messages.bye", "Bye!")
+	})
+}
+
 func BenchmarkIO(b *testing.B) {
 	benchIO := func(b *testing.B, entries int64) {
 		buf := []byte("en.")
@@ -168,6 +264,28 @@ func BenchmarkIO(b *testing.B) {
 	b.Run("100K", func(b *testing.B) { benchIO(b, 100000) })
 }
 
+func BenchmarkSet(b *testing.B) {
+	benchSet := func(b *testing.B, entries int64) {
+		buf := []byte("en.")
+		db, _ := New(xxhash.Hasher64[string]{})
+		for i := int64(0); i < entries; i++ {
+			buf = strconv.AppendInt(buf[:3], i, 10)
+			_ = db.Set(byteconv.B2S(buf), "Hello there!")
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			buf = strconv.AppendInt(buf[:3], rand.Int63n(entries), 10)
+			_ = db.Set(byteconv.B2S(buf), "Hi!")
+		}
+	}
+
+	b.Run("1K", func(b *testing.B) { benchSet(b, 1000) })
+	b.Run("100K", func(b *testing.B) { benchSet(b, 100000) })
+	b.Run("1M", func(b *testing.B) { benchSet(b, 1000000) })
+}
+
 func BenchmarkPlural(b *testing.B) {
 	benchPlural := func(b *testing.B, db *DB, key, def string, count int, expect string) {
 		repl := PlaceholderReplacer{}
@@ -0,0 +1,608 @@
+package i18n
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/koykov/byteconv"
+	"github.com/koykov/entry"
+)
+
+// ICU MessageFormat support.
+//
+// Parsed message stores in rules storage as a flat list of nodes in prefix order. The first rule is a header, that
+// spans whole message, the rest are nodes:
+// * text: bp is a literal text with unresolved apostrophe quoting
+// * argument: bp is an argument name
+// * pound: "#" sign of plural sub-message
+// * plural/selectordinal/select: bp is an argument name, lo is an offset of plural and hi is a count of nested rules
+// * branch: bp is a selector, hi is a count of nested rules; lo is an exact value or a plural category
+//
+// Nodes have no own raw spans, so compaction and dumping work with ICU messages as with regular translations.
+
+// Max nesting level of ICU message.
+const icuDepth = 32
+
+// Flags of ICU nodes.
+const (
+	// Text contains apostrophes.
+	icuTextQuoted = 1 << iota
+	// Text belongs to plural sub-message, so "#" may be quoted.
+	icuTextPlural
+	// Branch has exact value selector, eg: "=0".
+	icuBranchExact
+)
+
+// SetICU saves ICU MessageFormat message of key, eg:
+//
+//	db.SetICU("en.cart.items", "{count, plural, =0 {Cart is empty} one {# item} other {# items}}")
+//
+// Supported arguments are simple "{name}" or "{name, type[, style]}", "plural", "selectordinal" and "select" with
+// nesting, plural offset, exact "=N" selectors and apostrophe quoting. Use GetICU to render message.
+func (db *DB) SetICU(key, message string) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	if len(key) == 0 || len(message) == 0 {
+		return nil
+	}
+	if _, err := parseICU(nil, message, 0); err != nil {
+		return err
+	}
+
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	if txn := db.txnIndir(); txn != nil {
+		txn.set(key, "", message, txnOpSetICU)
+	} else {
+		hkey := db.hkey(key)
+		db.setICULF(hkey, message)
+		db.setKeyLF(hkey, key, "")
+		db.autoCompactLF()
+		db.publishLF()
+	}
+	return nil
+}
+
+// GetICU returns ICU message of key rendered with args.
+//
+// Result is valid until the next use of args. If translation doesn't exist, def will be used instead. Regular
+// translations return as Get() does.
+func (db *DB) GetICU(key, def string, args *Args) string {
+	if args == nil {
+		args = &Args{}
+	}
+	return db.get(key, def, &query{count: 1, args: args}, nil)
+}
+
+// GetICU returns ICU message of key rendered with args.
+//
+// See DB.GetICU().
+func (l Localizer) GetICU(key, def string, args *Args) string {
+	if args == nil {
+		args = &Args{}
+	}
+	return l.get(key, def, &query{count: 1, args: args}, nil)
+}
+
+// Lock-free inner setter of ICU message.
+func (db *DB) setICULF(hkey uint64, msg string) entry.Entry64 {
+	if e := db.index.get(hkey); e != 0 && e != entryTomb {
+		db.killRules(e)
+	}
+	off := len(db.buf)
+	db.buf = append(db.buf, msg...)
+	lo := len(db.rules)
+	// Message is already checked.
+	db.rules, _ = parseICU(db.rules, msg, off)
+	var e entry.Entry64
+	e.Encode(uint32(lo), uint32(len(db.rules)))
+	db.index.put(hkey, e)
+	return e
+}
+
+// Check if rules are ICU message.
+func isICU(rules []rule) bool {
+	return len(rules) > 0 && rules[0].kind == ruleICU
+}
+
+// Check structure of ICU nodes: nested rules of complex nodes and branches must fit nodes.
+func checkICUNodes(nodes []rule) bool {
+	for i := 0; i < len(nodes); i++ {
+		n := &nodes[i]
+		switch n.kind {
+		case ruleICUText, ruleICUArg, ruleICUPound:
+		case ruleICUPlural, ruleICUOrdinal, ruleICUSelect:
+			if n.hi < 0 || n.hi > int64(len(nodes)-i-1) {
+				return false
+			}
+			sub := nodes[i+1 : i+1+int(n.hi)]
+			for j := 0; j < len(sub); j += int(sub[j].hi) + 1 {
+				b := &sub[j]
+				if b.kind != ruleICUBranch || b.hi < 0 || b.hi > int64(len(sub)-j-1) || !checkICUNodes(sub[j+1:j+1+int(b.hi)]) {
+					return false
+				}
+			}
+			i += int(n.hi)
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Parse ICU message and append its nodes to dst.
+//
+// Offset base is a position of message in translations storage.
+func parseICU(dst []rule, msg string, base int) ([]rule, error) {
+	p := icuParser{s: msg, base: base, nodes: dst}
+	var h rule
+	h.kind = ruleICU
+	h.rp.init(base, len(msg))
+	h.bp.init(base, len(msg))
+	p.nodes = append(p.nodes, h)
+	if err := p.message(false, false); err != nil {
+		return dst, err
+	}
+	return p.nodes, nil
+}
+
+// ICU message parser.
+type icuParser struct {
+	s     string
+	off   int
+	base  int
+	depth int
+	nodes []rule
+}
+
+// Parse (sub-)message until the end or closing bracket of nested message.
+func (p *icuParser) message(plural, nested bool) error {
+	if p.depth++; p.depth > icuDepth {
+		return ErrBadICU
+	}
+	defer func() { p.depth-- }()
+	for p.off < len(p.s) {
+		switch c := p.s[p.off]; {
+		case c == '}':
+			if !nested {
+				return ErrBadICU
+			}
+			return nil
+		case c == '{':
+			if err := p.argument(); err != nil {
+				return err
+			}
+		case c == '#' && plural:
+			p.node(ruleICUPound, p.off, 0)
+			p.off++
+		default:
+			start := p.off
+			quoted := p.text(plural)
+			n := p.node(ruleICUText, start, p.off-start)
+			if quoted {
+				n.arg |= icuTextQuoted
+			}
+			if plural {
+				n.arg |= icuTextPlural
+			}
+		}
+	}
+	if nested {
+		return ErrBadICU
+	}
+	return nil
+}
+
+// Skip literal text considering apostrophe quoting. Returns true if text contains apostrophes.
+func (p *icuParser) text(plural bool) (quoted bool) {
+	for p.off < len(p.s) {
+		c := p.s[p.off]
+		if c == '{' || c == '}' || (c == '#' && plural) {
+			return
+		}
+		if c == '\'' {
+			quoted = true
+			p.off = skipICUQuote(p.s, p.off, plural)
+			continue
+		}
+		p.off++
+	}
+	return
+}
+
+// Parse argument starting with opening bracket.
+func (p *icuParser) argument() error {
+	p.off++
+	p.skip()
+	name := p.token()
+	if len(name) == 0 {
+		return ErrBadICU
+	}
+	nameOff := p.off - len(name)
+	p.skip()
+	if p.accept('}') {
+		p.node(ruleICUArg, nameOff, len(name))
+		return nil
+	}
+	if !p.accept(',') {
+		return ErrBadICU
+	}
+	p.skip()
+	typ := p.token()
+	p.skip()
+	var kind uint32
+	switch typ {
+	case "plural":
+		kind = ruleICUPlural
+	case "selectordinal":
+		kind = ruleICUOrdinal
+	case "select":
+		kind = ruleICUSelect
+	case "":
+		return ErrBadICU
+	default:
+		// Formatted argument, style ignores and value renders as is.
+		p.node(ruleICUArg, nameOff, len(name))
+		if p.accept('}') {
+			return nil
+		}
+		if !p.accept(',') {
+			return ErrBadICU
+		}
+		return p.style()
+	}
+	if !p.accept(',') {
+		return ErrBadICU
+	}
+	return p.complex(kind, nameOff, len(name))
+}
+
+// Parse branches of plural, selectordinal or select argument.
+func (p *icuParser) complex(kind uint32, nameOff, nameLen int) error {
+	idx := len(p.nodes)
+	p.node(kind, nameOff, nameLen)
+	plural := kind != ruleICUSelect
+	p.skip()
+	if plural && strings.HasPrefix(p.s[p.off:], "offset:") {
+		p.off += 7
+		p.skip()
+		raw := p.token()
+		offset, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return ErrBadICU
+		}
+		p.nodes[idx].lo = offset
+	}
+	var other bool
+	for {
+		p.skip()
+		if p.off == len(p.s) {
+			return ErrBadICU
+		}
+		if p.accept('}') {
+			break
+		}
+		sel := p.token()
+		if len(sel) == 0 {
+			return ErrBadICU
+		}
+		bidx := len(p.nodes)
+		b := p.node(ruleICUBranch, p.off-len(sel), len(sel))
+		switch {
+		case plural && sel[0] == '=':
+			v, err := strconv.ParseInt(sel[1:], 10, 64)
+			if err != nil {
+				return ErrBadICU
+			}
+			b.lo, b.arg = v, icuBranchExact
+		case plural:
+			c, ok := ParsePluralCategory(sel)
+			if !ok {
+				return ErrBadICU
+			}
+			b.lo = int64(c)
+		}
+		other = other || sel == "other"
+		p.skip()
+		if !p.accept('{') {
+			return ErrBadICU
+		}
+		if err := p.message(plural, true); err != nil {
+			return err
+		}
+		p.off++
+		p.nodes[bidx].hi = int64(len(p.nodes) - bidx - 1)
+		p.nodes[idx].arg++
+	}
+	if !other {
+		return ErrBadICU
+	}
+	p.nodes[idx].hi = int64(len(p.nodes) - idx - 1)
+	return nil
+}
+
+// Skip style of formatted argument including closing bracket.
+func (p *icuParser) style() error {
+	for depth := 0; p.off < len(p.s); {
+		switch p.s[p.off] {
+		case '\'':
+			p.off = skipICUQuote(p.s, p.off, false)
+			continue
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				p.off++
+				return nil
+			}
+			depth--
+		}
+		p.off++
+	}
+	return ErrBadICU
+}
+
+// Append new node spanning n bytes from offset off of message.
+func (p *icuParser) node(kind uint32, off, n int) *rule {
+	var r rule
+	r.kind = kind
+	r.rp.init(p.base, 0)
+	r.bp.init(p.base+off, n)
+	p.nodes = append(p.nodes, r)
+	return &p.nodes[len(p.nodes)-1]
+}
+
+// Read token up to space or syntax char.
+func (p *icuParser) token() string {
+	start := p.off
+	for p.off < len(p.s) {
+		c := p.s[p.off]
+		if isICUSpace(c) || c == '{' || c == '}' || c == ',' || c == '\'' || c == '#' {
+			break
+		}
+		p.off++
+	}
+	return p.s[start:p.off]
+}
+
+func (p *icuParser) accept(c byte) bool {
+	if p.off < len(p.s) && p.s[p.off] == c {
+		p.off++
+		return true
+	}
+	return false
+}
+
+func (p *icuParser) skip() {
+	for p.off < len(p.s) && isICUSpace(p.s[p.off]) {
+		p.off++
+	}
+}
+
+func isICUSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// Skip apostrophe at offset off and quoted literal after it.
+//
+// Doubled apostrophe is a single literal apostrophe. Apostrophe starts quoted literal only before special char,
+// otherwise it's literal too.
+func skipICUQuote(s string, off int, plural bool) int {
+	if off+1 < len(s) && s[off+1] == '\'' {
+		return off + 2
+	}
+	if off+1 == len(s) || !isICUQuotable(s[off+1], plural) {
+		return off + 1
+	}
+	for off += 2; off < len(s); off++ {
+		if s[off] == '\'' {
+			if off+1 < len(s) && s[off+1] == '\'' {
+				off++
+				continue
+			}
+			return off + 1
+		}
+	}
+	// Unterminated literal lasts till the end.
+	return off
+}
+
+func isICUQuotable(c byte, plural bool) bool {
+	return c == '{' || c == '}' || (c == '#' && plural)
+}
+
+// Append text resolving apostrophe quoting.
+func appendICUText(dst []byte, s string, plural bool) []byte {
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c != '\'' {
+			dst = append(dst, c)
+			i++
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '\'' {
+			dst = append(dst, '\'')
+			i += 2
+			continue
+		}
+		if i+1 == len(s) || !isICUQuotable(s[i+1], plural) {
+			dst = append(dst, c)
+			i++
+			continue
+		}
+		for i++; i < len(s); i++ {
+			if s[i] == '\'' {
+				if i+1 < len(s) && s[i+1] == '\'' {
+					dst = append(dst, '\'')
+					i++
+					continue
+				}
+				i++
+				break
+			}
+			dst = append(dst, s[i])
+		}
+	}
+	return dst
+}
+
+// Append text s quoting ICU special chars, plural means text belongs to plural branch where "#" is special.
+//
+// Apostrophe doubles only if it may start quoted literal, including the end of s, since text may be followed by
+// argument.
+func quoteICUText(dst []byte, s string, plural bool) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'':
+			if i+1 == len(s) || s[i+1] == '\'' || isICUQuotable(s[i+1], plural) {
+				dst = append(dst, "''"...)
+			} else {
+				dst = append(dst, c)
+			}
+		case isICUQuotable(c, plural):
+			dst = append(dst, '\'', c, '\'')
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
+
+// Check if ICU message contains arguments, otherwise it's plain text with apostrophe quoting.
+func isICUDynamic(s string) bool {
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '\'':
+			i = skipICUQuote(s, i, false)
+		case '{', '}':
+			return true
+		default:
+			i++
+		}
+	}
+	return false
+}
+
+// Render ICU message nodes to args output buffer.
+func (a *Args) render(nodes []rule, buf []byte, pr *pluralRule) string {
+	a.out = a.out[:0]
+	a.out = a.renderICU(a.out, nodes, buf, pr, -1, 0)
+	return byteconv.B2S(a.out)
+}
+
+// Render nodes to dst.
+//
+// Pound is a span of formatted plural value in args numbers buffer, negative offset means no plural value.
+func (a *Args) renderICU(dst []byte, nodes []rule, buf []byte, pr *pluralRule, poundOff, poundLen int) []byte {
+	for i := 0; i < len(nodes); i++ {
+		n := &nodes[i]
+		switch n.kind {
+		case ruleICUText:
+			if n.arg&icuTextQuoted == 0 {
+				dst = append(dst, n.bp.take(buf)...)
+			} else {
+				dst = appendICUText(dst, n.bp.take(buf), n.arg&icuTextPlural != 0)
+			}
+		case ruleICUArg:
+			name := n.bp.take(buf)
+			if v, ok := a.get(name); ok {
+				dst = append(dst, v...)
+			} else {
+				dst = append(dst, '{')
+				dst = append(dst, name...)
+				dst = append(dst, '}')
+			}
+		case ruleICUPound:
+			if poundOff >= 0 {
+				dst = append(dst, a.num[poundOff:poundOff+poundLen]...)
+			}
+		case ruleICUPlural, ruleICUOrdinal, ruleICUSelect:
+			sub := nodes[i+1 : i+1+int(n.hi)]
+			dst = a.renderComplex(dst, n, sub, buf, pr, poundOff, poundLen)
+			i += int(n.hi)
+		}
+	}
+	return dst
+}
+
+// Render branch of plural, selectordinal or select node n.
+func (a *Args) renderComplex(dst []byte, n *rule, sub []rule, buf []byte, pr *pluralRule, poundOff, poundLen int) []byte {
+	v, ok := a.get(n.bp.take(buf))
+	br, other := -1, -1
+
+	if n.kind == ruleICUSelect {
+		for j := 0; j < len(sub); j += int(sub[j].hi) + 1 {
+			sel := sub[j].bp.take(buf)
+			if ok && sel == v {
+				br = j
+				break
+			}
+			if sel == "other" && other == -1 {
+				other = j
+			}
+		}
+		if br == -1 {
+			br = other
+		}
+		if br == -1 {
+			return dst
+		}
+		return a.renderICU(dst, sub[br+1:br+1+int(sub[br].hi)], buf, pr, poundOff, poundLen)
+	}
+
+	// Format plural value considering offset.
+	var q query
+	num := ok && q.parseDecimal(v)
+	start := len(a.num)
+	switch {
+	case !ok:
+	case !num || n.lo == 0:
+		a.num = append(a.num, v...)
+	case !q.dec || q.op.v == 0:
+		a.num = strconv.AppendInt(a.num, q.count-n.lo, 10)
+	default:
+		f, _ := strconv.ParseFloat(v, 64)
+		a.num = strconv.AppendFloat(a.num, f-float64(n.lo), 'f', q.op.v, 64)
+	}
+	pound := a.num[start:]
+
+	cat := PluralOther
+	if num {
+		var qo query
+		if qo.parseDecimal(byteconv.B2S(pound)) {
+			f := formOther
+			if pr != nil {
+				if f = pr.card; n.kind == ruleICUOrdinal {
+					f = pr.ord
+				}
+			}
+			cat, _ = f.category(qo.operands())
+		}
+	}
+	// Exact selectors have priority over categories.
+	for j := 0; j < len(sub); j += int(sub[j].hi) + 1 {
+		b := &sub[j]
+		if b.arg&icuBranchExact != 0 {
+			if num && !q.frac() && q.count == b.lo {
+				br = j
+				break
+			}
+			continue
+		}
+		if PluralCategory(b.lo) == cat && br == -1 {
+			br = j
+		}
+		if PluralCategory(b.lo) == PluralOther && other == -1 {
+			other = j
+		}
+	}
+	if br == -1 {
+		br = other
+	}
+	if br != -1 {
+		dst = a.renderICU(dst, sub[br+1:br+1+int(sub[br].hi)], buf, pr, start, len(pound))
+	}
+	a.num = a.num[:start]
+	return dst
+}
@@ -0,0 +1,194 @@
+package i18n
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+func TestICU(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	msgs := map[string]string{
+		"en.cart.items":  "{count, plural, =0 {Cart is empty} one {# item in cart} other {# items in cart}}",
+		"ru.cart.items":  "{count, plural, one {# товар} few {# товара} many {# товаров} other {# товара}}",
+		"en.race.place":  "You finished {place, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}!",
+		"en.invite":      "{host} invites {guests, plural, offset:1 =0 {nobody} =1 {{guest}} one {{guest} and one other} other {{guest} and # others}} to {gender, select, female {her} male {his} other {their}} party.",
+		"en.quote":       "It''s '{'literal'}' text, '#' isn't special here",
+		"en.quote_pl":    "{n, plural, other {'#' is # and it''s fine}}",
+		"en.nested":      "{gender, select, female {{n, plural, one {She has # cat} other {She has # cats}}} other {{n, plural, one {They have # cat} other {They have # cats}}}}",
+		"en.format":      "Total: {sum, number, ::currency/USD} on {date, date, short}",
+		"en.hours":       "{h, plural, one {# hour} other {# hours}}",
+		"unknown.locale": "{n, plural, one {one} other {other}}",
+	}
+	for k, v := range msgs {
+		if err := db.SetICU(k, v); err != nil {
+			t.Fatalf("%s: %s", k, err)
+		}
+	}
+
+	var args Args
+	assertICU := func(t *testing.T, key, expect string) {
+		if s := db.GetICU(key, "N/D", &args); s != expect {
+			t.Errorf("%s: need %q got %q", key, expect, s)
+		}
+		args.Reset()
+	}
+
+	args.Int("count", 0)
+	assertICU(t, "en.cart.items", "Cart is empty")
+	args.Int("count", 1)
+	assertICU(t, "en.cart.items", "1 item in cart")
+	args.Int("count", 42)
+	assertICU(t, "en.cart.items", "42 items in cart")
+	args.Int("count", 22)
+	assertICU(t, "ru.cart.items", "22 товара")
+	args.Int("count", 11)
+	assertICU(t, "ru.cart.items", "11 товаров")
+	args.Decimal("count", "1.5")
+	assertICU(t, "ru.cart.items", "1.5 товара")
+
+	args.Int("place", 2)
+	assertICU(t, "en.race.place", "You finished 2nd!")
+	args.Int("place", 13)
+	assertICU(t, "en.race.place", "You finished 13th!")
+
+	args.Str("host", "Alice").Str("guest", "Bob").Int("guests", 1).Str("gender", "female")
+	assertICU(t, "en.invite", "Alice invites Bob to her party.")
+	args.Str("host", "Carl").Str("guest", "Bob").Int("guests", 2).Str("gender", "male")
+	assertICU(t, "en.invite", "Carl invites Bob and one other to his party.")
+	args.Str("host", "Dana").Str("guest", "Bob").Int("guests", 5).Str("gender", "x")
+	assertICU(t, "en.invite", "Dana invites Bob and 4 others to their party.")
+	args.Str("host", "Eve").Int("guests", 0)
+	assertICU(t, "en.invite", "Eve invites nobody to their party.")
+
+	assertICU(t, "en.quote", "It's {literal} text, '#' isn't special here")
+	args.Int("n", 3)
+	assertICU(t, "en.quote_pl", "# is 3 and it's fine")
+
+	args.Str("gender", "female").Int("n", 1)
+	assertICU(t, "en.nested", "She has 1 cat")
+	args.Str("gender", "male").Int("n", 3)
+	assertICU(t, "en.nested", "They have 3 cats")
+
+	args.Str("sum", "$10").Str("date", "10/17/26")
+	assertICU(t, "en.format", "Total: $10 on 10/17/26")
+	// Missing arguments stay as is.
+	assertICU(t, "en.format", "Total: {sum} on {date}")
+
+	args.Decimal("h", "1.0")
+	assertICU(t, "en.hours", "1.0 hours")
+	args.Float("h", 1)
+	assertICU(t, "en.hours", "1 hour")
+
+	args.Int("n", 1)
+	assertICU(t, "unknown.locale", "other")
+	assertICU(t, "en.missing", "N/D")
+
+	t.Run("replacer", func(t *testing.T) {
+		var repl PlaceholderReplacer
+		repl.AddKV("!count", "1")
+		args.Replacer(&repl)
+		assertICU(t, "en.cart.items", "1 item in cart")
+
+		// Key format applies only to pairs added after it's set.
+		repl.Reset()
+		args.Reset()
+		repl.SetKeyFormat("", "").AddKV("count}", "2")
+		repl.SetKeyFormat("%{", "}").AddKV("n", "3")
+		args.Replacer(&repl)
+		if v, _ := args.get("count}"); v != "2" {
+			t.Errorf("argument mismatch, need 2 got %q", v)
+		}
+		if v, _ := args.get("n"); v != "3" {
+			t.Errorf("argument mismatch, need 3 got %q", v)
+		}
+		repl.SetKeyFormat("", "")
+	})
+	t.Run("regular", func(t *testing.T) {
+		_ = db.Set("en.plain", "Hello!")
+		assertICU(t, "en.plain", "Hello!")
+		// Regular getters return ICU message as is.
+		if s := db.Get("en.hours", ""); s != msgs["en.hours"] {
+			t.Errorf("need raw message got %q", s)
+		}
+		// Overwrite ICU message with regular translation and vice versa.
+		_ = db.Set("en.hours", "hour|hours")
+		assertT9nPlural(t, db, "en.hours", "hours", 2)
+		_ = db.SetICU("en.hours", msgs["en.hours"])
+		args.Int("h", 2)
+		assertICU(t, "en.hours", "2 hours")
+	})
+	t.Run("localizer", func(t *testing.T) {
+		l := db.Localizer("ru")
+		args.Int("count", 5)
+		if s := l.GetICU("cart.items", "", &args); s != "5 товаров" {
+			t.Errorf("localizer mismatch, need 5 товаров got %s", s)
+		}
+		args.Reset()
+	})
+	t.Run("txn", func(t *testing.T) {
+		tx := db.Begin()
+		_ = tx.SetICU("en.txn", "{n, plural, one {# txn} other {# txns}}")
+		if err := tx.SetICU("en.txn_bad", "{n, plural, one {# txn}}"); err != ErrBadICU {
+			t.Errorf("need ErrBadICU got %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		args.Int("n", 3)
+		assertICU(t, "en.txn", "3 txns")
+	})
+	t.Run("dump", func(t *testing.T) {
+		db.Compact()
+		args.Int("count", 1)
+		assertICU(t, "en.cart.items", "1 item in cart")
+
+		var buf bytes.Buffer
+		if _, err := db.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		db1, _ := New(xxhash.Hasher64[string]{})
+		if _, err := db1.ReadFrom(&buf); err != nil {
+			t.Fatal(err)
+		}
+		args.Str("host", "Alice").Str("guest", "Bob").Int("guests", 3).Str("gender", "male")
+		if s := db1.GetICU("en.invite", "", &args); s != "Alice invites Bob and 2 others to his party." {
+			t.Errorf("dump mismatch, got %q", s)
+		}
+		args.Reset()
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, msg := range []string{
+			"{",
+			"}",
+			"{}",
+			"{n",
+			"{n, plural, one {x}}",
+			"{n, plural, uno {x} other {y}}",
+			"{n, plural, =x {x} other {y}}",
+			"{n, plural, offset:x other {y}}",
+			"{n, select, male {x}}",
+			"{n, plural, other {x}",
+			"{n, plural, other x}",
+			"{n, number, currency",
+			"{n, , x}",
+		} {
+			if err := db.SetICU("en.bad", msg); err != ErrBadICU {
+				t.Errorf("%q: need ErrBadICU got %v", msg, err)
+			}
+		}
+	})
+}
+
+func BenchmarkICU(b *testing.B) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	_ = db.SetICU("en.invite", "{host} invites {guests, plural, offset:1 =0 {nobody} =1 {{guest}} one {{guest} and one other} other {{guest} and # others}} to {gender, select, female {her} male {his} other {their}} party.")
+	var args Args
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		args.Str("host", "Alice").Str("guest", "Bob").Int("guests", 5).Str("gender", "female")
+		_ = db.GetICU("en.invite", "", &args)
+		args.Reset()
+	}
+}
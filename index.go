@@ -2,30 +2,172 @@ package i18n
 
 import "github.com/koykov/entry"
 
-// Index stores hashed key-entry pairs.
+// Count of index pages and count of shards in each page.
+const (
+	indexPages  = 256
+	indexShards = 256
+)
+
+// Special entry marks translation of underlying DB as removed in overlay DB.
+const entryTomb = entry.Entry64(1<<64 - 1)
+
+// Shards is a set of hashed key-entry maps grouped to pages.
 //
 // Hashed key uses to reduce pointers in the package to follow pointers policy.
 // Entry as uint64 value uses due to impossibility to take a pointer of map value.
-type index map[uint64]entry.Entry64
+type shards struct {
+	top *indexTop
+}
+
+// Top level of shards.
+type indexTop struct {
+	pages [indexPages]*indexPage
+	// Generation of index when the top was made.
+	gen uint64
+}
+
+// Page of shards.
+type indexPage struct {
+	maps [indexShards]map[uint64]entry.Entry64
+	// Generations of index when the page and its maps were made.
+	gens [indexShards]uint64
+	gen  uint64
+}
+
+// Get entry by given key.
+func (s shards) get(key uint64) entry.Entry64 {
+	e, _ := s.lookup(key)
+	return e
+}
+
+// Get entry by given key and check if it exists.
+func (s shards) lookup(key uint64) (entry.Entry64, bool) {
+	if s.top == nil {
+		return 0, false
+	}
+	p := s.top.pages[key%indexPages]
+	if p == nil {
+		return 0, false
+	}
+	e, ok := p.maps[key/indexPages%indexShards][key]
+	return e, ok
+}
+
+// Iterate over all entries.
+//
+// Index may be modified inside fn.
+func (s shards) each(fn func(key uint64, e entry.Entry64)) {
+	if s.top == nil {
+		return
+	}
+	for pi := 0; pi < indexPages; pi++ {
+		p := s.top.pages[pi]
+		if p == nil {
+			continue
+		}
+		for si := 0; si < indexShards; si++ {
+			for key, e := range p.maps[si] {
+				fn(key, e)
+			}
+		}
+	}
+}
+
+// Index stores hashed key-entry pairs.
+//
+// Index is persistent to reduce cost of copy-on-write: published shards are shared with readers, modification copies
+// only the path to modified map (top, page and map itself) and only once after publishing. Parts made after the last
+// publishing have current generation and modify in place.
+type index struct {
+	shards
+	// Current generation, increments on publishing.
+	gen uint64
+	// Count of keys.
+	n int
+}
 
 // Save new entry.
 func (i *index) set(key uint64, lo, hi uint32) {
 	var e entry.Entry64
 	e.Encode(lo, hi)
-	(*i)[key] = e
+	i.put(key, e)
 }
 
-// Get entry by given key.
-func (i *index) get(key uint64) entry.Entry64 {
-	if e, ok := (*i)[key]; ok {
-		return e
+// Save entry as is.
+func (i *index) put(key uint64, e entry.Entry64) {
+	m := i.own(key)
+	if _, ok := m[key]; !ok {
+		i.n++
+	}
+	m[key] = e
+}
+
+// Remove entry by given key.
+func (i *index) del(key uint64) {
+	if _, ok := i.lookup(key); !ok {
+		return
+	}
+	delete(i.own(key), key)
+	i.n--
+}
+
+// Get count of keys.
+func (i *index) len() int {
+	return i.n
+}
+
+// Get writable map of key, copy the path to it if needed.
+func (i *index) own(key uint64) map[uint64]entry.Entry64 {
+	t := i.top
+	switch {
+	case t == nil:
+		t = &indexTop{gen: i.gen}
+	case t.gen != i.gen:
+		cpy := *t
+		cpy.gen = i.gen
+		t = &cpy
+	}
+	i.top = t
+
+	pi := key % indexPages
+	p := t.pages[pi]
+	switch {
+	case p == nil:
+		p = &indexPage{gen: i.gen}
+	case p.gen != i.gen:
+		cpy := *p
+		cpy.gen = i.gen
+		p = &cpy
+	}
+	t.pages[pi] = p
+
+	si := key / indexPages % indexShards
+	m := p.maps[si]
+	switch {
+	case m == nil:
+		m = make(map[uint64]entry.Entry64)
+	case p.gens[si] != i.gen:
+		cpy := make(map[uint64]entry.Entry64, len(m)+1)
+		for k, v := range m {
+			cpy[k] = v
+		}
+		m = cpy
+	default:
+		return m
 	}
-	return 0
+	p.maps[si], p.gens[si] = m, i.gen
+	return m
+}
+
+// Mark all parts of index as shared and return them to publish.
+func (i *index) publish() shards {
+	i.gen++
+	return i.shards
 }
 
 // Remove all keys from index.
+//
+// Shards don't clear in place since they may be in use by readers.
 func (i *index) reset() {
-	for h := range *i {
-		delete(*i, h)
-	}
+	i.shards, i.n = shards{}, 0
 }
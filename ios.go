@@ -0,0 +1,438 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// LoadStrings loads iOS/macOS .strings file of locale to db, eg:
+//
+//	/* Greeting on main page */
+//	"welcome" = "Hello, %@!";
+//
+// Key of translation is locale and key of string: "en.welcome". Format specifiers convert to placeholders "!1", "!2", ...
+// (see PlaceholderReplacer). File may be encoded in UTF-8 or UTF-16 with byte order mark.
+//
+// All translations save in single transaction, so nothing saves if file is malformed. Errors contain line number, see
+// ParseError.
+func LoadStrings(db *DB, locale string, r io.Reader) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	src, err := readUTF(r)
+	if err != nil {
+		return err
+	}
+	tx := db.Begin()
+	p := stringsParser{src: src, line: 1}
+	var buf []byte
+	for {
+		key, val, ok, err := p.next()
+		if err != nil {
+			tx.Rollback()
+			return &ParseError{Line: p.line, Err: err}
+		}
+		if !ok {
+			break
+		}
+		buf, _ = printfPlaceholders(buf[:0], val, 1)
+		t9n := string(buf)
+		buf = escT9n(buf[:0], t9n)
+		if err = tx.Set(locale+"."+key, string(buf)); err != nil {
+			tx.Rollback()
+			return &ParseError{Line: p.line, Err: err}
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadStringsdict loads iOS/macOS .stringsdict file of locale to db, eg:
+//
+//	<plist version="1.0"><dict>
+//	  <key>files</key>
+//	  <dict>
+//	    <key>NSStringLocalizedFormatKey</key><string>%#@count@ found</string>
+//	    <key>count</key>
+//	    <dict>
+//	      <key>NSStringFormatSpecTypeKey</key><string>NSStringPluralRuleType</string>
+//	      <key>NSStringFormatValueTypeKey</key><string>d</string>
+//	      <key>zero</key><string>No files</string>
+//	      <key>one</key><string>%d file</string>
+//	      <key>other</key><string>%d files</string>
+//	    </dict>
+//	  </dict>
+//	</dict></plist>
+//
+// Plural variable expands into format and entry folds to single translation with category keywords:
+// "{0} No files found|{one} !1 file found|{other} !1 files found". As iOS does, "zero" form serves count 0 in any
+// language. Format specifiers convert to placeholders "!1", "!2", ... (see PlaceholderReplacer). Formats with several
+// variables aren't supported.
+//
+// All translations save in single transaction, so nothing saves if file is malformed.
+func LoadStringsdict(db *DB, locale string, r io.Reader) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	dec := xml.NewDecoder(r)
+	root, err := plistRoot(dec)
+	if err != nil {
+		return err
+	}
+	if root.dict == nil {
+		return ErrBadStrings
+	}
+	tx := db.Begin()
+	var (
+		buf   []byte
+		forms []catForm
+	)
+	for i := 0; i < len(root.dict); i++ {
+		e := &root.dict[i]
+		if e.val.dict == nil && len(e.val.str) == 0 {
+			continue
+		}
+		if buf, forms, err = stringsdictT9n(buf[:0], forms[:0], &e.val); err == nil {
+			err = tx.Set(locale+"."+e.key, string(buf))
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Compose translation of stringsdict entry.
+func stringsdictT9n(dst []byte, forms []catForm, v *plistValue) ([]byte, []catForm, error) {
+	if v.dict == nil {
+		// Simple string.
+		var t9n []byte
+		t9n, _ = printfPlaceholders(nil, v.str, 1)
+		return escT9n(dst, string(t9n)), forms, nil
+	}
+	format, ok := v.get("NSStringLocalizedFormatKey")
+	if !ok || format.dict != nil {
+		return dst, forms, ErrBadStrings
+	}
+	// Find plural variable "%#@name@" or "%N$#@name@".
+	lo := strings.Index(format.str, "#@")
+	if lo == -1 {
+		var t9n []byte
+		t9n, _ = printfPlaceholders(nil, format.str, 1)
+		return escT9n(dst, string(t9n)), forms, nil
+	}
+	hi := strings.IndexByte(format.str[lo+2:], '@')
+	pct := strings.LastIndexByte(format.str[:lo], '%')
+	if hi == -1 || pct == -1 || strings.Contains(format.str[lo+2+hi:], "#@") {
+		return dst, forms, ErrBadStrings
+	}
+	name := format.str[lo+2 : lo+2+hi]
+	prefix, suffix := format.str[:pct], format.str[lo+3+hi:]
+	variable, ok := v.get(name)
+	if !ok || variable.dict == nil {
+		return dst, forms, ErrBadStrings
+	}
+	if typ, _ := variable.get("NSStringFormatSpecTypeKey"); typ.str != "NSStringPluralRuleType" {
+		return dst, forms, ErrBadStrings
+	}
+
+	// Variable consumes argument of explicit index or the next one.
+	pre, next := printfPlaceholders(nil, prefix, 1)
+	idx := next
+	if n, err := strconv.Atoi(strings.TrimSuffix(format.str[pct+1:lo], "$")); err == nil && n > 0 {
+		idx = n
+	} else {
+		next++
+	}
+	var t9n []byte
+	for i := 0; i < len(variable.dict); i++ {
+		e := &variable.dict[i]
+		var f catForm
+		if e.key == "zero" {
+			f.zero = true
+		} else if f.cat, ok = ParsePluralCategory(e.key); !ok {
+			// Service keys, eg NSStringFormatValueTypeKey.
+			continue
+		}
+		if e.val.dict != nil {
+			return dst, forms, ErrBadStrings
+		}
+		t9n = append(t9n[:0], pre...)
+		t9n, _ = printfPlaceholders(t9n, e.val.str, idx)
+		t9n, _ = printfPlaceholders(t9n, suffix, next)
+		f.t9n = string(t9n)
+		forms = append(forms, f)
+	}
+	if len(forms) == 0 {
+		return dst, forms, ErrBadStrings
+	}
+	sortCatForms(forms)
+	return catT9n(dst, forms), forms, nil
+}
+
+// Read whole contents of r converting UTF-16 to UTF-8 according byte order mark.
+func readUTF(r io.Reader) (string, error) {
+	p, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	var be bool
+	switch {
+	case bytes.HasPrefix(p, []byte{0xef, 0xbb, 0xbf}):
+		return string(p[3:]), nil
+	case bytes.HasPrefix(p, []byte{0xfe, 0xff}):
+		be = true
+	case bytes.HasPrefix(p, []byte{0xff, 0xfe}):
+	default:
+		return string(p), nil
+	}
+	p = p[2:]
+	if len(p)%2 != 0 {
+		return "", ErrBadStrings
+	}
+	u := make([]uint16, len(p)/2)
+	for i := 0; i < len(u); i++ {
+		if be {
+			u[i] = uint16(p[2*i])<<8 | uint16(p[2*i+1])
+		} else {
+			u[i] = uint16(p[2*i+1])<<8 | uint16(p[2*i])
+		}
+	}
+	return string(utf16.Decode(u)), nil
+}
+
+// Parser of .strings file.
+type stringsParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+// Get next key and value pair, false means end of file.
+func (p *stringsParser) next() (key, val string, ok bool, err error) {
+	if err = p.skip(); err != nil || p.pos == len(p.src) {
+		return
+	}
+	if key, err = p.token(); err != nil {
+		return
+	}
+	if err = p.expect('='); err != nil {
+		return
+	}
+	if val, err = p.token(); err != nil {
+		return
+	}
+	if err = p.expect(';'); err != nil {
+		return
+	}
+	return key, val, true, nil
+}
+
+// Skip whitespace and comments.
+func (p *stringsParser) skip() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\n':
+			p.line++
+			p.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "//"):
+			i := strings.IndexByte(p.src[p.pos:], '\n')
+			if i == -1 {
+				p.pos = len(p.src)
+				return nil
+			}
+			p.pos += i
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			i := strings.Index(p.src[p.pos+2:], "*/")
+			if i == -1 {
+				return ErrBadStrings
+			}
+			p.line += strings.Count(p.src[p.pos:p.pos+2+i], "\n")
+			p.pos += i + 4
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// Skip whitespace and check next byte is c.
+func (p *stringsParser) expect(c byte) error {
+	if err := p.skip(); err != nil {
+		return err
+	}
+	if p.pos == len(p.src) || p.src[p.pos] != c {
+		return ErrBadStrings
+	}
+	p.pos++
+	return nil
+}
+
+// Read quoted string or unquoted word.
+func (p *stringsParser) token() (string, error) {
+	if err := p.skip(); err != nil {
+		return "", err
+	}
+	if p.pos == len(p.src) {
+		return "", ErrBadStrings
+	}
+	if p.src[p.pos] != '"' {
+		i := p.pos
+		for i < len(p.src) && isStringsWord(p.src[i]) {
+			i++
+		}
+		if i == p.pos {
+			return "", ErrBadStrings
+		}
+		s := p.src[p.pos:i]
+		p.pos = i
+		return s, nil
+	}
+	p.pos++
+	var buf []byte
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return string(buf), nil
+		case '\n':
+			p.line++
+		case '\\':
+			if p.pos == len(p.src) {
+				return "", ErrBadStrings
+			}
+			c = p.src[p.pos]
+			p.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 't':
+				c = '\t'
+			case 'r':
+				c = '\r'
+			case 'U', 'u':
+				if p.pos+4 > len(p.src) {
+					return "", ErrBadStrings
+				}
+				n, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 16)
+				if err != nil {
+					return "", ErrBadStrings
+				}
+				buf = utf8.AppendRune(buf, rune(n))
+				p.pos += 4
+				continue
+			case '\n':
+				p.line++
+			}
+		}
+		buf = append(buf, c)
+	}
+	return "", ErrBadStrings
+}
+
+func isStringsWord(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_.$:/-", c) != -1
+}
+
+// Value of property list: string or dictionary.
+type plistValue struct {
+	str  string
+	dict []plistEntry
+}
+
+type plistEntry struct {
+	key string
+	val plistValue
+}
+
+// Get value of dictionary key.
+func (v *plistValue) get(key string) (*plistValue, bool) {
+	for i := 0; i < len(v.dict); i++ {
+		if v.dict[i].key == key {
+			return &v.dict[i].val, true
+		}
+	}
+	return &plistValue{}, false
+}
+
+// Read root value of property list.
+func plistRoot(dec *xml.Decoder) (*plistValue, error) {
+	var plist bool
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			return nil, ErrBadStrings
+		}
+		if err != nil {
+			return nil, err
+		}
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if !plist {
+			if se.Name.Local != "plist" {
+				return nil, ErrBadStrings
+			}
+			plist = true
+			continue
+		}
+		var v plistValue
+		if err = plistRead(dec, se, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	}
+}
+
+// Read value of element se. Values other than strings and dictionaries are skipped.
+func plistRead(dec *xml.Decoder, se xml.StartElement, v *plistValue) error {
+	switch se.Name.Local {
+	case "string":
+		return dec.DecodeElement(&v.str, &se)
+	case "dict":
+	default:
+		return dec.Skip()
+	}
+	v.dict = []plistEntry{}
+	var key *string
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch t := t.(type) {
+		case xml.EndElement:
+			if key != nil {
+				return ErrBadStrings
+			}
+			return nil
+		case xml.StartElement:
+			if key == nil {
+				if t.Name.Local != "key" {
+					return ErrBadStrings
+				}
+				var k string
+				if err = dec.DecodeElement(&k, &t); err != nil {
+					return err
+				}
+				key = &k
+				continue
+			}
+			v.dict = append(v.dict, plistEntry{key: *key})
+			if err = plistRead(dec, t, &v.dict[len(v.dict)-1].val); err != nil {
+				return err
+			}
+			key = nil
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package i18n
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+const testStrings = `/* Greeting
+   on main page */
+"welcome" = "Hello, %@! You have %d new messages.";
+// Unquoted key.
+close = "Close";
+"escapes" = "Say \"hi\"\n\U2192 a|b";
+"Open file" = "Open %1$@ in %2$@";
+`
+
+const testStringsdict = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>files</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%@ has %#@count@.</string>
+		<key>count</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>d</string>
+			<key>zero</key>
+			<string>no files</string>
+			<key>one</key>
+			<string>%d file</string>
+			<key>other</key>
+			<string>%d files</string>
+		</dict>
+	</dict>
+	<key>plain</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>Just %@</string>
+	</dict>
+	<key>version</key>
+	<integer>2</integer>
+</dict>
+</plist>`
+
+func TestStrings(t *testing.T) {
+	assertStrings := func(t *testing.T, db *DB) {
+		assertT9n(t, db, "en.welcome", "Hello, !1! You have !2 new messages.")
+		assertT9n(t, db, "en.close", "Close")
+		assertT9n(t, db, "en.escapes", "Say \"hi\"\n→ a|b")
+		assertT9n(t, db, "en.Open file", "Open !1 in !2")
+	}
+	db, _ := New(xxhash.Hasher64[string]{})
+	if err := LoadStrings(db, "en", strings.NewReader(testStrings)); err != nil {
+		t.Fatal(err)
+	}
+	assertStrings(t, db)
+
+	t.Run("utf16", func(t *testing.T) {
+		u := utf16.Encode([]rune("\ufeff" + testStrings))
+		var buf bytes.Buffer
+		for _, c := range u {
+			buf.WriteByte(byte(c))
+			buf.WriteByte(byte(c >> 8))
+		}
+		db, _ := New(xxhash.Hasher64[string]{})
+		if err := LoadStrings(db, "en", &buf); err != nil {
+			t.Fatal(err)
+		}
+		assertStrings(t, db)
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, c := range []struct {
+			src  string
+			line int
+		}{
+			{"\"a\" = \"x\";\n\"b\" = \"y\"\n", 3},
+			{"\"a\" = \"x\";\n\n\"b\" \"y\";", 3},
+			{"\"a\" = \"x\";\n/* comment", 2},
+			{"\"a\" = \"x\";\n\"b\" = \"y\n", 3},
+		} {
+			db, _ := New(xxhash.Hasher64[string]{})
+			err := LoadStrings(db, "en", strings.NewReader(c.src))
+			var perr *ParseError
+			if !errors.As(err, &perr) || perr.Line != c.line || !errors.Is(err, ErrBadStrings) {
+				t.Errorf("%q: need error at line %d, got %v", c.src, c.line, err)
+			}
+			if db.Get("en.a", "") != "" {
+				t.Errorf("%q: translations saved despite error", c.src)
+			}
+		}
+	})
+}
+
+func TestStringsdict(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	if err := LoadStringsdict(db, "en", strings.NewReader(testStringsdict)); err != nil {
+		t.Fatal(err)
+	}
+	assertT9nPlural(t, db, "en.files", "!1 has no files.", 0)
+	assertT9nPlural(t, db, "en.files", "!1 has !2 file.", 1)
+	assertT9nPlural(t, db, "en.files", "!1 has !2 files.", 5)
+	assertT9n(t, db, "en.plain", "Just !1")
+	assertT9n(t, db, "en.version", "")
+
+	t.Run("errors", func(t *testing.T) {
+		for _, src := range []string{
+			`<dict><key>a</key><string>x</string></dict>`,
+			`<plist><dict><key>a</key><string>x</string><key>b</key></dict></plist>`,
+			`<plist><dict><key>a</key><string>x</string><key>b</key><dict><key>NSStringLocalizedFormatKey</key>` +
+				`<string>%#@n@ and %#@m@</string></dict></dict></plist>`,
+			`<plist><dict><key>a</key><string>x</string><key>b</key><dict><key>NSStringLocalizedFormatKey</key>` +
+				`<string>%#@n@</string></dict></dict></plist>`,
+			`<plist><dict><key>a</key><string>x</string><key>b</key><dict><key>NSStringLocalizedFormatKey</key>` +
+				`<string>%#@n@</string><key>n</key><dict><key>NSStringFormatSpecTypeKey</key><string>Other</string>` +
+				`<key>one</key><string>y</string></dict></dict></dict></plist>`,
+		} {
+			db, _ := New(xxhash.Hasher64[string]{})
+			if err := LoadStringsdict(db, "en", strings.NewReader(src)); err == nil {
+				t.Errorf("%s: error expected", src)
+			}
+			if db.Get("en.a", "") != "" {
+				t.Errorf("%s: translations saved despite error", src)
+			}
+		}
+	})
+}
@@ -0,0 +1,353 @@
+package i18n
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// LoadJSON loads nested JSON translations to db, eg:
+//
+//	{"en": {"messages": {"welcome": "Hi", "apples_one": "one apple", "apples_other": "{{count}} apples"}}}
+//
+// Nested objects flatten to dotted keys: "en.messages.welcome". If locale isn't empty, it prefixes all keys, so file
+// of single locale may be loaded without top-level locale object.
+//
+// Keys with i18next plural suffixes "_zero", "_one", "_two", "_few", "_many" and "_other" fold to single translation
+// with category keywords: "{one} one apple|{other} {{count}} apples". Suffix "_zero" becomes exact rule "{0}" and serves
+// count 0 in any language, as i18next does.
+//
+// All translations save in single transaction, so nothing saves if JSON is malformed.
+func LoadJSON(db *DB, locale string, r io.Reader) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	tx := db.Begin()
+	l := jsonLoader{dec: dec, tx: tx}
+	err := l.load(locale)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// DumpJSON writes translations of locale to w as nested JSON.
+//
+// Empty locale means all translations with top-level locale objects. Plural translations write as i18next plural
+// suffixes (see LoadJSON()). Translations which LoadJSON() can't restore return ErrUnrepresentable: contextual keys,
+// plural translations which can't be expressed by suffixes, ICU messages and keys with plural suffix itself.
+func DumpJSON(db *DB, locale string, w io.Writer) error {
+	if err := db.checkStatus(); err != nil {
+		return err
+	}
+	var prefix string
+	if len(locale) > 0 {
+		prefix = locale + "."
+	}
+	var (
+		root  jsonNode
+		forms []catForm
+		err   error
+	)
+	db.snap().eachPrefix(prefix, func(key string, rules []rule, buf []byte) {
+		if err != nil {
+			return
+		}
+		key, ctx := splitCtx(key)
+		path := key[len(prefix):]
+		if _, _, ok := jsonPluralSuffix(path); ok || len(ctx) > 0 || isICU(rules) {
+			err = ErrUnrepresentable
+			return
+		}
+		if bare, ok := bareForms(nil, rules, buf); ok && len(bare) == 1 {
+			err = root.add(path, bare[0])
+			return
+		}
+		loc, _ := splitKey(key)
+		if len(locale) > 0 {
+			loc = locale
+		}
+		var ok bool
+		if forms, ok = categoryForms(forms[:0], rules, buf, db.plural(loc)); !ok {
+			err = ErrUnrepresentable
+			return
+		}
+		for i := 0; i < len(forms) && err == nil; i++ {
+			f := &forms[i]
+			suffix := "_zero"
+			if !f.zero {
+				suffix = "_" + f.cat.String()
+			}
+			err = root.add(path+suffix, f.t9n)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	root.write(bw, 0)
+	_ = bw.WriteByte('\n')
+	return bw.Flush()
+}
+
+// Streaming loader of nested JSON.
+type jsonLoader struct {
+	dec *json.Decoder
+	tx  *Txn
+	buf []byte
+}
+
+// Leaf translation of JSON object.
+type jsonLeaf struct {
+	name, t9n string
+}
+
+func (l *jsonLoader) load(prefix string) error {
+	if err := l.delim('{'); err != nil {
+		return err
+	}
+	if err := l.object(prefix); err != nil {
+		return err
+	}
+	if _, err := l.dec.Token(); err != io.EOF {
+		return ErrBadJSON
+	}
+	return nil
+}
+
+// Check next token is delimiter d.
+func (l *jsonLoader) delim(d json.Delim) error {
+	t, err := l.dec.Token()
+	if err != nil {
+		return err
+	}
+	if t != d {
+		return ErrBadJSON
+	}
+	return nil
+}
+
+// Load object contents after opening bracket.
+func (l *jsonLoader) object(prefix string) error {
+	var leaves []jsonLeaf
+	for l.dec.More() {
+		t, err := l.dec.Token()
+		if err != nil {
+			return err
+		}
+		name, _ := t.(string)
+		if t, err = l.dec.Token(); err != nil {
+			return err
+		}
+		switch v := t.(type) {
+		case string:
+			leaves = append(leaves, jsonLeaf{name: name, t9n: v})
+		case nil:
+		case json.Delim:
+			if v != '{' {
+				return ErrBadJSON
+			}
+			if err = l.object(jsonKey(prefix, name)); err != nil {
+				return err
+			}
+		default:
+			return ErrBadJSON
+		}
+	}
+	if err := l.delim('}'); err != nil {
+		return err
+	}
+	return l.flush(prefix, leaves)
+}
+
+// Save leaves of object folding plural suffixes.
+func (l *jsonLoader) flush(prefix string, leaves []jsonLeaf) error {
+	var (
+		plurals map[string][]catForm
+		order   []string
+	)
+	for i := 0; i < len(leaves); i++ {
+		lf := &leaves[i]
+		if base, f, ok := jsonPluralSuffix(lf.name); ok {
+			if plurals == nil {
+				plurals = make(map[string][]catForm)
+			}
+			if _, ok := plurals[base]; !ok {
+				order = append(order, base)
+			}
+			f.t9n = lf.t9n
+			plurals[base] = append(plurals[base], f)
+		}
+	}
+	for i := 0; i < len(leaves); i++ {
+		lf := &leaves[i]
+		if _, _, ok := jsonPluralSuffix(lf.name); ok {
+			continue
+		}
+		if forms, ok := plurals[lf.name]; ok {
+			// Key without suffix serves as "other" form if it's missing.
+			if !hasCategory(forms, PluralOther) {
+				plurals[lf.name] = append(forms, catForm{cat: PluralOther, t9n: lf.t9n})
+			}
+			continue
+		}
+		l.buf = escT9n(l.buf[:0], lf.t9n)
+		if err := l.tx.Set(jsonKey(prefix, lf.name), string(l.buf)); err != nil {
+			return err
+		}
+	}
+	for _, base := range order {
+		forms := plurals[base]
+		sortCatForms(forms)
+		l.buf = catT9n(l.buf[:0], forms)
+		if err := l.tx.Set(jsonKey(prefix, base), string(l.buf)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Join key prefix and name.
+func jsonKey(prefix, name string) string {
+	if len(prefix) == 0 {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// Split i18next plural suffix of key name, eg: "apples_one".
+func jsonPluralSuffix(name string) (base string, f catForm, ok bool) {
+	i := strings.LastIndexByte(name, '_')
+	if i <= 0 {
+		return
+	}
+	base, suffix := name[:i], name[i+1:]
+	if suffix == "zero" {
+		f.zero = true
+		return base, f, true
+	}
+	if f.cat, ok = ParsePluralCategory(suffix); !ok {
+		return "", f, false
+	}
+	return
+}
+
+// Check if forms contain category c.
+func hasCategory(forms []catForm, c PluralCategory) bool {
+	for i := 0; i < len(forms); i++ {
+		if !forms[i].zero && forms[i].cat == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Node of nested JSON tree.
+type jsonNode struct {
+	children map[string]*jsonNode
+	t9n      string
+	leaf     bool
+}
+
+// Add translation by dotted path.
+func (n *jsonNode) add(path, t9n string) error {
+	for {
+		if n.leaf {
+			return ErrKeyConflict
+		}
+		name := path
+		i := strings.IndexByte(path, '.')
+		if i != -1 {
+			name, path = path[:i], path[i+1:]
+		}
+		if n.children == nil {
+			n.children = make(map[string]*jsonNode)
+		}
+		c, ok := n.children[name]
+		if !ok {
+			c = &jsonNode{}
+			n.children[name] = c
+		}
+		if i == -1 {
+			if c.leaf || len(c.children) > 0 {
+				return ErrKeyConflict
+			}
+			c.t9n, c.leaf = t9n, true
+			return nil
+		}
+		n = c
+	}
+}
+
+// Write node with indentation depth.
+func (n *jsonNode) write(w *bufio.Writer, depth int) {
+	if n.leaf {
+		writeJSONString(w, n.t9n)
+		return
+	}
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	_ = w.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			_ = w.WriteByte(',')
+		}
+		_ = w.WriteByte('\n')
+		_, _ = w.WriteString(strings.Repeat("  ", depth+1))
+		writeJSONString(w, name)
+		_, _ = w.WriteString(": ")
+		n.children[name].write(w, depth+1)
+	}
+	if len(names) > 0 {
+		_ = w.WriteByte('\n')
+		_, _ = w.WriteString(strings.Repeat("  ", depth))
+	}
+	_ = w.WriteByte('}')
+}
+
+// Write quoted JSON string.
+func writeJSONString(w *bufio.Writer, s string) {
+	const hex = "0123456789abcdef"
+	_ = w.WriteByte('"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				_ = w.WriteByte('\\')
+				_ = w.WriteByte(c)
+			case c == '\n':
+				_, _ = w.WriteString(`\n`)
+			case c == '\r':
+				_, _ = w.WriteString(`\r`)
+			case c == '\t':
+				_, _ = w.WriteString(`\t`)
+			case c < 0x20:
+				_, _ = w.WriteString(`\u00`)
+				_ = w.WriteByte(hex[c>>4])
+				_ = w.WriteByte(hex[c&0xf])
+			default:
+				_ = w.WriteByte(c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			_, _ = w.WriteString(`�`)
+		} else {
+			_, _ = w.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	_ = w.WriteByte('"')
+}
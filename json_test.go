@@ -0,0 +1,146 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+const testJSON = `{
+  "en": {
+    "messages": {
+      "welcome": "Hi, {{name}}!",
+      "pipe": "a|b",
+      "nothing": null
+    },
+    "apples_zero": "no apples",
+    "apples_one": "one apple",
+    "apples_other": "{{count}} apples",
+    "cats_one": "one cat",
+    "cats": "many cats",
+    "place_ordinal_one": "{{count}}st",
+    "place_ordinal_two": "{{count}}nd",
+    "place_ordinal_few": "{{count}}rd",
+    "place_ordinal_other": "{{count}}th",
+    "user_name": "Name"
+  },
+  "ru": {
+    "files_one": "файл",
+    "files_few": "файла",
+    "files_many": "файлов"
+  }
+}`
+
+func TestJSON(t *testing.T) {
+	assertJSON := func(t *testing.T, db *DB) {
+		assertT9n(t, db, "en.messages.welcome", "Hi, {{name}}!")
+		assertT9n(t, db, "en.messages.pipe", "a|b")
+		assertT9n(t, db, "en.messages.nothing", "")
+		assertT9nPlural(t, db, "en.apples", "no apples", 0)
+		assertT9nPlural(t, db, "en.apples", "one apple", 1)
+		assertT9nPlural(t, db, "en.apples", "{{count}} apples", 5)
+		assertT9nPlural(t, db, "en.cats", "one cat", 1)
+		assertT9nPlural(t, db, "en.cats", "many cats", 3)
+		assertT9n(t, db, "en.user_name", "Name")
+		if s := db.GetOrdinal("en.place_ordinal", "", 23); s != "{{count}}rd" {
+			t.Errorf("ordinal mismatch, got %q", s)
+		}
+		assertT9nPlural(t, db, "ru.files", "файла", 22)
+		assertT9nPlural(t, db, "ru.files", "файлов", 5)
+	}
+
+	db, _ := New(xxhash.Hasher64[string]{})
+	if err := LoadJSON(db, "", strings.NewReader(testJSON)); err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, db)
+
+	t.Run("locale", func(t *testing.T) {
+		db, _ := New(xxhash.Hasher64[string]{})
+		if err := LoadJSON(db, "de", strings.NewReader(`{"menu": {"open": "Öffnen"}}`)); err != nil {
+			t.Fatal(err)
+		}
+		assertT9n(t, db, "de.menu.open", "Öffnen")
+	})
+	t.Run("dump", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := DumpJSON(db, "", &buf); err != nil {
+			t.Fatal(err)
+		}
+		var tree map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &tree); err != nil {
+			t.Fatal(err)
+		}
+		db1, _ := New(xxhash.Hasher64[string]{})
+		if err := LoadJSON(db1, "", &buf); err != nil {
+			t.Fatal(err)
+		}
+		assertJSON(t, db1)
+
+		// Bare forms dump as plural suffixes of locale categories.
+		_ = db1.Set("ru.dirs", "папка|папки|папок|папки")
+		buf.Reset()
+		if err := DumpJSON(db1, "ru", &buf); err != nil {
+			t.Fatal(err)
+		}
+		expect := `{
+  "dirs_few": "папки",
+  "dirs_many": "папок",
+  "dirs_one": "папка",
+  "dirs_other": "папки",
+  "files_few": "файла",
+  "files_many": "файлов",
+  "files_one": "файл"
+}
+`
+		if buf.String() != expect {
+			t.Errorf("dump mismatch, need\n%s\ngot\n%s", expect, buf.String())
+		}
+
+		_ = db1.Set("ru.menu", "Меню")
+		_ = db1.Set("ru.menu.open", "Открыть")
+		if err := DumpJSON(db1, "ru", &buf); err != ErrKeyConflict {
+			t.Errorf("need ErrKeyConflict got %v", err)
+		}
+	})
+	t.Run("unrepresentable", func(t *testing.T) {
+		set := []func(db *DB){
+			func(db *DB) { _ = db.SetCtx("verb", "en.open", "Open") },
+			func(db *DB) { _ = db.SetCtx("one", "en.open", "Open") },
+			func(db *DB) { _ = db.Set("en.range", "[0,5] few|many") },
+			func(db *DB) { _ = db.Set("en.gender", "{male} He|{female} She|{other} They") },
+			func(db *DB) { _ = db.SetICU("en.files", "{n, plural, one {# file} other {# files}}") },
+			func(db *DB) { _ = db.Set("en.apples_one", "one apple") },
+			func(db *DB) { _ = db.Set("en.apples", "some apples|{0} no apples") },
+		}
+		for i, fn := range set {
+			db, _ := New(xxhash.Hasher64[string]{})
+			_ = db.Set("en.open", "Open")
+			fn(db)
+			var buf bytes.Buffer
+			if err := DumpJSON(db, "en", &buf); err != ErrUnrepresentable {
+				t.Errorf("#%d: need ErrUnrepresentable got %v", i, err)
+			}
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, src := range []string{
+			`[]`,
+			`{"en": {"a": 1}}`,
+			`{"en": {"a": ["x"]}}`,
+			`{"en": {"a": "x"}`,
+			`{"en": {"a": "x"}} {}`,
+		} {
+			db, _ := New(xxhash.Hasher64[string]{})
+			if err := LoadJSON(db, "", strings.NewReader(src)); err == nil {
+				t.Errorf("%s: error expected", src)
+			}
+			if db.Get("en.a", "") != "" {
+				t.Errorf("%s: translations saved despite error", src)
+			}
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package i18n
+
+// Localizer is a lightweight locale-bound accessor of DB.
+//
+// Localizer takes keys without locale prefix and uses prehashed locale, so lookups don't need any concatenation.
+// Locale fallback chain and plural rules of its locales resolve once on Localizer creation.
+type Localizer struct {
+	db *DB
+	// Locale chain, the first item is the locale itself.
+	chain []string
+	// Hashes of chain locales.
+	hchain []uint64
+	// Plural rules of chain locales.
+	plurals []*pluralRule
+}
+
+// Localizer makes new localizer of given locale, eg: "en" or "ru-RU".
+func (db *DB) Localizer(locale string) Localizer {
+	l := Localizer{db: db}
+	if err := db.checkStatus(); err != nil || len(locale) == 0 {
+		return l
+	}
+	var a [fallbackDepth]string
+	chain := append(a[:0], locale)
+	if fb := db.fallback(); fb != nil {
+		chain = fb.chain(chain[:0], locale)
+	}
+	l.chain = append(l.chain, chain...)
+	for i := 0; i < len(l.chain); i++ {
+		l.hchain = append(l.hchain, db.hasher.Sum64(l.chain[i]))
+		l.plurals = append(l.plurals, db.plural(l.chain[i]))
+	}
+	return l
+}
+
+// Locale returns the locale of localizer.
+func (l Localizer) Locale() string {
+	if len(l.chain) == 0 {
+		return ""
+	}
+	return l.chain[0]
+}
+
+// Get returns a translation of key.
+//
+// If translation doesn't exist, def will be used instead.
+func (l Localizer) Get(key, def string) string {
+	return l.GetPluralWR(key, def, 1, nil)
+}
+
+// GetWR returns a translation of key with replacer.
+//
+// See DB.GetWR().
+func (l Localizer) GetWR(key, def string, repl *PlaceholderReplacer) string {
+	return l.GetPluralWR(key, def, 1, repl)
+}
+
+// GetPlural returns a translation using plural formula.
+func (l Localizer) GetPlural(key, def string, count int) string {
+	return l.GetPluralWR(key, def, count, nil)
+}
+
+// GetPluralWR returns a translation using plural formula with replacer.
+//
+// See DB.GetPluralWR().
+func (l Localizer) GetPluralWR(key, def string, count int, repl *PlaceholderReplacer) string {
+	return l.get(key, def, &query{count: int64(count)}, repl)
+}
+
+// GetOrdinal returns a translation using ordinal plural rules.
+func (l Localizer) GetOrdinal(key, def string, n int) string {
+	return l.GetOrdinalWR(key, def, n, nil)
+}
+
+// GetOrdinalWR returns a translation using ordinal plural rules with replacer.
+//
+// See DB.GetOrdinalWR().
+func (l Localizer) GetOrdinalWR(key, def string, n int, repl *PlaceholderReplacer) string {
+	return l.get(key, def, &query{count: int64(n), ord: true}, repl)
+}
+
+// GetPluralFloat returns a translation using plural formula with fractional count.
+//
+// See DB.GetPluralFloat().
+func (l Localizer) GetPluralFloat(key, def string, count float64) string {
+	return l.GetPluralFloatWR(key, def, count, nil)
+}
+
+// GetPluralFloatWR returns a translation using plural formula with fractional count and replacer.
+func (l Localizer) GetPluralFloatWR(key, def string, count float64, repl *PlaceholderReplacer) string {
+	var q query
+	if !q.parseFloat(count) {
+		return l.get(key, def, nil, repl)
+	}
+	return l.get(key, def, &q, repl)
+}
+
+// GetPluralDecimal returns a translation using plural formula with decimal count given as string.
+//
+// See DB.GetPluralDecimal().
+func (l Localizer) GetPluralDecimal(key, def, count string) string {
+	return l.GetPluralDecimalWR(key, def, count, nil)
+}
+
+// GetPluralDecimalWR returns a translation using plural formula with decimal count and replacer.
+func (l Localizer) GetPluralDecimalWR(key, def, count string, repl *PlaceholderReplacer) string {
+	var q query
+	if !q.parseDecimal(count) {
+		return l.get(key, def, nil, repl)
+	}
+	return l.get(key, def, &q, repl)
+}
+
+// Inner getter of translation.
+//
+// Nil query means invalid count, def will be used.
+func (l Localizer) get(key, def string, q *query, repl *PlaceholderReplacer) string {
+	var raw string
+	if q != nil {
+		raw, _, _ = l.lookup(key, q)
+	}
+	if len(raw) == 0 {
+		raw = def
+	}
+	if len(raw) == 0 {
+		return ""
+	}
+
+	if repl != nil && repl.Size() > 0 {
+		return repl.Commit(raw)
+	}
+
+	return raw
+}
+
+// Lookup returns raw translation of key using plural formula and the locale of found translation.
+//
+// See DB.Lookup().
+func (l Localizer) Lookup(key string, count int) (t9n, locale string, ok bool) {
+	return l.lookup(key, &query{count: int64(count)})
+}
+
+// Inner lookup over locales chain.
+func (l Localizer) lookup(key string, q *query) (t9n, locale string, ok bool) {
+	if l.db == nil || len(l.chain) == 0 || len(key) == 0 {
+		return
+	}
+	if err := l.db.checkStatus(); err != nil {
+		return
+	}
+	s := l.db.snap()
+	hkey := l.db.hasher.Sum64(l.db.splitCtx(key, q))
+	for i := 0; i < len(l.chain); i++ {
+		if t9n, ok = s.get(withCtx(hcombine(l.hchain[i], hkey), q.hctx), q, l.plurals[i]); ok {
+			return t9n, l.chain[i], true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,60 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+func TestLocalizer(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	_ = db.Set("en.messages.welcome", "Hello there!")
+	_ = db.Set("en.messages.bye", "Goodbye!")
+	_ = db.Set("en.user.bag.apples", "You have !count apple|You have !count apples")
+	_ = db.Set("ru.messages.welcome", "Привет!")
+
+	assertGet := func(t *testing.T, l Localizer, key, expect string) {
+		if s := l.Get(key, ""); s != expect {
+			t.Errorf("translation mismatch, need %s got %s", expect, s)
+		}
+	}
+
+	t.Run("simple", func(t *testing.T) {
+		l := db.Localizer("ru")
+		assertGet(t, l, "messages.welcome", "Привет!")
+		assertGet(t, l, "messages.bye", "")
+	})
+	t.Run("plural", func(t *testing.T) {
+		l := db.Localizer("en")
+		repl := PlaceholderReplacer{}
+		repl.AddKV("!count", "5")
+		if s := l.GetPluralWR("user.bag.apples", "", 5, &repl); s != "You have 5 apples" {
+			t.Error("translation mismatch, need You have 5 apples got", s)
+		}
+	})
+
+	db.SetDefaultLocale("en")
+	t.Run("fallback", func(t *testing.T) {
+		l := db.Localizer("ru-RU")
+		assertGet(t, l, "messages.welcome", "Привет!")
+		assertGet(t, l, "messages.bye", "Goodbye!")
+		if _, locale, _ := l.Lookup("messages.bye", 1); locale != "en" {
+			t.Error("locale mismatch, need en got", locale)
+		}
+	})
+}
+
+func BenchmarkLocalizer(b *testing.B) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	_ = db.Set("en.messages.welcome", "Hello there!")
+	db.SetDefaultLocale("en")
+	l := db.Localizer("ru-RU")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if s := l.Get("messages.welcome", ""); s != "Hello there!" {
+			b.Error("translation mismatch")
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package i18n
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/koykov/hash"
+)
+
+// OpenMmap opens binary dump file (see DB.WriteTo()) as read-only DB.
+//
+// Translations and rules storages of such DB point straight to the memory mapped file, so many processes may share the
+// same memory pages. Any write to DB fails with ErrReadOnly, use Overlay() to get writable DB on top of it.
+func OpenMmap(path string, hasher hash.Hasher[string]) (*DB, error) {
+	if hasher == nil {
+		return nil, ErrNoHasher
+	}
+	data, err := mmapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	db := &DB{
+		status: statusActive,
+		hasher: hasher,
+		ro:     true,
+		mmap:   data,
+	}
+	d, err := db.decodeBin(data)
+	if err != nil {
+		_ = munmapFile(data)
+		return nil, err
+	}
+	s := &snapshot{
+		table: table(d.index),
+		rules: mapRules(d.rules),
+		buf:   d.buf[:d.bufLen],
+		kbuf:  d.kbuf[:d.kbufLen],
+	}
+	atomic.StorePointer(&db.sp, unsafe.Pointer(s))
+	return db, nil
+}
+
+// Overlay makes writable DB on top of db.
+//
+// Overlay DB uses the data of db at the moment of the call and collects its own changes: new translations override
+// the underlying ones and removals hide them. Underlying DB must not be closed while overlay is in use.
+func (db *DB) Overlay() (*DB, error) {
+	if err := db.checkStatus(); err != nil {
+		return nil, err
+	}
+	o := &DB{
+		status: statusActive,
+		hasher: db.hasher,
+		base:   db.snap(),
+	}
+	o.publishLF()
+	return o, nil
+}
+
+// Close releases DB resources, DB becomes unusable after that.
+//
+// Memory mapped DB must not be in use by any reader or overlay during the closing.
+func (db *DB) Close() error {
+	if !atomic.CompareAndSwapUint32(&db.status, statusActive, statusNil) {
+		return ErrBadDB
+	}
+	if db.mmap != nil {
+		return munmapFile(db.mmap)
+	}
+	return nil
+}
+
+// Get rules storage from rules section of binary dump.
+//
+// Section maps as is if the rule layout matches the dump, otherwise it will be decoded.
+func mapRules(p []byte) []rule {
+	n := len(p) / binRuleSize
+	if n == 0 {
+		return nil
+	}
+	if nativeLE() && unsafe.Sizeof(rule{}) == binRuleSize && uintptr(unsafe.Pointer(&p[0]))%unsafe.Alignof(rule{}) == 0 {
+		return unsafe.Slice((*rule)(unsafe.Pointer(&p[0])), n)
+	}
+	rules := make([]rule, n)
+	for i := 0; i < n; i++ {
+		rules[i] = decodeBinRule(p[i*binRuleSize:])
+	}
+	return rules
+}
+
+// Check if the host is little-endian.
+func nativeLE() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+
+package i18n
+
+func mmapFile(_ string) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+func munmapFile(_ []byte) error {
+	return ErrUnsupported
+}
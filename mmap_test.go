@@ -0,0 +1,84 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+func TestMmap(t *testing.T) {
+	src, _ := New(xxhash.Hasher64[string]{})
+	for i := 0; i < 100; i++ {
+		_ = src.Set("en.key"+strconv.Itoa(i), "Hello there!")
+	}
+	_ = src.Set("en.apples", "There is one apple|There are many apples")
+	_ = src.Set("ru.welcome", "Привет!")
+
+	path := filepath.Join(t.TempDir(), "i18n.bin")
+	f, _ := os.Create(path)
+	if _, err := src.WriteTo(f); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	db, err := OpenMmap(path, xxhash.Hasher64[string]{})
+	if err == ErrUnsupported {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+
+	t.Run("read", func(t *testing.T) {
+		assertT9n(t, db, "en.key42", "Hello there!")
+		assertT9n(t, db, "en.unknown", "")
+		assertT9nPlural(t, db, "en.apples", "There are many apples", 5)
+		if st := db.Stats(); st.Entries != 102 {
+			t.Error("entries count mismatch, need 102 got", st.Entries)
+		}
+	})
+	t.Run("readonly", func(t *testing.T) {
+		if err := db.Set("en.key1", "foo"); err != ErrReadOnly {
+			t.Error("error mismatch, need ErrReadOnly got", err)
+		}
+		if err := db.Begin().Set("en.key1", "foo"); err != ErrReadOnly {
+			t.Error("error mismatch, need ErrReadOnly got", err)
+		}
+	})
+	t.Run("overlay", func(t *testing.T) {
+		o, _ := db.Overlay()
+		_ = o.Set("en.key1", "foo")
+		_ = o.Set("en.new", "bar")
+		_ = o.Delete("en.key2")
+		_ = o.DeleteLocale("ru")
+		assertT9n(t, o, "en.key1", "foo")
+		assertT9n(t, o, "en.key3", "Hello there!")
+		assertT9n(t, o, "en.new", "bar")
+		assertT9n(t, o, "en.key2", "")
+		assertT9n(t, o, "ru.welcome", "")
+		assertT9n(t, db, "en.key1", "Hello there!")
+		assertT9n(t, db, "en.key2", "Hello there!")
+
+		_ = o.Set("en.key2", "restored")
+		assertT9n(t, o, "en.key2", "restored")
+
+		// Dump of overlay contains merged data.
+		path1 := filepath.Join(t.TempDir(), "i18n1.bin")
+		f, _ := os.Create(path1)
+		_, _ = o.WriteTo(f)
+		_ = f.Close()
+		db1, _ := OpenMmap(path1, xxhash.Hasher64[string]{})
+		defer func() { _ = db1.Close() }()
+		assertT9n(t, db1, "en.key1", "foo")
+		assertT9n(t, db1, "en.key2", "restored")
+		assertT9n(t, db1, "en.key3", "Hello there!")
+		assertT9n(t, db1, "ru.welcome", "")
+		if st := db1.Stats(); st.Entries != 102 {
+			t.Error("entries count mismatch, need 102 got", st.Entries)
+		}
+	})
+}
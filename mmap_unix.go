@@ -0,0 +1,31 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package i18n
+
+import (
+	"os"
+	"syscall"
+)
+
+// Map file to memory in read-only mode.
+func mmapFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	if size < binHeaderSize+binTrailSize || int64(int(size)) != size {
+		return nil, ErrBadDump
+	}
+	return syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+// Unmap file.
+func munmapFile(p []byte) error {
+	return syscall.Munmap(p)
+}
@@ -0,0 +1,221 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+const (
+	moMagic      = 0x950412de
+	moHeaderSize = 28
+)
+
+// LoadMO loads compiled gettext MO file of locale to db.
+//
+// Both little and big endian files are supported. Messages save the same way as LoadPO() does: msgctxt becomes message
+// context, plural forms save as bare forms and "Plural-Forms" header applies to locale. Hash table of file, if present,
+// is checked to find every message.
+//
+// All translations and plural forms save in single transaction, so readers see either all messages of file or none of
+// them.
+func LoadMO(db *DB, locale string, r io.Reader) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m, err := decodeMO(data)
+	if err != nil {
+		return err
+	}
+
+	tx := db.Begin()
+	var (
+		pf    string
+		forms []string
+		buf   []byte
+	)
+	for i := 0; i < m.n; i++ {
+		orig, trans := m.orig(i), m.trans(i)
+		// Original string is "msgctxt\x04msgid\x00msgid_plural".
+		if j := strings.IndexByte(orig, 0); j != -1 {
+			orig = orig[:j]
+		}
+		id, ctx := orig, ""
+		if j := strings.IndexByte(orig, ctxSep); j != -1 {
+			ctx, id = orig[:j], orig[j+1:]
+		}
+		if len(id) == 0 {
+			if len(ctx) == 0 {
+				pf = headerPluralForms(trans)
+			}
+			continue
+		}
+		// Translation is "msgstr[0]\x00msgstr[1]...".
+		forms = append(forms[:0], strings.Split(trans, "\x00")...)
+		var untranslated bool
+		for j := 0; j < len(forms); j++ {
+			untranslated = untranslated || len(forms[j]) == 0
+		}
+		if untranslated {
+			continue
+		}
+		buf = joinT9n(buf[:0], forms)
+		if err = tx.SetCtx(ctx, locale+"."+id, string(buf)); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if len(pf) > 0 {
+		if err = tx.SetPluralForms(locale, pf); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Decoded MO file.
+type moFile struct {
+	data []byte
+	bo   binary.ByteOrder
+	n    int
+	// Offsets of original and translation strings tables.
+	otab, ttab int
+}
+
+// Check MO file and decode its header.
+func decodeMO(data []byte) (m moFile, err error) {
+	if len(data) < moHeaderSize {
+		err = ErrBadMO
+		return
+	}
+	switch {
+	case binary.LittleEndian.Uint32(data) == moMagic:
+		m.bo = binary.LittleEndian
+	case binary.BigEndian.Uint32(data) == moMagic:
+		m.bo = binary.BigEndian
+	default:
+		err = ErrBadMO
+		return
+	}
+	// Only major revisions 0 and 1 are known.
+	if rev := m.bo.Uint32(data[4:]); rev>>16 > 1 {
+		err = ErrBadMO
+		return
+	}
+	n, otab, ttab := uint64(m.bo.Uint32(data[8:])), uint64(m.bo.Uint32(data[12:])), uint64(m.bo.Uint32(data[16:]))
+	hsize, htab := uint64(m.bo.Uint32(data[20:])), uint64(m.bo.Uint32(data[24:]))
+	size := uint64(len(data))
+	if otab+n*8 > size || ttab+n*8 > size || (hsize > 0 && (hsize < 3 || htab+hsize*4 > size)) {
+		err = ErrBadMO
+		return
+	}
+	m.data, m.n, m.otab, m.ttab = data, int(n), int(otab), int(ttab)
+	for i := 0; i < m.n; i++ {
+		if !m.check(m.otab+i*8) || !m.check(m.ttab+i*8) {
+			err = ErrBadMO
+			return
+		}
+	}
+	if hsize > 0 {
+		h := moHash{data: data[htab : htab+hsize*4], bo: m.bo, size: uint32(hsize)}
+		for i := 0; i < m.n; i++ {
+			if h.find(&m, m.key(i)) != i {
+				err = ErrBadMO
+				return
+			}
+		}
+	}
+	return
+}
+
+// Check string descriptor at offset off.
+func (m *moFile) check(off int) bool {
+	ln, so := uint64(m.bo.Uint32(m.data[off:])), uint64(m.bo.Uint32(m.data[off+4:]))
+	return so+ln <= uint64(len(m.data))
+}
+
+// Get string by descriptor at offset off.
+func (m *moFile) str(off int) string {
+	ln, so := m.bo.Uint32(m.data[off:]), m.bo.Uint32(m.data[off+4:])
+	return string(m.data[so : so+ln])
+}
+
+// Get original string of message i.
+func (m *moFile) orig(i int) string {
+	return m.str(m.otab + i*8)
+}
+
+// Get translation of message i.
+func (m *moFile) trans(i int) string {
+	return m.str(m.ttab + i*8)
+}
+
+// Get lookup key of message i: original string without plural part.
+func (m *moFile) key(i int) []byte {
+	off := m.otab + i*8
+	ln, so := m.bo.Uint32(m.data[off:]), m.bo.Uint32(m.data[off+4:])
+	key := m.data[so : so+ln]
+	if j := bytes.IndexByte(key, 0); j != -1 {
+		key = key[:j]
+	}
+	return key
+}
+
+// Hash table of MO file.
+type moHash struct {
+	data []byte
+	bo   binary.ByteOrder
+	size uint32
+}
+
+// Find index of message by key using double hashing the same way as gettext does.
+//
+// Returns -1 if key not found.
+func (h *moHash) find(m *moFile, key []byte) int {
+	hval := hashpjw(key)
+	idx, incr := hval%h.size, 1+hval%(h.size-2)
+	for i := uint32(0); i < h.size; i++ {
+		n := h.bo.Uint32(h.data[idx*4:])
+		if n == 0 || int(n) > m.n {
+			return -1
+		}
+		if bytes.Equal(m.key(int(n-1)), key) {
+			return int(n - 1)
+		}
+		if idx >= h.size-incr {
+			idx -= h.size - incr
+		} else {
+			idx += incr
+		}
+	}
+	return -1
+}
+
+// PJW hash function used by gettext.
+func hashpjw(s []byte) uint32 {
+	var hval uint32
+	for i := 0; i < len(s); i++ {
+		hval = hval<<4 + uint32(s[i])
+		if g := hval & (0xf << 28); g != 0 {
+			hval ^= g >> 24
+			hval ^= g
+		}
+	}
+	return hval
+}
+
+// Get Plural-Forms value of gettext header.
+func headerPluralForms(header string) string {
+	for _, line := range strings.Split(header, "\n") {
+		if i := strings.IndexByte(line, ':'); i != -1 && strings.EqualFold(strings.TrimSpace(line[:i]), "Plural-Forms") {
+			return strings.TrimSpace(line[i+1:])
+		}
+	}
+	return ""
+}
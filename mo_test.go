@@ -0,0 +1,125 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+// Compile MO file of messages, keys are original strings.
+func testMO(bo binary.ByteOrder, msgs map[string]string, hsize uint32) []byte {
+	keys := make([]string, 0, len(msgs))
+	for k := range msgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	n := uint32(len(keys))
+	otab := uint32(moHeaderSize)
+	ttab := otab + n*8
+	htab := ttab + n*8
+	soff := htab + hsize*4
+
+	buf := make([]byte, soff)
+	put := func(off, v uint32) { bo.PutUint32(buf[off:], v) }
+	put(0, moMagic)
+	put(8, n)
+	put(12, otab)
+	put(16, ttab)
+	put(20, hsize)
+	put(24, htab)
+	for i, k := range keys {
+		for j, s := range []string{k, msgs[k]} {
+			put([]uint32{otab, ttab}[j]+uint32(i)*8, uint32(len(s)))
+			put([]uint32{otab, ttab}[j]+uint32(i)*8+4, uint32(len(buf)))
+			buf = append(buf, s...)
+			buf = append(buf, 0)
+		}
+		if hsize == 0 {
+			continue
+		}
+		key := []byte(k)
+		if j := bytes.IndexByte(key, 0); j != -1 {
+			key = key[:j]
+		}
+		hval := hashpjw(key)
+		idx, incr := hval%hsize, 1+hval%(hsize-2)
+		for bo.Uint32(buf[htab+idx*4:]) != 0 {
+			if idx >= hsize-incr {
+				idx -= hsize - incr
+			} else {
+				idx += incr
+			}
+		}
+		put(htab+idx*4, uint32(i+1))
+	}
+	return buf
+}
+
+func TestMO(t *testing.T) {
+	msgs := map[string]string{
+		"":                   "Language: ru\nPlural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n",
+		"Open":               "Открыто",
+		"verb\x04Open":       "Открыть",
+		"file\x00files":      "файл\x00файла\x00файлов",
+		"Choice":             "да|нет",
+		"Untranslated\x00Xs": "x\x00",
+	}
+	t.Run("hash", func(t *testing.T) {
+		for s, h := range map[string]uint32{"": 0, "Open": 353982, "verb\x04Open": 136911486, "file": 446501,
+			"a much longer message id to overflow": 66045719} {
+			if v := hashpjw([]byte(s)); v != h {
+				t.Errorf("%q: need hash %d got %d", s, h, v)
+			}
+		}
+	})
+	for _, st := range []struct {
+		name  string
+		bo    binary.ByteOrder
+		hsize uint32
+	}{
+		{"le", binary.LittleEndian, 11},
+		{"be", binary.BigEndian, 11},
+		{"nohash", binary.LittleEndian, 0},
+	} {
+		t.Run(st.name, func(t *testing.T) {
+			db, _ := New(xxhash.Hasher64[string]{})
+			if err := LoadMO(db, "ru", bytes.NewReader(testMO(st.bo, msgs, st.hsize))); err != nil {
+				t.Fatal(err)
+			}
+			assertT9n(t, db, "ru.Open", "Открыто")
+			if s := db.GetCtx("verb", "ru.Open", ""); s != "Открыть" {
+				t.Errorf("context mismatch, got %q", s)
+			}
+			assertT9nPlural(t, db, "ru.file", "файл", 1)
+			assertT9nPlural(t, db, "ru.file", "файла", 22)
+			assertT9nPlural(t, db, "ru.file", "файлов", 0)
+			assertT9n(t, db, "ru.Choice", "да|нет")
+			assertT9n(t, db, "ru.Untranslated", "")
+		})
+	}
+	t.Run("errors", func(t *testing.T) {
+		mo := testMO(binary.LittleEndian, msgs, 11)
+		broken := func(fn func(p []byte)) []byte {
+			p := append([]byte(nil), mo...)
+			fn(p)
+			return p
+		}
+		for name, p := range map[string][]byte{
+			"short":    mo[:10],
+			"magic":    broken(func(p []byte) { p[0] = 0 }),
+			"revision": broken(func(p []byte) { binary.LittleEndian.PutUint32(p[4:], 2<<16) }),
+			"table":    broken(func(p []byte) { binary.LittleEndian.PutUint32(p[12:], 1<<30) }),
+			"string":   broken(func(p []byte) { binary.LittleEndian.PutUint32(p[moHeaderSize+4:], 1<<30) }),
+			"hash":     broken(func(p []byte) { binary.LittleEndian.PutUint32(p[24:], binary.LittleEndian.Uint32(p[24:])+4) }),
+		} {
+			db, _ := New(xxhash.Hasher64[string]{})
+			if err := LoadMO(db, "ru", bytes.NewReader(p)); !errors.Is(err, ErrBadMO) {
+				t.Errorf("%s: need ErrBadMO got %v", name, err)
+			}
+		}
+	})
+}
@@ -1,6 +1,9 @@
 package i18n
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/koykov/batch_replace"
 	"github.com/koykov/byteconv"
 	"github.com/koykov/byteptr"
@@ -13,11 +16,27 @@ type PlaceholderReplacer struct {
 	kvl int
 	buf []byte
 	br  batch_replace.BatchReplace
+	// Key format markers, see SetKeyFormat().
+	kpfx, ksfx string
+	// Order of pairs to replace.
+	ord []int
 }
 
 // Simple key-value pair.
 type kv struct {
 	k, v byteptr.Byteptr
+	// Lengths of key format markers of the key.
+	kpl, ksl int
+}
+
+// SetKeyFormat sets markers around keys of placeholders added after the call, eg:
+//
+//	repl.SetKeyFormat("%{", "}").AddKV("name", "John") // replaces "%{name}" with "John"
+//
+// Format keeps after Reset().
+func (r *PlaceholderReplacer) SetKeyFormat(prefix, suffix string) *PlaceholderReplacer {
+	r.kpfx, r.ksfx = prefix, suffix
+	return r
 }
 
 // AddKV stores new placeholder and replace strings as key-value pair.
@@ -29,11 +48,14 @@ func (r *PlaceholderReplacer) AddKV(key, value string) *PlaceholderReplacer {
 		r.kv = append(r.kv, kv{})
 		bpK, bpV = &r.kv[len(r.kv)-1].k, &r.kv[len(r.kv)-1].v
 	}
+	r.kv[r.kvl].kpl, r.kv[r.kvl].ksl = len(r.kpfx), len(r.ksfx)
 	r.kvl++
 
 	offsetK := len(r.buf)
+	r.buf = append(r.buf, r.kpfx...)
 	r.buf = append(r.buf, key...)
-	bpK.Init(r.buf, offsetK, len(key))
+	r.buf = append(r.buf, r.ksfx...)
+	bpK.Init(r.buf, offsetK, len(r.buf)-offsetK)
 
 	offsetV := len(r.buf)
 	r.buf = append(r.buf, value...)
@@ -61,6 +83,8 @@ func (r *PlaceholderReplacer) Size() int {
 }
 
 // Commit performs the replaces.
+//
+// Pairs with longer keys replace first, so keys may be prefixes of each other, eg: "!1" and "!10".
 func (r *PlaceholderReplacer) Commit(raw string) string {
 	l := r.kvl
 	if l == 0 {
@@ -68,7 +92,15 @@ func (r *PlaceholderReplacer) Commit(raw string) string {
 	}
 	r.br.SetSourceString(raw)
 	_ = r.kv[l-1]
+	// Longer keys replace first, so key "!1" doesn't break "!10".
+	r.ord = r.ord[:0]
 	for i := 0; i < l; i++ {
+		r.ord = append(r.ord, i)
+		for j := len(r.ord) - 1; j > 0 && r.kv[r.ord[j]].k.Len() > r.kv[r.ord[j-1]].k.Len(); j-- {
+			r.ord[j], r.ord[j-1] = r.ord[j-1], r.ord[j]
+		}
+	}
+	for _, i := range r.ord {
 		kv := &r.kv[i]
 		r.br.S2S(kv.k.TakeAddress(r.buf).String(), kv.v.TakeAddress(r.buf).String())
 	}
@@ -81,3 +113,73 @@ func (r *PlaceholderReplacer) Reset() {
 	r.buf = r.buf[:0]
 	r.br.Reset()
 }
+
+// Convert printf-style format specifiers of s to positional placeholders "!1", "!2", ..., eg: "%1$s" and "%@" become
+// "!1". Unnumbered specifiers get indexes starting from next, "%%" becomes "%".
+//
+// Returns dst and index of the next unnumbered specifier.
+func printfPlaceholders(dst []byte, s string, next int) ([]byte, int) {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			dst = append(dst, s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '%' {
+			dst = append(dst, '%')
+			i++
+			continue
+		}
+		idx, n := printfSpec(s[i+1:])
+		switch {
+		case n == 0:
+			// Not a specifier, keep as is.
+			dst = append(dst, '%')
+			continue
+		case idx == -1:
+			// Line separator of Java formatter.
+			dst = append(dst, '\n')
+		default:
+			if idx == 0 {
+				idx = next
+				next++
+			}
+			dst = append(dst, '!')
+			dst = strconv.AppendInt(dst, int64(idx), 10)
+		}
+		i += n
+	}
+	return dst, next
+}
+
+// Parse printf-style specifier after percent sign.
+//
+// Returns explicit argument index (0 if absent, -1 for "%n") and length of specifier, zero length means no specifier.
+// Space flag isn't supported to keep texts like "50% off" as is.
+func printfSpec(s string) (idx, n int) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i > 0 && i < len(s) && s[i] == '$' {
+		idx, _ = strconv.Atoi(s[:i])
+		i++
+	} else {
+		i = 0
+	}
+	for i < len(s) && strings.IndexByte("-+#0'", s[i]) != -1 {
+		i++
+	}
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '*' || s[i] == '.') {
+		i++
+	}
+	for i < len(s) && strings.IndexByte("hlqLzjt", s[i]) != -1 {
+		i++
+	}
+	if i == len(s) || strings.IndexByte("@dDiuUxXoOfFeEgGaAcCsSpbBn", s[i]) == -1 {
+		return 0, 0
+	}
+	if s[i] == 'n' {
+		return -1, i + 1
+	}
+	return idx, i + 1
+}
@@ -1,6 +1,7 @@
 package i18n
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/koykov/hash/xxhash"
@@ -21,6 +22,43 @@ func TestPlaceholderReplacer(t *testing.T) {
 	}
 }
 
+func TestPlaceholderKeyFormat(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	_ = db.Set("en.user.balance", "Balance of %{user}: %{val} %{cur}")
+
+	repl := PlaceholderReplacer{}
+	repl.SetKeyFormat("%{", "}").
+		AddKV("user", "John Ruth").
+		AddSolidKV("val:8000").
+		AddKV("cur", "USD")
+	if s := db.GetWR("en.user.balance", "", &repl); s != "Balance of John Ruth: 8000 USD" {
+		t.Errorf("replace mismatch, got '%s'", s)
+	}
+
+	repl.Reset()
+	repl.AddKV("user", "Jane")
+	var args Args
+	args.Replacer(&repl)
+	if v, _ := args.get("user"); v != "Jane" {
+		t.Errorf("args mismatch, got '%s'", v)
+	}
+}
+
+func TestPlaceholderPositional(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	// Printf specifiers of Android and iOS strings become positional placeholders "!1".."!11".
+	buf, _ := printfPlaceholders(nil, "%s %s %s %s %s %s %s %s %s %s %s", 1)
+	_ = db.Set("en.args", string(buf))
+
+	var repl PlaceholderReplacer
+	for i := 1; i <= 11; i++ {
+		repl.AddKV("!"+strconv.Itoa(i), "<"+strconv.Itoa(i)+">")
+	}
+	if s := db.GetWR("en.args", "", &repl); s != "<1> <2> <3> <4> <5> <6> <7> <8> <9> <10> <11>" {
+		t.Errorf("replace mismatch, got '%s'", s)
+	}
+}
+
 func BenchmarkPlaceholderReplacer(b *testing.B) {
 	origin, expect := "Balance of !user: !val !cur", "Balance of John Ruth: 8000 USD"
 
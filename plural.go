@@ -0,0 +1,1054 @@
+package i18n
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/koykov/byteconv"
+)
+
+// PluralCategory is a CLDR plural category.
+type PluralCategory uint8
+
+const (
+	PluralZero PluralCategory = iota
+	PluralOne
+	PluralTwo
+	PluralFew
+	PluralMany
+	PluralOther
+)
+
+var pluralCategoryNames = [...]string{"zero", "one", "two", "few", "many", "other"}
+
+// String returns CLDR name of the category.
+func (c PluralCategory) String() string {
+	if int(c) < len(pluralCategoryNames) {
+		return pluralCategoryNames[c]
+	}
+	return "unknown"
+}
+
+// ParsePluralCategory returns plural category by its CLDR name.
+func ParsePluralCategory(name string) (PluralCategory, bool) {
+	for i := 0; i < len(pluralCategoryNames); i++ {
+		if pluralCategoryNames[i] == name {
+			return PluralCategory(i), true
+		}
+	}
+	return PluralOther, false
+}
+
+// Plural operands of the number, see https://unicode.org/reports/tr35/tr35-numbers.html#Operands.
+type operands struct {
+	// Absolute value of integer digits.
+	i uint64
+	// Count of visible fraction digits, with and without trailing zeros.
+	v, w int
+	// Visible fraction digits, with and without trailing zeros.
+	f, t uint64
+}
+
+// Make operands of integer number.
+func intOperands(n int64) operands {
+	if n < 0 {
+		return operands{i: uint64(-n)}
+	}
+	return operands{i: uint64(n)}
+}
+
+// Check if n is an integer and equals x.
+func (o *operands) nIs(x uint64) bool {
+	return o.t == 0 && o.i == x
+}
+
+// Check if n is an integer in range [lo, hi].
+func (o *operands) nIn(lo, hi uint64) bool {
+	return o.t == 0 && o.i >= lo && o.i <= hi
+}
+
+// Check if n is an integer and n % m is in range [lo, hi].
+func (o *operands) nModIn(m, lo, hi uint64) bool {
+	return o.t == 0 && o.i%m >= lo && o.i%m <= hi
+}
+
+// Check if i % m is in range [lo, hi].
+func (o *operands) iModIn(m, lo, hi uint64) bool {
+	return o.i%m >= lo && o.i%m <= hi
+}
+
+// Check if f % m is in range [lo, hi].
+func (o *operands) fModIn(m, lo, hi uint64) bool {
+	return o.f%m >= lo && o.f%m <= hi
+}
+
+// Plural rule function returns category of number.
+type pluralFunc func(o operands) PluralCategory
+
+// Plural form describes categories of the locale and the function to select them.
+type pluralForm struct {
+	// Categories in CLDR order.
+	cats []PluralCategory
+	fn   pluralFunc
+}
+
+// Get category of the number and its position in categories list.
+func (f *pluralForm) category(o operands) (PluralCategory, int) {
+	c := f.fn(o)
+	for i := 0; i < len(f.cats); i++ {
+		if f.cats[i] == c {
+			return c, i
+		}
+	}
+	return c, len(f.cats) - 1
+}
+
+// Plural rules of the locale.
+type pluralRule struct {
+	// Cardinal and ordinal forms.
+	card, ord *pluralForm
+	// Gettext plural forms, replace cardinal positions of bare forms.
+	forms *pluralForms
+}
+
+// Lookup query.
+type query struct {
+	// Count to select plural form, integer part of decimal number.
+	count int64
+	// Operands of decimal number.
+	op operands
+	// Decimal number flag and sign of decimal number.
+	dec, neg bool
+	// Use ordinal plural rules instead of cardinal.
+	ord bool
+	// Arguments to render ICU messages.
+	args *Args
+	// Select keyword, eg: "female".
+	sel string
+	// Hash of message context.
+	hctx uint64
+}
+
+// Check if query number has non-zero fraction part.
+func (q *query) frac() bool {
+	return q.dec && q.op.t != 0
+}
+
+// Get plural operands of query number.
+func (q *query) operands() operands {
+	if q.dec {
+		return q.op
+	}
+	return intOperands(q.count)
+}
+
+// Max count of fraction digits fits to uint64.
+const maxFracDigits = 18
+
+// Init query with decimal number, eg: "-1.50".
+//
+// Trailing zeros of fraction are significant, thus "1.0" and "1" have different operands.
+// Returns false if s isn't a valid decimal number.
+func (q *query) parseDecimal(s string) bool {
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		q.neg = s[0] == '-'
+		s = s[1:]
+	}
+	var i int
+	for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		d := uint64(s[i] - '0')
+		if q.op.i > (math.MaxUint64-d)/10 {
+			q.op.i = math.MaxUint64
+			continue
+		}
+		q.op.i = q.op.i*10 + d
+	}
+	if i == 0 {
+		return false
+	}
+	if i < len(s) {
+		if s[i] != '.' || i+1 == len(s) {
+			return false
+		}
+		for i++; i < len(s); i++ {
+			if s[i] < '0' || s[i] > '9' {
+				return false
+			}
+			// Ignore digits beyond the precision.
+			if q.op.v < maxFracDigits {
+				q.op.f = q.op.f*10 + uint64(s[i]-'0')
+				q.op.v++
+			}
+		}
+	}
+	q.op.t, q.op.w = q.op.f, q.op.v
+	for q.op.w > 0 && q.op.t%10 == 0 {
+		q.op.t /= 10
+		q.op.w--
+	}
+	if q.op.i > math.MaxInt64 {
+		q.count = math.MaxInt64
+	} else {
+		q.count = int64(q.op.i)
+	}
+	if q.neg {
+		q.count = -q.count
+	}
+	q.dec = true
+	return true
+}
+
+// Init query with floating point number.
+//
+// Number formats using the shortest representation, so 1.0 is equal to 1. Use decimal strings to keep trailing zeros.
+func (q *query) parseFloat(f float64) bool {
+	var a [64]byte
+	return q.parseDecimal(byteconv.B2S(strconv.AppendFloat(a[:0], f, 'f', -1, 64)))
+}
+
+// Select rule of query q.
+//
+// Rules with explicit ranges have priority and check in order. Then category keywords and bare forms check: bare forms
+// select by position of count's category in locale categories list, the last bare form serves all the rest categories.
+// Keyword "other" serves all categories missing in the rules.
+//
+// Without plural rules all forms check in order using ranges, bare forms have legacy ranges [0, 2) and [2, ∞).
+//
+// Forms marked with select keyword check within keyword group of query, then groups "other" and the forms without
+// keyword serve as fallback.
+func selectRule(rules []rule, buf []byte, q *query, pr *pluralRule) *rule {
+	var hasSel bool
+	for i := 0; i < len(rules) && !hasSel; i++ {
+		hasSel = rules[i].arg>>16 != 0
+	}
+	if !hasSel {
+		return selectGroup(rules, buf, "", q, pr)
+	}
+	if len(q.sel) == 0 {
+		if r := selectGroup(rules, buf, "", q, pr); r != nil {
+			return r
+		}
+		return selectGroup(rules, buf, "other", q, pr)
+	}
+	// Try requested keyword, then keyword "other", then forms without keyword.
+	if r := selectGroup(rules, buf, q.sel, q, pr); r != nil {
+		return r
+	}
+	if r := selectGroup(rules, buf, "other", q, pr); r != nil {
+		return r
+	}
+	return selectGroup(rules, buf, "", q, pr)
+}
+
+// Select rule of select group sel, see selectRule().
+func selectGroup(rules []rule, buf []byte, sel string, q *query, pr *pluralRule) *rule {
+	var (
+		nbare, nkw int
+		other      *rule
+	)
+	for i := 0; i < len(rules); i++ {
+		r := &rules[i]
+		if !r.inGroup(buf, sel) {
+			continue
+		}
+		switch r.kind {
+		case ruleBare:
+			if pr == nil && r.match(q) {
+				return r
+			}
+			nbare++
+		case ruleCategory:
+			if PluralCategory(r.pos()) == PluralOther && other == nil {
+				other = r
+			}
+			nkw++
+		default:
+			if r.match(q) {
+				return r
+			}
+		}
+	}
+	if pr == nil || nbare+nkw == 0 {
+		return other
+	}
+
+	f := pr.card
+	if q.ord {
+		f = pr.ord
+	}
+	o := q.operands()
+	c, pos := f.category(o)
+	if pr.forms != nil && !q.ord {
+		pos = pr.forms.index(o.i)
+	}
+	if nkw > 0 {
+		for i := 0; i < len(rules); i++ {
+			if r := &rules[i]; r.kind == ruleCategory && r.inGroup(buf, sel) && PluralCategory(r.pos()) == c {
+				return r
+			}
+		}
+	}
+	if nbare > 0 {
+		if pos >= nbare {
+			pos = nbare - 1
+		}
+		for i := 0; i < len(rules); i++ {
+			if r := &rules[i]; r.kind == ruleBare && r.inGroup(buf, sel) && int(r.pos()) == pos {
+				return r
+			}
+		}
+	}
+	return other
+}
+
+// Get plural rules of locale.
+//
+// Locale truncates to its parents until rules found, eg: "pt-PT-x-foo" -> "pt-PT". Plural forms set by
+// SetPluralForms() have priority over CLDR rules at each level.
+func (db *DB) plural(locale string) *pluralRule {
+	forms := db.pluralForms()
+	for ; len(locale) > 0; locale = parentLocale(locale) {
+		if r, ok := forms[locale]; ok {
+			return r
+		}
+		if r, ok := cldrPlurals[locale]; ok {
+			return r
+		}
+	}
+	return nil
+}
+
+// Get built-in CLDR rules of locale.
+func cldrRule(locale string) *pluralRule {
+	for ; len(locale) > 0; locale = parentLocale(locale) {
+		if r, ok := cldrPlurals[locale]; ok {
+			return r
+		}
+	}
+	return nil
+}
+
+// Lists of categories.
+var (
+	catsO     = []PluralCategory{PluralOther}
+	catsOO    = []PluralCategory{PluralOne, PluralOther}
+	catsOMO   = []PluralCategory{PluralOne, PluralMany, PluralOther}
+	catsOTO   = []PluralCategory{PluralOne, PluralTwo, PluralOther}
+	catsOFO   = []PluralCategory{PluralOne, PluralFew, PluralOther}
+	catsZOO   = []PluralCategory{PluralZero, PluralOne, PluralOther}
+	catsOFMO  = []PluralCategory{PluralOne, PluralFew, PluralMany, PluralOther}
+	catsOTFO  = []PluralCategory{PluralOne, PluralTwo, PluralFew, PluralOther}
+	catsOTMO  = []PluralCategory{PluralOne, PluralTwo, PluralMany, PluralOther}
+	catsFO    = []PluralCategory{PluralFew, PluralOther}
+	catsMO    = []PluralCategory{PluralMany, PluralOther}
+	catsOTFMO = []PluralCategory{PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther}
+	catsAll   = []PluralCategory{PluralZero, PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther}
+)
+
+// Check CLDR "many" condition of romance languages: i != 0 and i % 1000000 = 0 and v = 0.
+func romanceMany(o operands) bool {
+	return o.i != 0 && o.i%1000000 == 0 && o.v == 0
+}
+
+// Built-in CLDR plural rules.
+//
+// See https://www.unicode.org/cldr/charts/latest/supplemental/language_plural_rules.html
+var (
+	cldrCardinals = map[string]*pluralForm{}
+	cldrOrdinals  = map[string]*pluralForm{}
+	cldrPlurals   = map[string]*pluralRule{}
+
+	// Default form of locales without data.
+	formOther = &pluralForm{cats: catsO, fn: func(operands) PluralCategory { return PluralOther }}
+)
+
+func registerCardinal(f *pluralForm, locales ...string) {
+	for i := 0; i < len(locales); i++ {
+		cldrCardinals[locales[i]] = f
+	}
+}
+
+func registerOrdinal(f *pluralForm, locales ...string) {
+	for i := 0; i < len(locales); i++ {
+		cldrOrdinals[locales[i]] = f
+	}
+}
+
+// Compose plural rules of all locales having cardinal data.
+//
+// Ordinal form resolves using locale truncation, eg: "pt-PT" uses ordinals of "pt".
+func composeCLDR() {
+	for locale, card := range cldrCardinals {
+		r := &pluralRule{card: card, ord: formOther}
+		for l := locale; len(l) > 0; l = parentLocale(l) {
+			if ord, ok := cldrOrdinals[l]; ok {
+				r.ord = ord
+				break
+			}
+		}
+		cldrPlurals[locale] = r
+	}
+}
+
+func init() {
+	registerCardinal(formOther, "bm", "bo", "dz", "hnj", "id", "ig", "ii", "in", "ja", "jbo", "jv", "jw", "kde", "kea", "km", "ko", "lkt",
+		"lo", "ms", "my", "nqo", "osa", "sah", "ses", "sg", "su", "th", "to", "tpi", "vi", "wo", "yo", "yue", "zh")
+
+	registerCardinal(&pluralForm{cats: catsOO, fn: func(o operands) PluralCategory {
+		// i = 0 or n = 1
+		if o.i == 0 || o.nIs(1) {
+			return PluralOne
+		}
+		return PluralOther
+	}}, "am", "as", "bn", "doi", "fa", "gu", "hi", "kn", "pcm", "zu")
+
+	registerCardinal(&pluralForm{cats: catsOO, fn: func(o operands) PluralCategory {
+		// i = 0,1
+		if o.i <= 1 {
+			return PluralOne
+		}
+		return PluralOther
+	}}, "ff", "hy", "kab")
+
+	registerCardinal(&pluralForm{cats: catsOO, fn: func(o operands) PluralCategory {
+		// n = 0..1
+		if o.nIn(0, 1) {
+			return PluralOne
+		}
+		return PluralOther
+	}}, "ak", "bho", "guw", "ln", "mg", "nso", "pa", "ti", "wa")
+
+	registerCardinal(&pluralForm{cats: catsOO, fn: func(o operands) PluralCategory {
+		// i = 1 and v = 0
+		if o.i == 1 && o.v == 0 {
+			return PluralOne
+		}
+		return PluralOther
+	}}, "ast", "de", "en", "et", "fi", "fy", "gl", "ia", "io", "ji", "lij", "nl", "sc", "sv", "sw", "ur", "yi")
+
+	registerCardinal(&pluralForm{cats: catsOO, fn: func(o operands) PluralCategory {
+		// n = 1
+		if o.nIs(1) {
+			return PluralOne
+		}
+		return PluralOther
+	}}, "af", "an", "asa", "az", "bal", "bem", "bez", "bg", "brx", "ce", "cgg", "chr", "ckb", "dv", "ee", "el",
+		"eo", "eu", "fo", "fur", "gsw", "ha", "haw", "hu", "jgo", "jmc", "ka", "kaj", "kcg", "kk", "kkj", "kl", "ks",
+		"ksb", "ku", "ky", "lb", "lg", "mas", "mgo", "ml", "mn", "mr", "nah", "nb", "nd", "ne", "nn", "nnh", "no",
+		"nr", "ny", "nyn", "om", "or", "os", "pap", "ps", "rm", "rof", "rwk", "saq", "sd", "sdh", "seh", "sn", "so",
+		"sq", "ss", "ssy", "st", "syr", "ta", "te", "teo", "tig", "tk", "tn", "tr", "ts", "ug", "uz", "ve", "vo",
+		"vun", "wae", "xh", "xog")
+
+	registerCardinal(&pluralForm{cats: catsOO, fn: func(o operands) PluralCategory {
+		// n = 1 or t != 0 and i = 0,1
+		if o.nIs(1) || (o.t != 0 && o.i <= 1) {
+			return PluralOne
+		}
+		return PluralOther
+	}}, "da")
+
+	registerCardinal(&pluralForm{cats: catsOO, fn: func(o operands) PluralCategory {
+		// t = 0 and i % 10 = 1 and i % 100 != 11 or t % 10 = 1 and t % 100 != 11
+		if (o.t == 0 && o.i%10 == 1 && o.i%100 != 11) || (o.t%10 == 1 && o.t%100 != 11) {
+			return PluralOne
+		}
+		return PluralOther
+	}}, "is")
+
+	registerCardinal(&pluralForm{cats: catsOO, fn: func(o operands) PluralCategory {
+		// v = 0 and i % 10 = 1 and i % 100 != 11 or f % 10 = 1 and f % 100 != 11
+		if (o.v == 0 && o.i%10 == 1 && o.i%100 != 11) || (o.f%10 == 1 && o.f%100 != 11) {
+			return PluralOne
+		}
+		return PluralOther
+	}}, "mk")
+
+	registerCardinal(&pluralForm{cats: catsOO, fn: func(o operands) PluralCategory {
+		// v = 0 and i = 1,2,3 or v = 0 and i % 10 != 4,6,9 or v != 0 and f % 10 != 4,6,9
+		im, fm := o.i%10, o.f%10
+		if (o.v == 0 && o.i >= 1 && o.i <= 3) || (o.v == 0 && im != 4 && im != 6 && im != 9) ||
+			(o.v != 0 && fm != 4 && fm != 6 && fm != 9) {
+			return PluralOne
+		}
+		return PluralOther
+	}}, "ceb", "fil", "tl")
+
+	registerCardinal(&pluralForm{cats: catsZOO, fn: func(o operands) PluralCategory {
+		// zero: n % 10 = 0 or n % 100 = 11..19 or v = 2 and f % 100 = 11..19
+		// one: n % 10 = 1 and n % 100 != 11 or v = 2 and f % 10 = 1 and f % 100 != 11 or v != 2 and f % 10 = 1
+		switch {
+		case o.nModIn(10, 0, 0) || o.nModIn(100, 11, 19) || (o.v == 2 && o.fModIn(100, 11, 19)):
+			return PluralZero
+		case (o.nModIn(10, 1, 1) && !o.nModIn(100, 11, 11)) || (o.v == 2 && o.f%10 == 1 && o.f%100 != 11) ||
+			(o.v != 2 && o.f%10 == 1):
+			return PluralOne
+		}
+		return PluralOther
+	}}, "lv", "prg")
+
+	registerCardinal(&pluralForm{cats: catsOTO, fn: func(o operands) PluralCategory {
+		// one: n = 1; two: n = 2
+		switch {
+		case o.nIs(1):
+			return PluralOne
+		case o.nIs(2):
+			return PluralTwo
+		}
+		return PluralOther
+	}}, "iu", "naq", "sat", "se", "sma", "smi", "smj", "smn", "sms")
+
+	registerCardinal(&pluralForm{cats: catsOMO, fn: func(o operands) PluralCategory {
+		// one: i = 0,1; many: see romanceMany()
+		switch {
+		case o.i <= 1:
+			return PluralOne
+		case romanceMany(o):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "fr", "pt")
+
+	registerCardinal(&pluralForm{cats: catsOMO, fn: func(o operands) PluralCategory {
+		// one: i = 1 and v = 0; many: see romanceMany()
+		switch {
+		case o.i == 1 && o.v == 0:
+			return PluralOne
+		case romanceMany(o):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "ca", "it", "pt-PT", "vec")
+
+	registerCardinal(&pluralForm{cats: catsOMO, fn: func(o operands) PluralCategory {
+		// one: n = 1; many: see romanceMany()
+		switch {
+		case o.nIs(1):
+			return PluralOne
+		case romanceMany(o):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "es")
+
+	registerCardinal(&pluralForm{cats: catsOFO, fn: func(o operands) PluralCategory {
+		// one: i = 1 and v = 0
+		// few: v != 0 or n = 0 or n != 1 and n % 100 = 1..19
+		switch {
+		case o.i == 1 && o.v == 0:
+			return PluralOne
+		case o.v != 0 || o.nIs(0) || (!o.nIs(1) && o.nModIn(100, 1, 19)):
+			return PluralFew
+		}
+		return PluralOther
+	}}, "mo", "ro")
+
+	registerCardinal(&pluralForm{cats: catsOFO, fn: func(o operands) PluralCategory {
+		// one: v = 0 and i % 10 = 1 and i % 100 != 11 or f % 10 = 1 and f % 100 != 11
+		// few: v = 0 and i % 10 = 2..4 and i % 100 != 12..14 or f % 10 = 2..4 and f % 100 != 12..14
+		switch {
+		case (o.v == 0 && o.i%10 == 1 && o.i%100 != 11) || (o.f%10 == 1 && o.f%100 != 11):
+			return PluralOne
+		case (o.v == 0 && o.iModIn(10, 2, 4) && !o.iModIn(100, 12, 14)) ||
+			(o.fModIn(10, 2, 4) && !o.fModIn(100, 12, 14)):
+			return PluralFew
+		}
+		return PluralOther
+	}}, "bs", "hr", "sh", "sr")
+
+	registerCardinal(&pluralForm{cats: catsOTFO, fn: func(o operands) PluralCategory {
+		// one: n = 1,11; two: n = 2,12; few: n = 3..10,13..19
+		switch {
+		case o.nIs(1) || o.nIs(11):
+			return PluralOne
+		case o.nIs(2) || o.nIs(12):
+			return PluralTwo
+		case o.nIn(3, 10) || o.nIn(13, 19):
+			return PluralFew
+		}
+		return PluralOther
+	}}, "gd")
+
+	registerCardinal(&pluralForm{cats: catsOTFO, fn: func(o operands) PluralCategory {
+		// one: v = 0 and i % 100 = 1; two: v = 0 and i % 100 = 2; few: v = 0 and i % 100 = 3..4 or v != 0
+		switch {
+		case o.v == 0 && o.i%100 == 1:
+			return PluralOne
+		case o.v == 0 && o.i%100 == 2:
+			return PluralTwo
+		case (o.v == 0 && o.iModIn(100, 3, 4)) || o.v != 0:
+			return PluralFew
+		}
+		return PluralOther
+	}}, "sl")
+
+	registerCardinal(&pluralForm{cats: catsOTFO, fn: func(o operands) PluralCategory {
+		// one: v = 0 and i % 100 = 1 or f % 100 = 1
+		// two: v = 0 and i % 100 = 2 or f % 100 = 2
+		// few: v = 0 and i % 100 = 3..4 or f % 100 = 3..4
+		switch {
+		case (o.v == 0 && o.i%100 == 1) || o.f%100 == 1:
+			return PluralOne
+		case (o.v == 0 && o.i%100 == 2) || o.f%100 == 2:
+			return PluralTwo
+		case (o.v == 0 && o.iModIn(100, 3, 4)) || o.fModIn(100, 3, 4):
+			return PluralFew
+		}
+		return PluralOther
+	}}, "dsb", "hsb")
+
+	registerCardinal(&pluralForm{cats: catsOTO, fn: func(o operands) PluralCategory {
+		// one: i = 1 and v = 0 or i = 0 and v != 0; two: i = 2 and v = 0
+		switch {
+		case (o.i == 1 && o.v == 0) || (o.i == 0 && o.v != 0):
+			return PluralOne
+		case o.i == 2 && o.v == 0:
+			return PluralTwo
+		}
+		return PluralOther
+	}}, "he", "iw")
+
+	registerCardinal(&pluralForm{cats: catsOFMO, fn: func(o operands) PluralCategory {
+		// one: i = 1 and v = 0; few: i = 2..4 and v = 0; many: v != 0
+		switch {
+		case o.i == 1 && o.v == 0:
+			return PluralOne
+		case o.i >= 2 && o.i <= 4 && o.v == 0:
+			return PluralFew
+		case o.v != 0:
+			return PluralMany
+		}
+		return PluralOther
+	}}, "cs", "sk")
+
+	registerCardinal(&pluralForm{cats: catsOFMO, fn: func(o operands) PluralCategory {
+		// one: i = 1 and v = 0
+		// few: v = 0 and i % 10 = 2..4 and i % 100 != 12..14
+		// many: v = 0 and i != 1 and i % 10 = 0..1 or v = 0 and i % 10 = 5..9 or v = 0 and i % 100 = 12..14
+		switch {
+		case o.i == 1 && o.v == 0:
+			return PluralOne
+		case o.v == 0 && o.iModIn(10, 2, 4) && !o.iModIn(100, 12, 14):
+			return PluralFew
+		case o.v == 0 && ((o.i != 1 && o.iModIn(10, 0, 1)) || o.iModIn(10, 5, 9) || o.iModIn(100, 12, 14)):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "pl")
+
+	registerCardinal(&pluralForm{cats: catsOFMO, fn: func(o operands) PluralCategory {
+		// one: n % 10 = 1 and n % 100 != 11
+		// few: n % 10 = 2..4 and n % 100 != 12..14
+		// many: n % 10 = 0 or n % 10 = 5..9 or n % 100 = 11..14
+		switch {
+		case o.nModIn(10, 1, 1) && !o.nModIn(100, 11, 11):
+			return PluralOne
+		case o.nModIn(10, 2, 4) && !o.nModIn(100, 12, 14):
+			return PluralFew
+		case o.nModIn(10, 0, 0) || o.nModIn(10, 5, 9) || o.nModIn(100, 11, 14):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "be")
+
+	registerCardinal(&pluralForm{cats: catsOFMO, fn: func(o operands) PluralCategory {
+		// one: n % 10 = 1 and n % 100 != 11..19
+		// few: n % 10 = 2..9 and n % 100 != 11..19
+		// many: f != 0
+		switch {
+		case o.nModIn(10, 1, 1) && !o.nModIn(100, 11, 19):
+			return PluralOne
+		case o.nModIn(10, 2, 9) && !o.nModIn(100, 11, 19):
+			return PluralFew
+		case o.f != 0:
+			return PluralMany
+		}
+		return PluralOther
+	}}, "lt")
+
+	registerCardinal(&pluralForm{cats: catsOFMO, fn: func(o operands) PluralCategory {
+		// one: v = 0 and i % 10 = 1 and i % 100 != 11
+		// few: v = 0 and i % 10 = 2..4 and i % 100 != 12..14
+		// many: v = 0 and i % 10 = 0 or v = 0 and i % 10 = 5..9 or v = 0 and i % 100 = 11..14
+		switch {
+		case o.v == 0 && o.i%10 == 1 && o.i%100 != 11:
+			return PluralOne
+		case o.v == 0 && o.iModIn(10, 2, 4) && !o.iModIn(100, 12, 14):
+			return PluralFew
+		case o.v == 0 && (o.i%10 == 0 || o.iModIn(10, 5, 9) || o.iModIn(100, 11, 14)):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "ru", "uk")
+
+	registerCardinal(&pluralForm{cats: catsOTFMO, fn: func(o operands) PluralCategory {
+		// one: n = 1; two: n = 2; few: n = 0 or n % 100 = 3..10; many: n % 100 = 11..19
+		switch {
+		case o.nIs(1):
+			return PluralOne
+		case o.nIs(2):
+			return PluralTwo
+		case o.nIs(0) || o.nModIn(100, 3, 10):
+			return PluralFew
+		case o.nModIn(100, 11, 19):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "mt")
+
+	registerCardinal(&pluralForm{cats: catsOTFMO, fn: func(o operands) PluralCategory {
+		// one: n = 1; two: n = 2; few: n = 3..6; many: n = 7..10
+		switch {
+		case o.nIs(1):
+			return PluralOne
+		case o.nIs(2):
+			return PluralTwo
+		case o.nIn(3, 6):
+			return PluralFew
+		case o.nIn(7, 10):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "ga")
+
+	registerCardinal(&pluralForm{cats: catsAll, fn: func(o operands) PluralCategory {
+		// zero: n = 0; one: n = 1; two: n = 2; few: n % 100 = 3..10; many: n % 100 = 11..99
+		switch {
+		case o.nIs(0):
+			return PluralZero
+		case o.nIs(1):
+			return PluralOne
+		case o.nIs(2):
+			return PluralTwo
+		case o.nModIn(100, 3, 10):
+			return PluralFew
+		case o.nModIn(100, 11, 99):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "ar", "ars")
+
+	registerCardinal(&pluralForm{cats: catsAll, fn: func(o operands) PluralCategory {
+		// zero: n = 0; one: n = 1; two: n = 2; few: n = 3; many: n = 6
+		switch {
+		case o.nIs(0):
+			return PluralZero
+		case o.nIs(1):
+			return PluralOne
+		case o.nIs(2):
+			return PluralTwo
+		case o.nIs(3):
+			return PluralFew
+		case o.nIs(6):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "cy")
+
+	// Ordinals, locales without data have the only category "other".
+
+	registerOrdinal(&pluralForm{cats: catsOTFO, fn: func(o operands) PluralCategory {
+		// one: n % 10 = 1 and n % 100 != 11; two: n % 10 = 2 and n % 100 != 12; few: n % 10 = 3 and n % 100 != 13
+		switch {
+		case o.nModIn(10, 1, 1) && !o.nModIn(100, 11, 11):
+			return PluralOne
+		case o.nModIn(10, 2, 2) && !o.nModIn(100, 12, 12):
+			return PluralTwo
+		case o.nModIn(10, 3, 3) && !o.nModIn(100, 13, 13):
+			return PluralFew
+		}
+		return PluralOther
+	}}, "en")
+
+	registerOrdinal(&pluralForm{cats: catsOO, fn: func(o operands) PluralCategory {
+		// one: n = 1
+		if o.nIs(1) {
+			return PluralOne
+		}
+		return PluralOther
+	}}, "fil", "fr", "ga", "hy", "lo", "mo", "ms", "ro", "tl", "vi")
+
+	registerOrdinal(&pluralForm{cats: catsOO, fn: func(o operands) PluralCategory {
+		// one: n % 10 = 1,2 and n % 100 != 11,12
+		if o.nModIn(10, 1, 2) && !o.nModIn(100, 11, 12) {
+			return PluralOne
+		}
+		return PluralOther
+	}}, "sv")
+
+	registerOrdinal(&pluralForm{cats: catsOO, fn: func(o operands) PluralCategory {
+		// one: n = 1,5
+		if o.nIs(1) || o.nIs(5) {
+			return PluralOne
+		}
+		return PluralOther
+	}}, "hu")
+
+	registerOrdinal(&pluralForm{cats: catsOO, fn: func(o operands) PluralCategory {
+		// one: n = 1..4
+		if o.nIn(1, 4) {
+			return PluralOne
+		}
+		return PluralOther
+	}}, "ne")
+
+	registerOrdinal(&pluralForm{cats: catsMO, fn: func(o operands) PluralCategory {
+		// many: n = 11,8,80,800
+		if o.nIs(11) || o.nIs(8) || o.nIs(80) || o.nIs(800) {
+			return PluralMany
+		}
+		return PluralOther
+	}}, "it", "sc")
+
+	registerOrdinal(&pluralForm{cats: catsMO, fn: func(o operands) PluralCategory {
+		// many: n % 10 = 6 or n % 10 = 9 or n % 10 = 0 and n != 0
+		if o.nModIn(10, 6, 6) || o.nModIn(10, 9, 9) || (o.nModIn(10, 0, 0) && !o.nIs(0)) {
+			return PluralMany
+		}
+		return PluralOther
+	}}, "kk")
+
+	registerOrdinal(&pluralForm{cats: catsOTFO, fn: func(o operands) PluralCategory {
+		// one: n = 1,3; two: n = 2; few: n = 4
+		switch {
+		case o.nIs(1) || o.nIs(3):
+			return PluralOne
+		case o.nIs(2):
+			return PluralTwo
+		case o.nIs(4):
+			return PluralFew
+		}
+		return PluralOther
+	}}, "ca")
+
+	registerOrdinal(&pluralForm{cats: catsOMO, fn: func(o operands) PluralCategory {
+		// one: i = 1; many: i = 0 or i % 100 = 2..20,40,60,80
+		m := o.i % 100
+		switch {
+		case o.i == 1:
+			return PluralOne
+		case o.i == 0 || (m >= 2 && m <= 20) || m == 40 || m == 60 || m == 80:
+			return PluralMany
+		}
+		return PluralOther
+	}}, "ka")
+
+	registerOrdinal(&pluralForm{cats: catsOMO, fn: func(o operands) PluralCategory {
+		// one: n = 1; many: n % 10 = 4 and n % 100 != 14
+		switch {
+		case o.nIs(1):
+			return PluralOne
+		case o.nModIn(10, 4, 4) && !o.nModIn(100, 14, 14):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "sq")
+
+	registerOrdinal(&pluralForm{cats: catsOTMO, fn: func(o operands) PluralCategory {
+		// one: i % 10 = 1 and i % 100 != 11; two: i % 10 = 2 and i % 100 != 12
+		// many: i % 10 = 7,8 and i % 100 != 17,18
+		switch {
+		case o.i%10 == 1 && o.i%100 != 11:
+			return PluralOne
+		case o.i%10 == 2 && o.i%100 != 12:
+			return PluralTwo
+		case o.iModIn(10, 7, 8) && !o.iModIn(100, 17, 18):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "mk")
+
+	registerOrdinal(&pluralForm{cats: catsOFMO, fn: func(o operands) PluralCategory {
+		// one: i % 10 = 1,2,5,7,8 or i % 100 = 20,50,70,80
+		// few: i % 10 = 3,4 or i % 1000 = 100,200,300,400,500,600,700,800,900
+		// many: i = 0 or i % 10 = 6 or i % 100 = 40,60,90
+		d, h := o.i%10, o.i%100
+		switch {
+		case d == 1 || d == 2 || d == 5 || d == 7 || d == 8 || h == 20 || h == 50 || h == 70 || h == 80:
+			return PluralOne
+		case d == 3 || d == 4 || (o.i%1000 != 0 && o.i%100 == 0):
+			return PluralFew
+		case o.i == 0 || d == 6 || h == 40 || h == 60 || h == 90:
+			return PluralMany
+		}
+		return PluralOther
+	}}, "az")
+
+	registerOrdinal(&pluralForm{cats: catsFO, fn: func(o operands) PluralCategory {
+		// few: n % 10 = 2,3 and n % 100 != 12,13
+		if o.nModIn(10, 2, 3) && !o.nModIn(100, 12, 13) {
+			return PluralFew
+		}
+		return PluralOther
+	}}, "be")
+
+	registerOrdinal(&pluralForm{cats: catsFO, fn: func(o operands) PluralCategory {
+		// few: n % 10 = 3 and n % 100 != 13
+		if o.nModIn(10, 3, 3) && !o.nModIn(100, 13, 13) {
+			return PluralFew
+		}
+		return PluralOther
+	}}, "uk")
+
+	registerOrdinal(&pluralForm{cats: catsFO, fn: func(o operands) PluralCategory {
+		// few: n % 10 = 6,9 or n = 10
+		if o.nModIn(10, 6, 6) || o.nModIn(10, 9, 9) || o.nIs(10) {
+			return PluralFew
+		}
+		return PluralOther
+	}}, "tk")
+
+	registerOrdinal(&pluralForm{cats: catsOTFMO, fn: func(o operands) PluralCategory {
+		// one: n = 1; two: n = 2,3; few: n = 4; many: n = 6
+		switch {
+		case o.nIs(1):
+			return PluralOne
+		case o.nIn(2, 3):
+			return PluralTwo
+		case o.nIs(4):
+			return PluralFew
+		case o.nIs(6):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "gu", "hi")
+
+	registerOrdinal(&pluralForm{cats: catsOTFMO, fn: func(o operands) PluralCategory {
+		// one: n = 1,5,7,8,9,10; two: n = 2,3; few: n = 4; many: n = 6
+		switch {
+		case o.nIs(1) || o.nIs(5) || o.nIn(7, 10):
+			return PluralOne
+		case o.nIn(2, 3):
+			return PluralTwo
+		case o.nIs(4):
+			return PluralFew
+		case o.nIs(6):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "as", "bn")
+
+	registerOrdinal(&pluralForm{cats: catsOTFMO, fn: func(o operands) PluralCategory {
+		// one: n = 1,5,7..9; two: n = 2,3; few: n = 4; many: n = 6
+		switch {
+		case o.nIs(1) || o.nIs(5) || o.nIn(7, 9):
+			return PluralOne
+		case o.nIn(2, 3):
+			return PluralTwo
+		case o.nIs(4):
+			return PluralFew
+		case o.nIs(6):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "or")
+
+	registerOrdinal(&pluralForm{cats: catsOTFO, fn: func(o operands) PluralCategory {
+		// one: n = 1; two: n = 2,3; few: n = 4
+		switch {
+		case o.nIs(1):
+			return PluralOne
+		case o.nIn(2, 3):
+			return PluralTwo
+		case o.nIs(4):
+			return PluralFew
+		}
+		return PluralOther
+	}}, "mr")
+
+	registerOrdinal(&pluralForm{cats: catsAll, fn: func(o operands) PluralCategory {
+		// zero: n = 0,7,8,9; one: n = 1; two: n = 2; few: n = 3,4; many: n = 5,6
+		switch {
+		case o.nIs(0) || o.nIn(7, 9):
+			return PluralZero
+		case o.nIs(1):
+			return PluralOne
+		case o.nIs(2):
+			return PluralTwo
+		case o.nIn(3, 4):
+			return PluralFew
+		case o.nIn(5, 6):
+			return PluralMany
+		}
+		return PluralOther
+	}}, "cy")
+
+	composeCLDR()
+}
+
+// Translation form of plural category.
+type catForm struct {
+	cat PluralCategory
+	// Exact zero form, eg: "{0} no files".
+	zero bool
+	t9n  string
+}
+
+// Get forms of translation rules by plural categories.
+//
+// Bare forms convert to categories of plural rules pr if their count matches count of categories. Returns false if
+// rules can't be expressed by categories, eg: contain ranges, select keywords or ICU message.
+func categoryForms(dst []catForm, rules []rule, buf []byte, pr *pluralRule) ([]catForm, bool) {
+	if isICU(rules) {
+		return dst, false
+	}
+	var nbare, nkw int
+	for i := 0; i < len(rules); i++ {
+		r := &rules[i]
+		if r.arg>>16 != 0 {
+			return dst, false
+		}
+		f := catForm{t9n: unescT9n(r.bp.take(buf))}
+		switch {
+		case r.kind == ruleCategory:
+			f.cat = PluralCategory(r.pos())
+			nkw++
+		case r.kind == ruleExact && r.lo == 0:
+			f.zero = true
+		case r.kind == ruleBare:
+			f.cat = PluralCategory(r.pos())
+			nbare++
+		default:
+			return dst, false
+		}
+		dst = append(dst, f)
+	}
+	if nbare == 0 {
+		return dst, true
+	}
+	if nkw > 0 || pr == nil || pr.forms != nil || nbare != len(pr.card.cats) {
+		return dst, false
+	}
+	for i := 0; i < len(dst); i++ {
+		if !dst[i].zero {
+			dst[i].cat = pr.card.cats[dst[i].cat]
+		}
+	}
+	return dst, true
+}
+
+// Compose translation of category forms, eg: "{0} no files|{one} one file|{other} many files".
+func catT9n(dst []byte, forms []catForm) []byte {
+	for i := 0; i < len(forms); i++ {
+		if i > 0 {
+			dst = append(dst, '|')
+		}
+		f := &forms[i]
+		if f.zero {
+			dst = append(dst, "{0} "...)
+		} else {
+			dst = append(dst, '{')
+			dst = append(dst, f.cat.String()...)
+			dst = append(dst, "} "...)
+		}
+		dst = escT9n(dst, f.t9n)
+	}
+	return dst
+}
+
+// Sort category forms: exact zero first, then categories in CLDR order.
+func sortCatForms(forms []catForm) {
+	sort.SliceStable(forms, func(i, j int) bool {
+		return forms[i].zero && !forms[j].zero || forms[i].zero == forms[j].zero && forms[i].cat < forms[j].cat
+	})
+}
@@ -0,0 +1,216 @@
+package i18n
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+func TestPluralCLDR(t *testing.T) {
+	t.Run("category", func(t *testing.T) {
+		db, _ := New(xxhash.Hasher64[string]{})
+		stages := []struct {
+			locale string
+			counts []int
+			expect []PluralCategory
+		}{
+			{"en", []int{0, 1, 2, 21}, []PluralCategory{PluralOther, PluralOne, PluralOther, PluralOther}},
+			{"fr", []int{0, 1, 2, 1000000}, []PluralCategory{PluralOne, PluralOne, PluralOther, PluralMany}},
+			{"ru-RU", []int{1, 3, 5, 11, 21, 22, 111}, []PluralCategory{PluralOne, PluralFew, PluralMany, PluralMany, PluralOne, PluralFew, PluralMany}},
+			{"pl", []int{1, 2, 5, 12, 22, 101}, []PluralCategory{PluralOne, PluralFew, PluralMany, PluralMany, PluralFew, PluralMany}},
+			{"ar", []int{0, 1, 2, 3, 11, 100}, []PluralCategory{PluralZero, PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther}},
+			{"ja", []int{0, 1, 2}, []PluralCategory{PluralOther, PluralOther, PluralOther}},
+			{"cy", []int{0, 1, 2, 3, 6, 7}, []PluralCategory{PluralZero, PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther}},
+			{"lv", []int{0, 1, 11, 21, 2}, []PluralCategory{PluralZero, PluralOne, PluralZero, PluralOne, PluralOther}},
+			{"pt-PT", []int{0, 1}, []PluralCategory{PluralOther, PluralOne}},
+		}
+		for _, st := range stages {
+			pr := db.plural(st.locale)
+			if pr == nil {
+				t.Errorf("no plural rules of %s", st.locale)
+				continue
+			}
+			for i, n := range st.counts {
+				o := intOperands(int64(n))
+				if c, _ := pr.card.category(o); c != st.expect[i] {
+					t.Errorf("%s: category mismatch of %d, need %s got %s", st.locale, n, st.expect[i], c)
+				}
+			}
+		}
+		if db.plural("xx-YY") != nil {
+			t.Error("unexpected plural rules of unknown locale")
+		}
+	})
+	t.Run("bare", func(t *testing.T) {
+		db, _ := New(xxhash.Hasher64[string]{})
+		_ = db.Set("ru.apples", "яблоко|яблока|яблок")
+		_ = db.Set("fr.apples", "pomme|pommes")
+		_ = db.Set("ar.apples", "لا تفاحات|تفاحة|تفاحتان|تفاحات|تفاحة|تفاحة")
+		_ = db.Set("en.apples", "{0} no apples|apple|apples")
+		_ = db.Set("en.greeting", "Hello!")
+		_ = db.Set("apples", "apple|apples")
+
+		assertT9nPlural(t, db, "ru.apples", "яблоко", 21)
+		assertT9nPlural(t, db, "ru.apples", "яблока", 3)
+		assertT9nPlural(t, db, "ru.apples", "яблок", 11)
+		assertT9nPlural(t, db, "fr.apples", "pomme", 0)
+		assertT9nPlural(t, db, "fr.apples", "pommes", 2)
+		assertT9nPlural(t, db, "ar.apples", "تفاحتان", 2)
+		assertT9nPlural(t, db, "ar.apples", "تفاحات", 7)
+		assertT9nPlural(t, db, "en.apples", "no apples", 0)
+		assertT9nPlural(t, db, "en.apples", "apple", 1)
+		assertT9nPlural(t, db, "en.apples", "apples", 5)
+		assertT9nPlural(t, db, "en.greeting", "Hello!", 5)
+		// Keys without locale keep legacy ranges.
+		assertT9nPlural(t, db, "apples", "apple", 0)
+		assertT9nPlural(t, db, "apples", "apples", 2)
+
+		db.EnableFallback()
+		l := db.Localizer("ru-RU")
+		if s := l.GetPlural("apples", "", 2); s != "яблока" {
+			t.Errorf("localizer plural mismatch, need яблока got %s", s)
+		}
+	})
+	t.Run("dump", func(t *testing.T) {
+		db, _ := New(xxhash.Hasher64[string]{})
+		_ = db.Set("ru.apples", "яблоко|яблока|яблок")
+		var buf bytes.Buffer
+		if _, err := db.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		db1, _ := New(xxhash.Hasher64[string]{})
+		if _, err := db1.ReadFrom(&buf); err != nil {
+			t.Fatal(err)
+		}
+		assertT9nPlural(t, db1, "ru.apples", "яблок", 5)
+	})
+}
+
+func TestOrdinal(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	_ = db.Set("en.place", "{one} !nst|{two} !nnd|{few} !nrd|{other} !nth")
+	_ = db.Set("en.place_bare", "st|nd|rd|th")
+	_ = db.Set("it.place", "{many} l'!n°|il !n°")
+	_ = db.Set("ru.place", "!n-е место")
+	_ = db.Set("en.apples", "{one} one apple|{other} many apples")
+
+	assertOrdinal := func(t *testing.T, key string, n int, expect string) {
+		repl := PlaceholderReplacer{}
+		repl.AddKV("!n", strconv.Itoa(n))
+		if s := db.GetOrdinalWR(key, "", n, &repl); s != expect {
+			t.Errorf("ordinal mismatch, need %s got %s", expect, s)
+		}
+	}
+	assertOrdinal(t, "en.place", 1, "1st")
+	assertOrdinal(t, "en.place", 2, "2nd")
+	assertOrdinal(t, "en.place", 3, "3rd")
+	assertOrdinal(t, "en.place", 11, "11th")
+	assertOrdinal(t, "en.place", 22, "22nd")
+	assertOrdinal(t, "en.place", 103, "103rd")
+	assertOrdinal(t, "en.place_bare", 4, "th")
+	assertOrdinal(t, "en.place_bare", 42, "nd")
+	assertOrdinal(t, "it.place", 8, "l'8°")
+	assertOrdinal(t, "it.place", 2, "il 2°")
+	assertOrdinal(t, "ru.place", 3, "3-е место")
+
+	// Category keywords work for cardinals too.
+	assertT9nPlural(t, db, "en.apples", "one apple", 1)
+	assertT9nPlural(t, db, "en.apples", "many apples", 3)
+
+	l := db.Localizer("en")
+	if s := l.GetOrdinal("place_bare", "", 3); s != "rd" {
+		t.Errorf("localizer ordinal mismatch, need rd got %s", s)
+	}
+}
+
+func TestPluralDecimal(t *testing.T) {
+	t.Run("operands", func(t *testing.T) {
+		stages := []struct {
+			num    string
+			expect operands
+			count  int64
+		}{
+			{"1", operands{i: 1}, 1},
+			{"1.0", operands{i: 1, v: 1}, 1},
+			{"-1.50", operands{i: 1, v: 2, w: 1, f: 50, t: 5}, -1},
+			{"0.05", operands{v: 2, w: 2, f: 5, t: 5}, 0},
+			{"+12.340", operands{i: 12, v: 3, w: 2, f: 340, t: 34}, 12},
+		}
+		for _, st := range stages {
+			var q query
+			if !q.parseDecimal(st.num) {
+				t.Errorf("%s: parse failed", st.num)
+				continue
+			}
+			if q.op != st.expect || q.count != st.count {
+				t.Errorf("%s: operands mismatch, need %+v/%d got %+v/%d", st.num, st.expect, st.count, q.op, q.count)
+			}
+		}
+		for _, num := range []string{"", "-", "1.", ".5", "1,5", "1.5e3", "abc"} {
+			var q query
+			if q.parseDecimal(num) {
+				t.Errorf("%s: parse must fail", num)
+			}
+		}
+	})
+	t.Run("lookup", func(t *testing.T) {
+		db, _ := New(xxhash.Hasher64[string]{})
+		_ = db.Set("en.hours", "hour|hours")
+		_ = db.Set("ru.hours", "час|часа|часов|часа")
+		_ = db.Set("lv.km", "kilometru|kilometrs|kilometri")
+		_ = db.Set("en.dist", "{1} exactly one|[0,2] less than two|[2,*] two or more")
+		_ = db.Set("en.temp", "[*,0] freezing|[0,30] fine|[30,*] hot")
+
+		assertF := func(t *testing.T, key string, n float64, expect string) {
+			if s := db.GetPluralFloat(key, "", n); s != expect {
+				t.Errorf("%s(%v): need %s got %s", key, n, expect, s)
+			}
+		}
+		assertD := func(t *testing.T, key, n, expect string) {
+			if s := db.GetPluralDecimal(key, "N/D", n); s != expect {
+				t.Errorf("%s(%s): need %s got %s", key, n, expect, s)
+			}
+		}
+		assertF(t, "en.hours", 1, "hour")
+		assertF(t, "en.hours", 1.5, "hours")
+		assertD(t, "en.hours", "1", "hour")
+		assertD(t, "en.hours", "1.0", "hours")
+		assertD(t, "ru.hours", "1.5", "часа")
+		assertD(t, "ru.hours", "5", "часов")
+		assertD(t, "lv.km", "0.1", "kilometrs")
+		assertD(t, "lv.km", "0.11", "kilometru")
+		assertF(t, "en.dist", 1, "exactly one")
+		assertF(t, "en.dist", 1.5, "less than two")
+		assertF(t, "en.dist", 2.5, "two or more")
+		assertF(t, "en.temp", -0.5, "freezing")
+		assertF(t, "en.temp", 0.5, "fine")
+		assertF(t, "en.temp", 29.9, "fine")
+		assertF(t, "en.temp", 30.1, "hot")
+		assertD(t, "en.hours", "1.5.0", "N/D")
+
+		l := db.Localizer("ru")
+		if s := l.GetPluralDecimal("hours", "", "2.5"); s != "часа" {
+			t.Errorf("localizer decimal mismatch, need часа got %s", s)
+		}
+	})
+}
+
+func BenchmarkPluralCLDR(b *testing.B) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	_ = db.Set("ru.apples", "яблоко|яблока|яблок")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = db.GetPlural("ru.apples", "", i)
+	}
+}
+
+func BenchmarkPluralDecimal(b *testing.B) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	_ = db.Set("ru.hours", "час|часа|часов|часа")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = db.GetPluralFloat("ru.hours", "", 1.5)
+	}
+}
@@ -0,0 +1,596 @@
+package i18n
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Compiled gettext plural expression, returns index of plural form.
+type pluralExpr func(n uint64) uint64
+
+// Gettext plural forms of locale.
+type pluralForms struct {
+	// Count of plural forms.
+	n    int
+	expr pluralExpr
+	// Source expression, eg: "nplurals=2; plural=(n != 1);".
+	src string
+}
+
+// Get index of plural form of number n.
+//
+// Gettext works with unsigned integers, so the sign and fraction of number are ignored.
+func (pf *pluralForms) index(n uint64) int {
+	if i := pf.expr(n); i < uint64(pf.n) {
+		return int(i)
+	}
+	return pf.n - 1
+}
+
+// SetPluralForms sets gettext plural forms of the locale, eg:
+//
+//	db.SetPluralForms("ru", "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);")
+//
+// Header may be passed as is from PO file, including "Plural-Forms:" prefix. Plural forms take priority over CLDR
+// rules and select bare forms of translations by index, that the expression returns. Child locales inherit plural
+// forms, eg: "ru-RU" uses forms of "ru". Empty header removes plural forms of the locale.
+//
+// Localizers made before the call keep using the previous rules.
+func (db *DB) SetPluralForms(locale, header string) error {
+	if err := db.checkStatus(); err != nil {
+		return err
+	}
+	var pf *pluralForms
+	if len(header) > 0 {
+		var err error
+		if pf, err = parsePluralForms(header); err != nil {
+			return err
+		}
+	}
+
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	db.setPluralFormsLF(locale, pf)
+	return nil
+}
+
+// Set plural forms of locale, nil pf removes them.
+//
+// Database must be locked.
+func (db *DB) setPluralFormsLF(locale string, pf *pluralForms) {
+	old := db.pluralForms()
+	forms := make(map[string]*pluralRule, len(old)+1)
+	for k, v := range old {
+		forms[k] = v
+	}
+	if pf == nil {
+		delete(forms, locale)
+	} else {
+		r := &pluralRule{card: formOther, ord: formOther, forms: pf}
+		if cr := cldrRule(locale); cr != nil {
+			r.card, r.ord = cr.card, cr.ord
+		}
+		forms[locale] = r
+	}
+	atomic.StorePointer(&db.pf, unsafe.Pointer(&forms))
+}
+
+// Get plural forms expression of locale: set by SetPluralForms() or made of CLDR rules.
+func (db *DB) pluralFormsSrc(locale string) string {
+	r := db.plural(locale)
+	if r != nil && r.forms != nil {
+		return r.forms.src
+	}
+	return cldrPluralForms(r)
+}
+
+// Load current custom plural rules.
+func (db *DB) pluralForms() map[string]*pluralRule {
+	if p := (*map[string]*pluralRule)(atomic.LoadPointer(&db.pf)); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// Parse Plural-Forms header.
+func parsePluralForms(header string) (*pluralForms, error) {
+	header = strings.TrimSpace(header)
+	if len(header) > 13 && strings.EqualFold(header[:13], "Plural-Forms:") {
+		header = header[13:]
+	}
+	var (
+		pf         pluralForms
+		nok, exprk bool
+	)
+	for _, field := range strings.Split(header, ";") {
+		field = strings.TrimSpace(field)
+		i := strings.IndexByte(field, '=')
+		if i == -1 {
+			if len(field) > 0 {
+				return nil, ErrBadPluralForms
+			}
+			continue
+		}
+		k, v := strings.TrimSpace(field[:i]), strings.TrimSpace(field[i+1:])
+		switch k {
+		case "nplurals":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return nil, ErrBadPluralForms
+			}
+			pf.n, nok = n, true
+		case "plural":
+			p := pexprParser{s: v}
+			expr, err := p.parse()
+			if err != nil {
+				return nil, err
+			}
+			pf.expr, exprk = expr, true
+		}
+	}
+	if !nok || !exprk {
+		return nil, ErrBadPluralForms
+	}
+	pf.src = header
+	return &pf, nil
+}
+
+// Recursive descent parser of C-like gettext plural expression.
+//
+// Grammar in order of precedence:
+//
+//	expr    := or ['?' expr ':' expr]
+//	or      := and {'||' and}
+//	and     := eq {'&&' eq}
+//	eq      := rel {('==' | '!=') rel}
+//	rel     := add {('<' | '>' | '<=' | '>=') add}
+//	add     := mul {('+' | '-') mul}
+//	mul     := unary {('*' | '/' | '%') unary}
+//	unary   := '!' unary | primary
+//	primary := 'n' | number | '(' expr ')'
+type pexprParser struct {
+	s   string
+	off int
+}
+
+func (p *pexprParser) parse() (expr pluralExpr, err error) {
+	if expr, err = p.ternary(); err != nil {
+		return
+	}
+	if p.skip(); p.off != len(p.s) {
+		return nil, ErrBadPluralForms
+	}
+	return
+}
+
+func (p *pexprParser) ternary() (pluralExpr, error) {
+	cond, err := p.or()
+	if err != nil || !p.accept("?") {
+		return cond, err
+	}
+	then, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(":") {
+		return nil, ErrBadPluralForms
+	}
+	els, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return func(n uint64) uint64 {
+		if cond(n) != 0 {
+			return then(n)
+		}
+		return els(n)
+	}, nil
+}
+
+func (p *pexprParser) or() (pluralExpr, error) {
+	l, err := p.and()
+	for err == nil && p.accept("||") {
+		var r pluralExpr
+		if r, err = p.and(); err == nil {
+			l = binop(l, r, func(a, b uint64) bool { return a != 0 || b != 0 })
+		}
+	}
+	return l, err
+}
+
+func (p *pexprParser) and() (pluralExpr, error) {
+	l, err := p.eq()
+	for err == nil && p.accept("&&") {
+		var r pluralExpr
+		if r, err = p.eq(); err == nil {
+			l = binop(l, r, func(a, b uint64) bool { return a != 0 && b != 0 })
+		}
+	}
+	return l, err
+}
+
+func (p *pexprParser) eq() (pluralExpr, error) {
+	l, err := p.rel()
+	for err == nil {
+		var op func(a, b uint64) bool
+		switch {
+		case p.accept("=="):
+			op = func(a, b uint64) bool { return a == b }
+		case p.accept("!="):
+			op = func(a, b uint64) bool { return a != b }
+		default:
+			return l, nil
+		}
+		var r pluralExpr
+		if r, err = p.rel(); err == nil {
+			l = binop(l, r, op)
+		}
+	}
+	return nil, err
+}
+
+func (p *pexprParser) rel() (pluralExpr, error) {
+	l, err := p.add()
+	for err == nil {
+		var op func(a, b uint64) bool
+		// Check two-char operators first.
+		switch {
+		case p.accept("<="):
+			op = func(a, b uint64) bool { return a <= b }
+		case p.accept(">="):
+			op = func(a, b uint64) bool { return a >= b }
+		case p.accept("<"):
+			op = func(a, b uint64) bool { return a < b }
+		case p.accept(">"):
+			op = func(a, b uint64) bool { return a > b }
+		default:
+			return l, nil
+		}
+		var r pluralExpr
+		if r, err = p.add(); err == nil {
+			l = binop(l, r, op)
+		}
+	}
+	return nil, err
+}
+
+func (p *pexprParser) add() (pluralExpr, error) {
+	l, err := p.mul()
+	for err == nil {
+		var r pluralExpr
+		switch {
+		case p.accept("+"):
+			if r, err = p.mul(); err == nil {
+				l = arith(l, r, func(a, b uint64) uint64 { return a + b })
+			}
+		case p.accept("-"):
+			if r, err = p.mul(); err == nil {
+				l = arith(l, r, func(a, b uint64) uint64 { return a - b })
+			}
+		default:
+			return l, nil
+		}
+	}
+	return nil, err
+}
+
+func (p *pexprParser) mul() (pluralExpr, error) {
+	l, err := p.unary()
+	for err == nil {
+		var r pluralExpr
+		switch {
+		case p.accept("*"):
+			if r, err = p.unary(); err == nil {
+				l = arith(l, r, func(a, b uint64) uint64 { return a * b })
+			}
+		case p.accept("/"):
+			if r, err = p.unary(); err == nil {
+				// Division by zero gives zero instead of panic.
+				l = arith(l, r, func(a, b uint64) uint64 {
+					if b == 0 {
+						return 0
+					}
+					return a / b
+				})
+			}
+		case p.accept("%"):
+			if r, err = p.unary(); err == nil {
+				l = arith(l, r, func(a, b uint64) uint64 {
+					if b == 0 {
+						return 0
+					}
+					return a % b
+				})
+			}
+		default:
+			return l, nil
+		}
+	}
+	return nil, err
+}
+
+func (p *pexprParser) unary() (pluralExpr, error) {
+	// Don't confuse with "!=" since binary operator can't start an operand.
+	if p.accept("!") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n uint64) uint64 { return b2u(x(n) == 0) }, nil
+	}
+	return p.primary()
+}
+
+func (p *pexprParser) primary() (pluralExpr, error) {
+	p.skip()
+	if p.off == len(p.s) {
+		return nil, ErrBadPluralForms
+	}
+	switch c := p.s[p.off]; {
+	case c == 'n':
+		p.off++
+		return func(n uint64) uint64 { return n }, nil
+	case c >= '0' && c <= '9':
+		start := p.off
+		for p.off < len(p.s) && p.s[p.off] >= '0' && p.s[p.off] <= '9' {
+			p.off++
+		}
+		v, err := strconv.ParseUint(p.s[start:p.off], 10, 64)
+		if err != nil {
+			return nil, ErrBadPluralForms
+		}
+		return func(uint64) uint64 { return v }, nil
+	case c == '(':
+		p.off++
+		x, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, ErrBadPluralForms
+		}
+		return x, nil
+	}
+	return nil, ErrBadPluralForms
+}
+
+// Skip spaces and consume token tok if it's next.
+func (p *pexprParser) accept(tok string) bool {
+	if p.skip(); strings.HasPrefix(p.s[p.off:], tok) {
+		p.off += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *pexprParser) skip() {
+	for p.off < len(p.s) && (p.s[p.off] == ' ' || p.s[p.off] == '\t' || p.s[p.off] == '\n' || p.s[p.off] == '\r') {
+		p.off++
+	}
+}
+
+func binop(l, r pluralExpr, op func(a, b uint64) bool) pluralExpr {
+	return func(n uint64) uint64 { return b2u(op(l(n), r(n))) }
+}
+
+func arith(l, r pluralExpr, op func(a, b uint64) uint64) pluralExpr {
+	return func(n uint64) uint64 { return op(l(n), r(n)) }
+}
+
+func b2u(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Make gettext plural forms of CLDR cardinal rules pr, eg: "nplurals=2; plural=(n==1 ? 0 : 1);".
+//
+// Gettext counts are integers, so categories of fractions only are skipped. Nil rules get legacy forms of bare
+// translations. Returns empty string if rules depend on anything but remainders of 10 and 100.
+func cldrPluralForms(pr *pluralRule) string {
+	if pr == nil {
+		return "nplurals=2; plural=(n > 1);"
+	}
+	index := func(n uint64) int {
+		_, pos := pr.card.category(operands{i: n})
+		return pos
+	}
+	// Forms of counts below 100 and of the rest by remainder of 100.
+	var small, per [100]int
+	cnt := make([]int, len(pr.card.cats))
+	var np, def int
+	for r := 0; r < 100; r++ {
+		small[r], per[r] = index(uint64(r)), index(uint64(100+r))
+		cnt[small[r]]++
+		cnt[per[r]]++
+		if small[r] >= np {
+			np = small[r] + 1
+		}
+		if per[r] >= np {
+			np = per[r] + 1
+		}
+	}
+	// Some languages have a special form of millions, eg: "many" of French.
+	mln := index(1e6)
+	if mln == per[0] {
+		mln = -1
+	} else if mln >= np {
+		np = mln + 1
+	}
+	// The most frequent form is the default one.
+	for k := 0; k < len(cnt); k++ {
+		if cnt[k] > cnt[def] {
+			def = k
+		}
+	}
+	var b strings.Builder
+	b.WriteString("nplurals=")
+	b.WriteString(strconv.Itoa(np))
+	b.WriteString("; plural=(")
+	if mln >= 0 {
+		b.WriteString("n!=0 && n%1000000==0 ? ")
+		b.WriteString(strconv.Itoa(mln))
+		b.WriteString(" : ")
+	}
+	for k := 0; k < np; k++ {
+		if k == def || cnt[k] == 0 {
+			continue
+		}
+		b.WriteString(pluralCond(k, &small, &per))
+		b.WriteString(" ? ")
+		b.WriteString(strconv.Itoa(k))
+		b.WriteString(" : ")
+	}
+	b.WriteString(strconv.Itoa(def))
+	b.WriteString(");")
+	src := b.String()
+
+	// Check the expression, since some rules depend on greater remainders.
+	pf, err := parsePluralForms(src)
+	if err != nil {
+		return ""
+	}
+	for n := uint64(0); n < 10000; n++ {
+		if pf.index(n) != index(n) {
+			return ""
+		}
+	}
+	for _, n := range [...]uint64{1e5, 1e6, 1e6 + 1, 1e9, 1e12} {
+		if pf.index(n) != index(n) {
+			return ""
+		}
+	}
+	return src
+}
+
+// Make condition of form k by forms of small counts and remainders of 100.
+func pluralCond(k int, small, per *[100]int) string {
+	var s, p, a, b [100]bool
+	var np, na, nb int
+	for r := 0; r < 100; r++ {
+		s[r], p[r] = small[r] == k, per[r] == k
+		a[r], b[r] = s[r] && !p[r], p[r] && !s[r]
+		np += int(b2u(p[r]))
+		na += int(b2u(a[r]))
+		nb += int(b2u(b[r]))
+	}
+	if np == 0 {
+		return pexprSet("n", s[:], false)
+	}
+	c := pexprMod100(&p)
+	if nb > 0 {
+		c = pexprParen(c) + " && " + pexprNot("n", b[:], false)
+	}
+	if na > 0 {
+		c = pexprSet("n", a[:], false) + " || " + pexprParen(c)
+	}
+	return c
+}
+
+// Make the shortest condition of remainder of 100 in set x: by ranges or by remainders of 10 with exceptions.
+func pexprMod100(x *[100]bool) string {
+	c := pexprSet("n%100", x[:], true)
+	var (
+		d    [10]bool
+		nd   [10]int
+		e, f [100]bool
+	)
+	for r := 0; r < 100; r++ {
+		nd[r%10] += int(b2u(x[r]))
+	}
+	var ok, ne, nf bool
+	for i := 0; i < 10; i++ {
+		d[i] = nd[i] >= 5
+		ok = ok || d[i]
+	}
+	if !ok {
+		return c
+	}
+	for r := 0; r < 100; r++ {
+		e[r], f[r] = d[r%10] && !x[r], x[r] && !d[r%10]
+		ne, nf = ne || e[r], nf || f[r]
+	}
+	c1 := pexprSet("n%10", d[:], true)
+	if ne {
+		c1 = pexprParen(c1) + " && " + pexprNot("n%100", e[:], true)
+	}
+	if nf {
+		c1 = pexprSet("n%100", f[:], true) + " || " + pexprParen(c1)
+	}
+	if len(c1) < len(c) {
+		return c1
+	}
+	return c
+}
+
+// Make condition of v in set x by ranges of values. Open means range at the end of x has no upper bound.
+func pexprSet(v string, x []bool, open bool) string {
+	var parts []string
+	for lo := 0; lo < len(x); lo++ {
+		if !x[lo] {
+			continue
+		}
+		hi := lo
+		for hi+1 < len(x) && x[hi+1] {
+			hi++
+		}
+		switch {
+		case lo == hi:
+			parts = append(parts, v+"=="+strconv.Itoa(lo))
+		case lo == 0 && hi == len(x)-1 && open:
+			parts = append(parts, "1")
+		case lo == 0:
+			parts = append(parts, v+"<="+strconv.Itoa(hi))
+		case hi == len(x)-1 && open:
+			parts = append(parts, v+">="+strconv.Itoa(lo))
+		default:
+			parts = append(parts, v+">="+strconv.Itoa(lo)+" && "+v+"<="+strconv.Itoa(hi))
+		}
+		lo = hi
+	}
+	if len(parts) > 1 {
+		for i := 0; i < len(parts); i++ {
+			if strings.Contains(parts[i], "&&") {
+				parts[i] = "(" + parts[i] + ")"
+			}
+		}
+	}
+	return strings.Join(parts, " || ")
+}
+
+// Make condition of v not in set x, open has the same meaning as in pexprSet.
+func pexprNot(v string, x []bool, open bool) string {
+	var parts []string
+	for lo := 0; lo < len(x); lo++ {
+		if !x[lo] {
+			continue
+		}
+		hi := lo
+		for hi+1 < len(x) && x[hi+1] {
+			hi++
+		}
+		switch {
+		case lo == hi:
+			parts = append(parts, v+"!="+strconv.Itoa(lo))
+		case lo == 0:
+			parts = append(parts, v+">"+strconv.Itoa(hi))
+		case hi == len(x)-1 && open:
+			parts = append(parts, v+"<"+strconv.Itoa(lo))
+		default:
+			parts = append(parts, "("+v+"<"+strconv.Itoa(lo)+" || "+v+">"+strconv.Itoa(hi)+")")
+		}
+		lo = hi
+	}
+	return strings.Join(parts, " && ")
+}
+
+// Wrap condition c to parentheses if it contains "||".
+func pexprParen(c string) string {
+	if strings.Contains(c, "||") {
+		return "(" + c + ")"
+	}
+	return c
+}
@@ -0,0 +1,122 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+func TestPluralForms(t *testing.T) {
+	t.Run("expr", func(t *testing.T) {
+		stages := []struct {
+			header string
+			counts []uint64
+			expect []int
+		}{
+			{"nplurals=1; plural=0;", []uint64{0, 1, 5}, []int{0, 0, 0}},
+			{"nplurals=2; plural=(n != 1);", []uint64{0, 1, 2}, []int{1, 0, 1}},
+			{"nplurals=2; plural=(n > 1);", []uint64{0, 1, 2}, []int{0, 0, 1}},
+			{"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+				[]uint64{1, 2, 5, 11, 21, 22, 112}, []int{0, 1, 2, 2, 0, 1, 2}},
+			{"nplurals=6; plural=n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5;",
+				[]uint64{0, 1, 2, 3, 11, 100}, []int{0, 1, 2, 3, 4, 5}},
+			{"nplurals=2; plural=!(n==1);", []uint64{1, 7}, []int{0, 1}},
+			{"nplurals=3; plural=n*2-n+n/1%3;", []uint64{1, 3, 7}, []int{2, 2, 2}},
+			{"nplurals=2; plural=n/0+n%0;", []uint64{1}, []int{0}},
+		}
+		for _, st := range stages {
+			pf, err := parsePluralForms(st.header)
+			if err != nil {
+				t.Errorf("%s: %s", st.header, err)
+				continue
+			}
+			for i, n := range st.counts {
+				if idx := pf.index(n); idx != st.expect[i] {
+					t.Errorf("%s: index mismatch of %d, need %d got %d", st.header, n, st.expect[i], idx)
+				}
+			}
+		}
+		for _, header := range []string{
+			"",
+			"nplurals=2;",
+			"plural=(n != 1);",
+			"nplurals=0; plural=0;",
+			"nplurals=2; plural=(n != 1;",
+			"nplurals=2; plural=n ? 1;",
+			"nplurals=2; plural=x;",
+			"nplurals=2; plural=n 1;",
+		} {
+			if _, err := parsePluralForms(header); err != ErrBadPluralForms {
+				t.Errorf("%s: need ErrBadPluralForms got %v", header, err)
+			}
+		}
+	})
+	t.Run("cldr", func(t *testing.T) {
+		// Expressions are checked while making, so all locales must be representable.
+		for locale, r := range cldrPlurals {
+			if len(cldrPluralForms(r)) == 0 {
+				t.Errorf("%s: no plural forms", locale)
+			}
+		}
+		stages := []struct {
+			locale, expect string
+		}{
+			{"en", "nplurals=2; plural=(n==1 ? 0 : 1);"},
+			{"ja", "nplurals=1; plural=(0);"},
+			{"ru", "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<12 || n%100>14) ? 1 : 2);"},
+			{"fr", "nplurals=3; plural=(n!=0 && n%1000000==0 ? 1 : n<=1 ? 0 : 2);"},
+		}
+		for _, st := range stages {
+			if s := cldrPluralForms(cldrPlurals[st.locale]); s != st.expect {
+				t.Errorf("%s: plural forms mismatch, need %q got %q", st.locale, st.expect, s)
+			}
+		}
+		if s := cldrPluralForms(nil); s != "nplurals=2; plural=(n > 1);" {
+			t.Errorf("legacy plural forms mismatch, got %q", s)
+		}
+	})
+	t.Run("lookup", func(t *testing.T) {
+		db, _ := New(xxhash.Hasher64[string]{})
+		_ = db.Set("fr.files", "fichier|fichiers")
+		_ = db.Set("ru.files", "файл|файла|файлов")
+		_ = db.Set("ru-RU.dirs", "{0} нет папок|папка|папки|папок")
+
+		// CLDR makes 0 singular in French.
+		assertT9nPlural(t, db, "fr.files", "fichier", 0)
+		if err := db.SetPluralForms("fr", "nplurals=2; plural=(n != 1);"); err != nil {
+			t.Fatal(err)
+		}
+		assertT9nPlural(t, db, "fr.files", "fichiers", 0)
+		assertT9nPlural(t, db, "fr.files", "fichier", 1)
+
+		if err := db.SetPluralForms("ru", "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);"); err != nil {
+			t.Fatal(err)
+		}
+		assertT9nPlural(t, db, "ru.files", "файла", 22)
+		assertT9nPlural(t, db, "ru.files", "файлов", 111)
+		assertT9nPlural(t, db, "ru-RU.dirs", "нет папок", 0)
+		assertT9nPlural(t, db, "ru-RU.dirs", "папка", 21)
+		assertT9nPlural(t, db, "ru-RU.dirs", "папки", 3)
+
+		l := db.Localizer("fr")
+		if s := l.GetPlural("files", "", 0); s != "fichiers" {
+			t.Errorf("localizer plural mismatch, need fichiers got %s", s)
+		}
+
+		if err := db.SetPluralForms("fr", "nplurals=2; plural=n +;"); err != ErrBadPluralForms {
+			t.Errorf("need ErrBadPluralForms got %v", err)
+		}
+		_ = db.SetPluralForms("fr", "")
+		assertT9nPlural(t, db, "fr.files", "fichier", 0)
+	})
+}
+
+func BenchmarkPluralForms(b *testing.B) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	_ = db.SetPluralForms("ru", "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);")
+	_ = db.Set("ru.files", "файл|файла|файлов")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = db.GetPlural("ru.files", "", i)
+	}
+}
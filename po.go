@@ -0,0 +1,454 @@
+package i18n
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PO flags of special translations, they keep translation as is on import.
+const (
+	// ICU message, see DB.SetICU().
+	poFlagICU = "icu-format"
+	// Translation with plural formula rules, see DB.Set().
+	poFlagRules = "i18n-rules"
+)
+
+// POSidecar keeps PO data which DB doesn't store, so it may survive import and export.
+//
+// Sidecar contains exported fields only and may be saved in any format, eg JSON.
+type POSidecar struct {
+	// Plural source strings (msgid_plural) by msgid, eg: "One file" => "%d files". Msgid of contextual message is
+	// prefixed with msgctxt and EOT char "\x04" like in MO files.
+	Plurals map[string]string
+}
+
+// LoadPO loads gettext PO file of locale to db, eg:
+//
+//	f, _ := os.Open("ru.po")
+//	err := i18n.LoadPO(db, "ru", f, nil)
+//
+// Key of translation is locale and msgid, eg: "ru.Open file", msgctxt becomes message context (see DB.SetCtx()). Plural
+// forms msgstr[n] save as bare forms, "Plural-Forms" header applies to locale using DB.SetPluralForms(). Fuzzy, obsolete
+// and untranslated messages are skipped. Plural source strings msgid_plural are saved to sidecar sc if it isn't nil.
+//
+// All translations save in single transaction, so nothing saves if file is malformed. Errors contain line number, see
+// ParseError.
+func LoadPO(db *DB, locale string, r io.Reader, sc *POSidecar) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	tx := db.Begin()
+	p := poParser{db: db, tx: tx, locale: locale}
+	if sc != nil {
+		if sc.Plurals == nil {
+			sc.Plurals = make(map[string]string)
+		}
+		p.plurals = sc.Plurals
+	}
+	if err := p.parse(r); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if len(p.pf) > 0 {
+		if err := tx.SetPluralForms(locale, p.pf); err != nil {
+			tx.Rollback()
+			return &ParseError{Line: p.pfLine, Err: err}
+		}
+	}
+	return tx.Commit()
+}
+
+// DumpPO writes all translations of locale to w in gettext PO format.
+//
+// Plural translations with bare forms write as msgid_plural entries, plural source is taken from sidecar sc if it isn't
+// nil, otherwise msgid uses. "Plural-Forms" header is set by DB.SetPluralForms() or made of CLDR rules of locale. ICU
+// messages and translations with plural formula rules write as is and marked with flags "icu-format" and "i18n-rules",
+// so LoadPO() restores them exactly.
+func DumpPO(db *DB, locale string, w io.Writer, sc *POSidecar) error {
+	if err := db.checkStatus(); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString("msgid \"\"\nmsgstr \"\"\n")
+	header := "Language: " + locale + "\n" +
+		"MIME-Version: 1.0\n" +
+		"Content-Type: text/plain; charset=UTF-8\n" +
+		"Content-Transfer-Encoding: 8bit\n"
+	if pf := db.pluralFormsSrc(locale); len(pf) > 0 {
+		header += "Plural-Forms: " + pf + "\n"
+	}
+	for _, line := range strings.SplitAfter(header, "\n") {
+		if len(line) > 0 {
+			writePOString(bw, "", line)
+		}
+	}
+
+	prefix := locale + "."
+	var forms []string
+	db.snap().eachPrefix(prefix, func(key string, rules []rule, buf []byte) {
+		key, ctx := splitCtx(key)
+		id := key[len(prefix):]
+		_ = bw.WriteByte('\n')
+		var ok bool
+		if isICU(rules) {
+			_, _ = bw.WriteString("#, " + poFlagICU + "\n")
+			forms = append(forms[:0], rules[0].bp.take(buf))
+		} else if forms, ok = bareForms(forms[:0], rules, buf); !ok {
+			_, _ = bw.WriteString("#, " + poFlagRules + "\n")
+			forms = append(forms[:0], rawRules(rules, buf))
+		}
+		if len(ctx) > 0 {
+			writePOString(bw, "msgctxt", ctx)
+		}
+		writePOString(bw, "msgid", id)
+		if len(forms) == 1 {
+			writePOString(bw, "msgstr", forms[0])
+			return
+		}
+		idPlural := id
+		if sc != nil {
+			if s, ok := sc.Plurals[poSidecarKey(ctx, id)]; ok {
+				idPlural = s
+			}
+		}
+		writePOString(bw, "msgid_plural", idPlural)
+		for i := 0; i < len(forms); i++ {
+			writePOString(bw, "msgstr["+strconv.Itoa(i)+"]", forms[i])
+		}
+	})
+	return bw.Flush()
+}
+
+// Make sidecar key of message: msgid prefixed with msgctxt and EOT char if context isn't empty.
+func poSidecarKey(ctx, id string) string {
+	if len(ctx) == 0 {
+		return id
+	}
+	return ctx + "\x04" + id
+}
+
+// Write PO keyword kw with quoted string s. Multiline string splits to lines after empty string.
+func writePOString(w *bufio.Writer, kw, s string) {
+	if len(kw) > 0 {
+		_, _ = w.WriteString(kw)
+		_ = w.WriteByte(' ')
+		if i := strings.IndexByte(s, '\n'); i != -1 && i < len(s)-1 {
+			_, _ = w.WriteString("\"\"\n")
+			for _, line := range strings.SplitAfter(s, "\n") {
+				if len(line) > 0 {
+					writePOString(w, "", line)
+				}
+			}
+			return
+		}
+	}
+	_ = w.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\':
+			_ = w.WriteByte('\\')
+			_ = w.WriteByte(c)
+		case '\n':
+			_, _ = w.WriteString("\\n")
+		case '\t':
+			_, _ = w.WriteString("\\t")
+		case '\r':
+			_, _ = w.WriteString("\\r")
+		default:
+			_ = w.WriteByte(c)
+		}
+	}
+	_, _ = w.WriteString("\"\n")
+}
+
+// PO file parser.
+type poParser struct {
+	db     *DB
+	tx     *Txn
+	locale string
+	// Plural-Forms header and its line.
+	pf     string
+	pfLine int
+	// Plural source strings of sidecar.
+	plurals map[string]string
+
+	line int
+	e    poEntry
+	// Current string to append continuation lines, see poTarget* constants.
+	target, idx int
+	buf         []byte
+}
+
+// Parsing message.
+type poEntry struct {
+	ctx, id, idPlural string
+	str               []string
+	hasCtx, hasID     bool
+	hasStr            bool
+	fuzzy, icu, rules bool
+	line              int
+}
+
+const (
+	poTargetNone = iota
+	poTargetCtx
+	poTargetID
+	poTargetIDPlural
+	poTargetStr
+)
+
+func (p *poParser) parse(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 16<<20)
+	for s.Scan() {
+		p.line++
+		if err := p.parseLine(strings.TrimSpace(s.Text())); err != nil {
+			if _, ok := err.(*ParseError); ok {
+				return err
+			}
+			return &ParseError{Line: p.line, Err: err}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	return p.flush()
+}
+
+func (p *poParser) parseLine(line string) error {
+	switch {
+	case len(line) == 0:
+		return p.flush()
+	case strings.HasPrefix(line, "#~"):
+		// Obsolete message.
+		return nil
+	case line[0] == '#':
+		if p.e.hasStr {
+			if err := p.flush(); err != nil {
+				return err
+			}
+		}
+		if strings.HasPrefix(line, "#,") {
+			for _, flag := range strings.Split(line[2:], ",") {
+				switch strings.TrimSpace(flag) {
+				case "fuzzy":
+					p.e.fuzzy = true
+				case poFlagICU:
+					p.e.icu = true
+				case poFlagRules:
+					p.e.rules = true
+				}
+			}
+		}
+		return nil
+	case line[0] == '"':
+		if p.target == poTargetNone {
+			return ErrBadPO
+		}
+		s, err := p.unquote(line)
+		if err != nil {
+			return err
+		}
+		p.appendTarget(s)
+		return nil
+	}
+
+	i := strings.IndexByte(line, ' ')
+	if i == -1 {
+		return ErrBadPO
+	}
+	kw := line[:i]
+	s, err := p.unquote(strings.TrimSpace(line[i+1:]))
+	if err != nil {
+		return err
+	}
+	switch {
+	case kw == "msgctxt":
+		if p.e.hasCtx && !p.e.hasID {
+			return ErrBadPO
+		}
+		if p.e.hasID {
+			if err = p.flush(); err != nil {
+				return err
+			}
+		}
+		p.e.hasCtx, p.target = true, poTargetCtx
+	case kw == "msgid":
+		if p.e.hasStr || p.e.hasID {
+			if err = p.flush(); err != nil {
+				return err
+			}
+		}
+		p.e.hasID, p.e.line, p.target = true, p.line, poTargetID
+	case kw == "msgid_plural":
+		if !p.e.hasID || p.e.hasStr {
+			return ErrBadPO
+		}
+		p.target = poTargetIDPlural
+	case kw == "msgstr":
+		if !p.e.hasID {
+			return ErrBadPO
+		}
+		p.e.hasStr, p.target, p.idx = true, poTargetStr, 0
+	case strings.HasPrefix(kw, "msgstr[") && kw[len(kw)-1] == ']':
+		n, err := strconv.Atoi(kw[7 : len(kw)-1])
+		if err != nil || n < 0 || n > 255 || !p.e.hasID {
+			return ErrBadPO
+		}
+		p.e.hasStr, p.target, p.idx = true, poTargetStr, n
+	default:
+		return ErrBadPO
+	}
+	p.appendTarget(s)
+	return nil
+}
+
+// Append string s to current target.
+func (p *poParser) appendTarget(s string) {
+	switch p.target {
+	case poTargetCtx:
+		p.e.ctx += s
+	case poTargetID:
+		p.e.id += s
+	case poTargetIDPlural:
+		p.e.idPlural += s
+	case poTargetStr:
+		for len(p.e.str) <= p.idx {
+			p.e.str = append(p.e.str, "")
+		}
+		p.e.str[p.idx] += s
+	}
+}
+
+// Save parsed message to transaction.
+func (p *poParser) flush() error {
+	e := &p.e
+	defer func() {
+		str := e.str[:0]
+		*e = poEntry{str: str}
+		p.target = poTargetNone
+	}()
+	if !e.hasID {
+		return nil
+	}
+	if len(e.id) == 0 && !e.hasCtx {
+		// Header entry.
+		if len(e.str) > 0 {
+			if pf := headerPluralForms(e.str[0]); len(pf) > 0 {
+				p.pf, p.pfLine = pf, e.line
+			}
+		}
+		return nil
+	}
+	if e.fuzzy || len(e.id) == 0 || len(e.str) == 0 {
+		return nil
+	}
+	for i := 0; i < len(e.str); i++ {
+		if len(e.str[i]) == 0 {
+			// Untranslated message.
+			return nil
+		}
+	}
+
+	key := ctxKey(e.ctx, p.locale+"."+e.id)
+	var err error
+	switch {
+	case e.icu:
+		err = p.tx.SetICU(key, e.str[0])
+	case e.rules:
+		err = p.tx.Set(key, e.str[0])
+	default:
+		p.buf = joinT9n(p.buf[:0], e.str)
+		err = p.tx.Set(key, string(p.buf))
+	}
+	if err != nil {
+		return &ParseError{Line: e.line, Err: err}
+	}
+	if p.plurals != nil && len(e.idPlural) > 0 {
+		p.plurals[poSidecarKey(e.ctx, e.id)] = e.idPlural
+	}
+	return nil
+}
+
+// Unquote C-like string.
+func (p *poParser) unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", ErrBadPO
+	}
+	s = s[1 : len(s)-1]
+	if strings.IndexByte(s, '\\') == -1 {
+		if strings.IndexByte(s, '"') != -1 {
+			return "", ErrBadPO
+		}
+		return s, nil
+	}
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '"' {
+			return "", ErrBadPO
+		}
+		if c != '\\' {
+			buf = append(buf, c)
+			continue
+		}
+		if i++; i == len(s) {
+			return "", ErrBadPO
+		}
+		switch c = s[i]; c {
+		case 'n':
+			buf = append(buf, '\n')
+		case 't':
+			buf = append(buf, '\t')
+		case 'r':
+			buf = append(buf, '\r')
+		case 'a':
+			buf = append(buf, '\a')
+		case 'b':
+			buf = append(buf, '\b')
+		case 'f':
+			buf = append(buf, '\f')
+		case 'v':
+			buf = append(buf, '\v')
+		case '"', '\\', '\'', '?':
+			buf = append(buf, c)
+		case 'x':
+			var n, j int
+			for j = i + 1; j < len(s) && j < i+3 && isHex(s[j]); j++ {
+				n = n<<4 | unhex(s[j])
+			}
+			if j == i+1 {
+				return "", ErrBadPO
+			}
+			buf = append(buf, byte(n))
+			i = j - 1
+		default:
+			if c < '0' || c > '7' {
+				return "", ErrBadPO
+			}
+			var n, j int
+			for j = i; j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7'; j++ {
+				n = n<<3 | int(s[j]-'0')
+			}
+			buf = append(buf, byte(n))
+			i = j - 1
+		}
+	}
+	return string(buf), nil
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func unhex(c byte) int {
+	switch {
+	case c >= 'a':
+		return int(c-'a') + 10
+	case c >= 'A':
+		return int(c-'A') + 10
+	}
+	return int(c - '0')
+}
@@ -0,0 +1,204 @@
+package i18n
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+const testPO = `# Russian translation.
+#, fuzzy
+msgid ""
+msgstr ""
+"Language: ru\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+#: main.go:10
+msgid "Open"
+msgstr "Открыто"
+
+msgctxt "verb"
+msgid "Open"
+msgstr "Открыть"
+
+#. Files counter.
+msgid "file"
+msgid_plural "files"
+msgstr[0] "файл"
+msgstr[1] "файла"
+msgstr[2] "файлов"
+
+msgid "Welcome"
+msgstr ""
+"Добро пожаловать,\n"
+"\"друг\"!"
+
+msgid "Choice"
+msgstr "да|нет \\ {0} и [1,2]"
+
+msgid "Braces"
+msgstr "{0} не правило"
+
+#, fuzzy
+msgid "Draft"
+msgstr "Черновик"
+
+msgid "Untranslated"
+msgstr ""
+
+msgid "Partial"
+msgid_plural "Partials"
+msgstr[0] "частично"
+msgstr[1] ""
+
+#, icu-format
+msgid "cart"
+msgstr "{n, plural, one {# товар} few {# товара} other {# товаров}}"
+
+#, i18n-rules
+msgid "apples"
+msgstr "{0} нет яблок|яблоко|яблока|яблок"
+
+#~ msgid "Obsolete"
+#~ msgstr "Устарело"
+`
+
+func TestPO(t *testing.T) {
+	assertPO := func(t *testing.T, db *DB) {
+		assertT9n(t, db, "ru.Open", "Открыто")
+		if s := db.GetCtx("verb", "ru.Open", ""); s != "Открыть" {
+			t.Errorf("context mismatch, got %q", s)
+		}
+		assertT9nPlural(t, db, "ru.file", "файл", 21)
+		assertT9nPlural(t, db, "ru.file", "файла", 3)
+		assertT9nPlural(t, db, "ru.file", "файлов", 11)
+		assertT9n(t, db, "ru.Welcome", "Добро пожаловать,\n\"друг\"!")
+		assertT9n(t, db, "ru.Choice", `да|нет \ {0} и [1,2]`)
+		assertT9nPlural(t, db, "ru.Braces", "{0} не правило", 0)
+		assertT9n(t, db, "ru.Draft", "")
+		assertT9n(t, db, "ru.Untranslated", "")
+		assertT9n(t, db, "ru.Partial", "")
+		assertT9n(t, db, "ru.Obsolete", "")
+		assertT9nPlural(t, db, "ru.apples", "нет яблок", 0)
+		assertT9nPlural(t, db, "ru.apples", "яблока", 2)
+		var args Args
+		args.Int("n", 5)
+		if s := db.GetICU("ru.cart", "", &args); s != "5 товаров" {
+			t.Errorf("ICU mismatch, got %q", s)
+		}
+	}
+
+	db, _ := New(xxhash.Hasher64[string]{})
+	var sc POSidecar
+	if err := LoadPO(db, "ru", strings.NewReader(testPO), &sc); err != nil {
+		t.Fatal(err)
+	}
+	assertPO(t, db)
+	if s := sc.Plurals["file"]; s != "files" {
+		t.Errorf("sidecar plural mismatch, got %q", s)
+	}
+
+	t.Run("dump", func(t *testing.T) {
+		_ = db.Set("en.Open", "Open")
+		var buf bytes.Buffer
+		if err := DumpPO(db, "ru", &buf, &sc); err != nil {
+			t.Fatal(err)
+		}
+		s := buf.String()
+		if strings.Contains(s, "en.Open") {
+			t.Error("dump contains foreign locale")
+		}
+		if !strings.Contains(s, "msgid \"file\"\nmsgid_plural \"files\"\n") {
+			t.Error("dump lost msgid_plural")
+		}
+		db1, _ := New(xxhash.Hasher64[string]{})
+		var sc1 POSidecar
+		if err := LoadPO(db1, "ru", &buf, &sc1); err != nil {
+			t.Fatal(err)
+		}
+		assertPO(t, db1)
+		if s := sc1.Plurals["file"]; s != "files" {
+			t.Errorf("sidecar plural mismatch, got %q", s)
+		}
+	})
+	t.Run("dump plural forms", func(t *testing.T) {
+		db, _ := New(xxhash.Hasher64[string]{})
+		_ = db.SetCtx("count", "ru.file", "файл|файла|файлов")
+		var buf bytes.Buffer
+		if err := DumpPO(db, "ru", &buf, nil); err != nil {
+			t.Fatal(err)
+		}
+		s := buf.String()
+		if !strings.Contains(s, "\"Plural-Forms: "+cldrPluralForms(cldrPlurals["ru"])+"\\n\"") {
+			t.Errorf("dump lost Plural-Forms header:\n%s", s)
+		}
+		sc := POSidecar{Plurals: map[string]string{"count\x04file": "files"}}
+		buf.Reset()
+		_ = DumpPO(db, "ru", &buf, &sc)
+		if !strings.Contains(buf.String(), "msgid_plural \"files\"") {
+			t.Error("dump lost msgid_plural of contextual message")
+		}
+
+		db1, _ := New(xxhash.Hasher64[string]{})
+		if err := LoadPO(db1, "ru", &buf, nil); err != nil {
+			t.Fatal(err)
+		}
+		for n, expect := range map[int]string{1: "файл", 3: "файла", 11: "файлов", 21: "файл", 112: "файлов"} {
+			if s := db1.GetCtxPlural("count", "ru.file", "", n); s != expect {
+				t.Errorf("plural mismatch of %d, need %s got %s", n, expect, s)
+			}
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		stages := []struct {
+			po   string
+			line int
+			err  error
+		}{
+			{"msgid \"a\"\nmsgstr \"b", 2, ErrBadPO},
+			{"msgid \"a\"\nmsgstr \"b\\q\"", 2, ErrBadPO},
+			{"msgstr \"b\"", 1, ErrBadPO},
+			{"\"b\"", 1, ErrBadPO},
+			{"msgid \"a\"\nmsgtext \"b\"", 2, ErrBadPO},
+			{"\nmsgid \"a\"\nmsgstr \"[0,99999999999999999999] x\"\n", 2, nil},
+			{"#, i18n-rules\nmsgid \"a\"\nmsgstr \"[0,99999999999999999999] x\"\n", 2, ErrBadRange},
+			{"msgid \"\"\nmsgstr \"Plural-Forms: nplurals=2; plural=n +;\\n\"\n", 1, ErrBadPluralForms},
+		}
+		for _, st := range stages {
+			db, _ := New(xxhash.Hasher64[string]{})
+			err := LoadPO(db, "en", strings.NewReader(st.po), nil)
+			if st.err == nil {
+				if err != nil {
+					t.Errorf("%q: unexpected error %s", st.po, err)
+				}
+				continue
+			}
+			var pe *ParseError
+			if !errors.As(err, &pe) || pe.Line != st.line || !errors.Is(err, st.err) {
+				t.Errorf("%q: need line %d %v got %v", st.po, st.line, st.err, err)
+			}
+			if db.Get("en.a", "") != "" {
+				t.Errorf("%q: translations saved despite error", st.po)
+			}
+		}
+	})
+}
+
+func TestEscape(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	_ = db.Set("en.pipe", `a\|b|c\\|d`)
+	assertT9nPlural(t, db, "en.pipe", "a|b", 1)
+	assertT9nPlural(t, db, "en.pipe", `c\`, 5)
+	_ = db.Set("en.brace", `\{0} literal`)
+	assertT9nPlural(t, db, "en.brace", "{0} literal", 1)
+	for _, s := range []string{"x|y", `\`, "{1} z", "[1,2] w", `a\|b`} {
+		b := escT9n(nil, s)
+		if u := unescT9n(string(b)); u != s {
+			t.Errorf("escape mismatch: need %q got %q", s, u)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package i18n
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LoadProperties loads Java .properties file of locale to db, eg:
+//
+//	# messages_ru.properties
+//	welcome = Привет, {0}!
+//	multiline = first \
+//	            second
+//	escaped = \u041f\u0440\u0438\u0432\u0435\u0442
+//
+// Key of translation is locale and property key: "ru.welcome". Comments, line continuations, unicode and other escapes
+// are processed as java.util.Properties does, values are saved as is. File must be encoded in UTF-8.
+//
+// File is streamed into single transaction, so nothing saves if file is malformed. Errors contain line number, see
+// ParseError.
+func LoadProperties(db *DB, locale string, r io.Reader) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	tx := db.Begin()
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 16<<20)
+	var (
+		line, start int
+		logical     []byte
+		cont        bool
+		buf         []byte
+	)
+	flush := func() error {
+		if len(logical) == 0 {
+			return nil
+		}
+		key, val, err := propertiesPair(logical)
+		logical = logical[:0]
+		if err != nil {
+			return &ParseError{Line: start, Err: err}
+		}
+		buf = escT9n(buf[:0], val)
+		if err = tx.Set(locale+"."+key, string(buf)); err != nil {
+			return &ParseError{Line: start, Err: err}
+		}
+		return nil
+	}
+	for s.Scan() {
+		line++
+		text := strings.TrimLeft(s.Text(), " \t\f")
+		if !cont {
+			if len(text) == 0 || text[0] == '#' || text[0] == '!' {
+				continue
+			}
+			start = line
+		}
+		// Odd number of trailing backslashes continues the line.
+		var n int
+		for n < len(text) && text[len(text)-1-n] == '\\' {
+			n++
+		}
+		if cont = n%2 == 1; cont {
+			text = text[:len(text)-1]
+		}
+		logical = append(logical, text...)
+		if cont {
+			continue
+		}
+		if err := flush(); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := flush(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Split logical line of properties file to unescaped key and value.
+func propertiesPair(p []byte) (key, val string, err error) {
+	// Key ends with the first unescaped separator: '=', ':' or whitespace.
+	i := 0
+	for ; i < len(p); i++ {
+		if c := p[i]; c == '\\' {
+			i++
+		} else if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+			break
+		}
+	}
+	if i > len(p) {
+		// Trailing backslash.
+		i = len(p)
+	}
+	if key, err = propertiesUnescape(p[:i]); err != nil {
+		return
+	}
+	if len(key) == 0 {
+		return "", "", ErrBadProperties
+	}
+	for i < len(p) && (p[i] == ' ' || p[i] == '\t' || p[i] == '\f') {
+		i++
+	}
+	if i < len(p) && (p[i] == '=' || p[i] == ':') {
+		i++
+	}
+	for i < len(p) && (p[i] == ' ' || p[i] == '\t' || p[i] == '\f') {
+		i++
+	}
+	val, err = propertiesUnescape(p[i:])
+	return
+}
+
+// Process escapes of properties file.
+func propertiesUnescape(p []byte) (string, error) {
+	if bytes.IndexByte(p, '\\') == -1 {
+		return string(p), nil
+	}
+	buf := make([]byte, 0, len(p))
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c != '\\' {
+			buf = append(buf, c)
+			continue
+		}
+		if i++; i == len(p) {
+			break
+		}
+		switch c = p[i]; c {
+		case 't':
+			buf = append(buf, '\t')
+		case 'n':
+			buf = append(buf, '\n')
+		case 'r':
+			buf = append(buf, '\r')
+		case 'f':
+			buf = append(buf, '\f')
+		case 'u':
+			if i+4 >= len(p) {
+				return "", ErrBadProperties
+			}
+			n, err := strconv.ParseUint(string(p[i+1:i+5]), 16, 16)
+			if err != nil {
+				return "", ErrBadProperties
+			}
+			i += 4
+			r := rune(n)
+			// Surrogate pair of escaped supplementary character.
+			if r >= 0xd800 && r < 0xdc00 && i+6 < len(p) && p[i+1] == '\\' && p[i+2] == 'u' {
+				if n2, err := strconv.ParseUint(string(p[i+3:i+7]), 16, 16); err == nil && n2 >= 0xdc00 && n2 < 0xe000 {
+					r = (r-0xd800)<<10 | (rune(n2) - 0xdc00) + 0x10000
+					i += 6
+				}
+			}
+			buf = utf8.AppendRune(buf, r)
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,63 @@
+package i18n
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+const testProperties = `# Comment
+! Another comment
+welcome = Привет, {0}!
+colon:value
+spaced   value with spaces
+multiline = first \
+            second \
+    third
+escaped = \u041f\u0440\u0438\u0432\u0435\u0442 \ud83d\ude00\t\\|
+key\ with\ spaces = x
+key\=eq = y
+pipe = a|b
+empty
+trailing = backslash \\
+`
+
+func TestProperties(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	if err := LoadProperties(db, "ru", strings.NewReader(testProperties)); err != nil {
+		t.Fatal(err)
+	}
+	assertT9n(t, db, "ru.welcome", "Привет, {0}!")
+	assertT9n(t, db, "ru.colon", "value")
+	assertT9n(t, db, "ru.spaced", "value with spaces")
+	assertT9n(t, db, "ru.multiline", "first second third")
+	assertT9n(t, db, "ru.escaped", "Привет 😀\t\\|")
+	assertT9n(t, db, "ru.key with spaces", "x")
+	assertT9n(t, db, "ru.key=eq", "y")
+	assertT9n(t, db, "ru.pipe", "a|b")
+	assertT9n(t, db, "ru.empty", "")
+	assertT9n(t, db, "ru.trailing", "backslash \\")
+
+	t.Run("errors", func(t *testing.T) {
+		for _, c := range []struct {
+			src  string
+			line int
+		}{
+			{"a = x\n\nb = \\u12\n", 3},
+			{"a = x\nb = \\\n  \\uzzzz\n", 2},
+			{"a = x\n = y\n", 2},
+		} {
+			db, _ := New(xxhash.Hasher64[string]{})
+			err := LoadProperties(db, "en", strings.NewReader(c.src))
+			var perr *ParseError
+			if !errors.As(err, &perr) || perr.Line != c.line || !errors.Is(err, ErrBadProperties) {
+				t.Errorf("%q: need error at line %d, got %v", c.src, c.line, err)
+			}
+			if db.Get("en.a", "") != "" {
+				t.Errorf("%q: translations saved despite error", c.src)
+			}
+		}
+	})
+}
@@ -1,28 +1,117 @@
 package i18n
 
-import "github.com/koykov/byteptr"
+import (
+	"math"
+
+	"github.com/koykov/byteconv"
+)
 
 // Rule stores low and high ranges of plural rule and rule's body bytes.
+//
+// Rule contains no pointers and has fixed layout, so rules storage may be dumped and loaded as is.
 type rule struct {
-	lh int64
-	rp byteptr.Byteptr
-	bp byteptr.Byteptr
+	lo, hi int64
+	rp     span
+	bp     span
+	// Rule kind and its argument, eg: position of bare form.
+	// Upper 16 bits of plural rules argument keep length of select keyword.
+	kind, arg uint32
+}
+
+const (
+	// Rule with explicit range of counts.
+	ruleRange uint32 = iota
+	// Bare form, selects by position according locale plural rules.
+	ruleBare
+	// Form of plural category keyword, argument keeps the category.
+	ruleCategory
+	// Rule with exact count, matches integers only.
+	ruleExact
+	// ICU message header and nodes, see icu.go.
+	ruleICU
+	ruleICUText
+	ruleICUArg
+	ruleICUPound
+	ruleICUPlural
+	ruleICUOrdinal
+	ruleICUSelect
+	ruleICUBranch
+)
+
+// Max length of select keyword.
+const maxSelLen = 0xffff
+
+// Get rule's argument without select keyword length.
+func (r *rule) pos() uint32 {
+	return r.arg & 0xffff
 }
 
-// Merge lo/hi ranges and save it.
-func (r *rule) encode(lo, hi int32) {
-	r.lh = int64(lo)<<32 | int64(hi)
+// Get select keyword of the rule.
+//
+// Keyword is always stored right after opening curly bracket of the rule, eg: "{female} She".
+func (r *rule) sel(buf []byte) string {
+	n := r.arg >> 16
+	if n == 0 {
+		return ""
+	}
+	return byteconv.B2S(buf[r.rp.off+1 : r.rp.off+1+n])
 }
 
-// Decode lo/hi ranges.
-func (r *rule) decode() (lo, hi int32) {
-	lo = int32(r.lh >> 32)
-	hi = int32(r.lh & 0xffffffff)
-	return
+// Check if the rule belongs to select group sel.
+func (r *rule) inGroup(buf []byte, sel string) bool {
+	n := int(r.arg >> 16)
+	return n == len(sel) && (n == 0 || r.sel(buf) == sel)
 }
 
 // Check if count passes the rule's range.
-func (r *rule) check(count int) bool {
-	lo, hi := r.decode()
-	return int32(count) >= lo && int32(count) < hi
+//
+// High bound math.MaxInt64 means infinity and includes itself.
+func (r *rule) check(count int64) bool {
+	if r.kind == ruleExact {
+		return count == r.lo
+	}
+	return count >= r.lo && (count < r.hi || r.hi == math.MaxInt64)
+}
+
+// Check if number of query q passes the rule's range.
+//
+// Decimal number passes range [lo, hi) if lo <= n < hi, exact rules never match decimals.
+func (r *rule) match(q *query) bool {
+	if !q.frac() {
+		return r.check(q.count)
+	}
+	if r.kind == ruleExact {
+		return false
+	}
+	// Count keeps integer part of number truncated toward zero.
+	c := q.count
+	if q.neg {
+		return c > r.lo && (c <= r.hi || r.hi == math.MaxInt64)
+	}
+	return c >= r.lo && (c < r.hi || r.hi == math.MaxInt64)
+}
+
+// Span describes bytes sequence in translations storage.
+type span struct {
+	off, ln uint32
+}
+
+// Init span with offset and length.
+func (s *span) init(offset, length int) {
+	s.off, s.ln = uint32(offset), uint32(length)
+}
+
+// Get offset of span.
+func (s *span) offset() int {
+	return int(s.off)
+}
+
+// Get length of span.
+func (s *span) len() int {
+	return int(s.ln)
+}
+
+// Get span contents from storage buf.
+func (s *span) take(buf []byte) string {
+	return byteconv.B2S(buf[s.off : s.off+s.ln])
 }
@@ -0,0 +1,62 @@
+package i18n
+
+// GetSelect returns a translation form marked with select keyword selector, eg:
+//
+//	db.Set("ru.user.entered", "{male} Он вошёл|{female} Она вошла|{other} Пользователь вошёл")
+//	db.GetSelect("ru.user.entered", "", "female") // Она вошла
+//
+// Forms of keyword "other" and forms without keyword serve unknown selectors. If translation doesn't exist, def will
+// be used instead.
+func (db *DB) GetSelect(key, def, selector string) string {
+	return db.GetSelectPluralWR(key, def, selector, 1, nil)
+}
+
+// GetSelectWR returns a translation form marked with select keyword selector with replacer.
+//
+// See GetWR().
+func (db *DB) GetSelectWR(key, def, selector string, repl *PlaceholderReplacer) string {
+	return db.GetSelectPluralWR(key, def, selector, 1, repl)
+}
+
+// GetSelectPlural returns a translation using select keyword and plural formula within keyword forms, eg:
+//
+//	db.Set("en.cats", "{female} {one} She has a cat|{female} She has cats|{other} {one} They have a cat|{other} They have cats")
+//	db.GetSelectPlural("en.cats", "", "female", 3) // She has cats
+func (db *DB) GetSelectPlural(key, def, selector string, count int) string {
+	return db.GetSelectPluralWR(key, def, selector, count, nil)
+}
+
+// GetSelectPluralWR returns a translation using select keyword and plural formula with replacer.
+//
+// See GetWR().
+func (db *DB) GetSelectPluralWR(key, def, selector string, count int, repl *PlaceholderReplacer) string {
+	return db.get(key, def, &query{count: int64(count), sel: selector}, repl)
+}
+
+// GetSelect returns a translation form marked with select keyword selector.
+//
+// See DB.GetSelect().
+func (l Localizer) GetSelect(key, def, selector string) string {
+	return l.GetSelectPluralWR(key, def, selector, 1, nil)
+}
+
+// GetSelectWR returns a translation form marked with select keyword selector with replacer.
+//
+// See DB.GetWR().
+func (l Localizer) GetSelectWR(key, def, selector string, repl *PlaceholderReplacer) string {
+	return l.GetSelectPluralWR(key, def, selector, 1, repl)
+}
+
+// GetSelectPlural returns a translation using select keyword and plural formula within keyword forms.
+//
+// See DB.GetSelectPlural().
+func (l Localizer) GetSelectPlural(key, def, selector string, count int) string {
+	return l.GetSelectPluralWR(key, def, selector, count, nil)
+}
+
+// GetSelectPluralWR returns a translation using select keyword and plural formula with replacer.
+//
+// See DB.GetWR().
+func (l Localizer) GetSelectPluralWR(key, def, selector string, count int, repl *PlaceholderReplacer) string {
+	return l.get(key, def, &query{count: int64(count), sel: selector}, repl)
+}
@@ -0,0 +1,124 @@
+package i18n
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+func TestSelect(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	t9ns := map[string]string{
+		"ru.user.entered": "{male} Он вошёл|{female} Она вошла|{other} Пользователь вошёл",
+		"ru.user.files":   "{male} Он загрузил !n файл|{male} Он загрузил !n файла|{male} Он загрузил !n файлов|{female} Она загрузила !n файл|{female} Она загрузила !n файла|{female} Она загрузила !n файлов",
+		"en.user.cats":    "{female} {0} She has no cats|{female} {one} She has a cat|{female} She has !n cats|{other} {one} They have a cat|{other} They have !n cats",
+		"en.user.plain":   "apple|apples|{formal} {one} one apple|{formal} several apples",
+		"xx.user.files":   "{male} file|{male} files|{female} [0,1] none|{female} some",
+	}
+	for k, v := range t9ns {
+		if err := db.Set(k, v); err != nil {
+			t.Fatalf("%s: %s", k, err)
+		}
+	}
+
+	assertSelect := func(t *testing.T, db *DB, key, sel, expect string, count int) {
+		if s := db.GetSelectPlural(key, "N/D", sel, count); s != expect {
+			t.Errorf("%s/%s/%d: need %q got %q", key, sel, count, expect, s)
+		}
+	}
+	t.Run("select", func(t *testing.T) {
+		for _, sel := range []string{"male", "female", "other", "unknown", ""} {
+			expect := map[string]string{"male": "Он вошёл", "female": "Она вошла"}[sel]
+			if len(expect) == 0 {
+				expect = "Пользователь вошёл"
+			}
+			if s := db.GetSelect("ru.user.entered", "", sel); s != expect {
+				t.Errorf("%s: need %q got %q", sel, expect, s)
+			}
+		}
+		if s := db.Get("ru.user.entered", ""); s != "Пользователь вошёл" {
+			t.Errorf("plain getter mismatch, got %q", s)
+		}
+		if s := db.GetSelect("ru.user.missing", "N/D", "male"); s != "N/D" {
+			t.Errorf("default mismatch, got %q", s)
+		}
+	})
+	t.Run("plural", func(t *testing.T) {
+		assertSelect(t, db, "ru.user.files", "male", "Он загрузил !n файл", 21)
+		assertSelect(t, db, "ru.user.files", "male", "Он загрузил !n файла", 3)
+		assertSelect(t, db, "ru.user.files", "female", "Она загрузила !n файлов", 11)
+		assertSelect(t, db, "ru.user.files", "female", "Она загрузила !n файла", 22)
+
+		assertSelect(t, db, "en.user.cats", "female", "She has no cats", 0)
+		assertSelect(t, db, "en.user.cats", "female", "She has a cat", 1)
+		assertSelect(t, db, "en.user.cats", "female", "She has !n cats", 5)
+		assertSelect(t, db, "en.user.cats", "male", "They have a cat", 1)
+		assertSelect(t, db, "en.user.cats", "male", "They have !n cats", 0)
+
+		assertSelect(t, db, "en.user.plain", "", "apple", 1)
+		assertSelect(t, db, "en.user.plain", "", "apples", 2)
+		assertSelect(t, db, "en.user.plain", "formal", "one apple", 1)
+		assertSelect(t, db, "en.user.plain", "formal", "several apples", 2)
+		assertT9nPlural(t, db, "en.user.plain", "apples", 7)
+
+		// Legacy ranges apply within keyword group without plural rules.
+		assertSelect(t, db, "xx.user.files", "male", "file", 1)
+		assertSelect(t, db, "xx.user.files", "male", "files", 2)
+		assertSelect(t, db, "xx.user.files", "female", "none", 0)
+		assertSelect(t, db, "xx.user.files", "female", "some", 3)
+	})
+	t.Run("replacer", func(t *testing.T) {
+		var repl PlaceholderReplacer
+		repl.AddKV("!n", "3")
+		if s := db.GetSelectPluralWR("en.user.cats", "", "female", 3, &repl); s != "She has 3 cats" {
+			t.Errorf("replacer mismatch, got %q", s)
+		}
+	})
+	t.Run("localizer", func(t *testing.T) {
+		l := db.Localizer("ru")
+		if s := l.GetSelect("user.entered", "", "female"); s != "Она вошла" {
+			t.Errorf("localizer mismatch, got %q", s)
+		}
+		if s := l.GetSelectPlural("user.files", "", "male", 5); s != "Он загрузил !n файлов" {
+			t.Errorf("localizer plural mismatch, got %q", s)
+		}
+	})
+	t.Run("update", func(t *testing.T) {
+		// The same length translation updates in place.
+		_ = db.Set("ru.user.entered", "{male} Он вышел|{female} Она вышла|{other} Пользователь вышел")
+		assertSelect(t, db, "ru.user.entered", "female", "Она вышла", 1)
+		_ = db.Set("ru.user.entered", "{male} Он|{female} Она")
+		assertSelect(t, db, "ru.user.entered", "unknown", "N/D", 1)
+		assertSelect(t, db, "ru.user.entered", "male", "Он", 1)
+	})
+	t.Run("dump", func(t *testing.T) {
+		db.Compact()
+		assertSelect(t, db, "en.user.cats", "female", "She has a cat", 1)
+
+		var buf bytes.Buffer
+		if _, err := db.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		db1, _ := New(xxhash.Hasher64[string]{})
+		if _, err := db1.ReadFrom(&buf); err != nil {
+			t.Fatal(err)
+		}
+		assertSelect(t, db1, "ru.user.files", "female", "Она загрузила !n файла", 4)
+		assertSelect(t, db1, "en.user.cats", "other", "They have !n cats", 2)
+	})
+	t.Run("errors", func(t *testing.T) {
+		if err := db.Set("en.bad", "{male} [0,99999999999999999999] x|y"); err != ErrBadRange {
+			t.Errorf("need ErrBadRange got %v", err)
+		}
+	})
+}
+
+func BenchmarkSelect(b *testing.B) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	_ = db.Set("ru.user.files", "{male} Он загрузил !n файл|{male} Он загрузил !n файла|{male} Он загрузил !n файлов|{female} Она загрузила !n файл|{female} Она загрузила !n файла|{female} Она загрузила !n файлов")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = db.GetSelectPlural("ru.user.files", "", "female", i)
+	}
+}
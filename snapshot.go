@@ -0,0 +1,207 @@
+package i18n
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/koykov/byteconv"
+	"github.com/koykov/entry"
+)
+
+// Snapshot is an immutable view of DB data.
+//
+// Readers load current snapshot atomically and don't use any locks. Writers modify DB data copy-on-write and publish
+// new snapshot at once, thus in-flight reads always see consistent data.
+type snapshot struct {
+	// Translations index.
+	index shards
+	// Rules storage.
+	rules []rule
+	// Translations storage.
+	buf []byte
+	// Keys index and storage.
+	keys shards
+	kbuf []byte
+	// Sorted index of binary dump, replaces both indexes in mapped DB.
+	table table
+	// Underlying snapshot of overlay DB.
+	base *snapshot
+}
+
+// Get translation of hkey according query q and plural rules pr.
+//
+// Returns false if hkey doesn't exist. Empty translation with true means that none of the rules matches count.
+func (s *snapshot) get(hkey uint64, q *query, pr *pluralRule) (string, bool) {
+	l, e := s.find(hkey)
+	if l == nil {
+		return "", false
+	}
+	lo, hi := e.Decode()
+	rules := l.rules[lo:hi]
+	if isICU(rules) {
+		// Render ICU message if possible, otherwise return it as is.
+		if q.args == nil {
+			return rules[0].bp.take(l.buf), true
+		}
+		return q.args.render(rules[1:], l.buf, pr), true
+	}
+	if r := selectRule(rules, l.buf, q, pr); r != nil {
+		return unescT9n(r.bp.take(l.buf)), true
+	}
+	return "", true
+}
+
+// Get raw translation of hkey.
+func (s *snapshot) getRaw(hkey uint64) string {
+	if l, e := s.find(hkey); l != nil {
+		return rawT9n(e, l.rules, l.buf)
+	}
+	return ""
+}
+
+// Check if translation of hkey is ICU message.
+func (s *snapshot) isICU(hkey uint64) bool {
+	if l, e := s.find(hkey); l != nil {
+		lo, hi := e.Decode()
+		return isICU(l.rules[lo:hi])
+	}
+	return false
+}
+
+// Find the snapshot layer containing hkey and its entry.
+func (s *snapshot) find(hkey uint64) (*snapshot, entry.Entry64) {
+	for ; s != nil; s = s.base {
+		var e entry.Entry64
+		if s.table != nil {
+			e, _ = s.table.get(hkey)
+		} else {
+			e = s.index.get(hkey)
+		}
+		if e == entryTomb {
+			break
+		}
+		if e != 0 {
+			return s, e
+		}
+	}
+	return nil, 0
+}
+
+// Get key entry of hkey in the layer.
+func (s *snapshot) key(hkey uint64) entry.Entry64 {
+	if s.table != nil {
+		_, ke := s.table.get(hkey)
+		return ke
+	}
+	return s.keys.get(hkey)
+}
+
+// Iterate over all live entries of all layers.
+func (s *snapshot) each(fn func(hkey uint64, l *snapshot, e entry.Entry64)) {
+	var seen map[uint64]struct{}
+	if s.base != nil {
+		seen = make(map[uint64]struct{})
+	}
+	for l := s; l != nil; l = l.base {
+		visit := func(hkey uint64, e entry.Entry64) {
+			if seen != nil {
+				if _, ok := seen[hkey]; ok {
+					return
+				}
+				seen[hkey] = struct{}{}
+			}
+			if e != entryTomb {
+				fn(hkey, l, e)
+			}
+		}
+		if l.table != nil {
+			l.table.each(func(hkey uint64, e, _ entry.Entry64) { visit(hkey, e) })
+			continue
+		}
+		l.index.each(visit)
+	}
+}
+
+// Iterate over live entries which keys start with prefix in order of keys.
+func (s *snapshot) eachPrefix(prefix string, fn func(key string, rules []rule, buf []byte)) {
+	type record struct {
+		key string
+		l   *snapshot
+		e   entry.Entry64
+	}
+	var list []record
+	s.each(func(hkey uint64, l *snapshot, e entry.Entry64) {
+		ke := l.key(hkey)
+		lo, hi := ke.Decode()
+		if key := byteconv.B2S(l.kbuf[lo:hi]); len(key) > 0 && strings.HasPrefix(key, prefix) {
+			list = append(list, record{key: key, l: l, e: e})
+		}
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].key < list[j].key })
+	for i := 0; i < len(list); i++ {
+		r := &list[i]
+		lo, hi := r.e.Decode()
+		fn(r.key, r.l.rules[lo:hi], r.l.buf)
+	}
+}
+
+// Get unescaped bare forms of translation rules.
+//
+// Returns false if rules contain explicit ranges, keywords or ICU message.
+func bareForms(dst []string, rules []rule, buf []byte) ([]string, bool) {
+	for i := 0; i < len(rules); i++ {
+		r := &rules[i]
+		if r.kind != ruleBare || r.arg>>16 != 0 {
+			return dst, false
+		}
+		dst = append(dst, unescT9n(r.bp.take(buf)))
+	}
+	return dst, true
+}
+
+// Get raw translation of entry e including all plural formula rules.
+func rawT9n(e entry.Entry64, rules []rule, buf []byte) string {
+	if e == 0 || e == entryTomb {
+		return ""
+	}
+	lo, hi := e.Decode()
+	return rawRules(rules[lo:hi], buf)
+}
+
+// Get raw translation of entry rules.
+func rawRules(rules []rule, buf []byte) string {
+	if len(rules) > 0 {
+		var sp span
+		_ = rules[len(rules)-1]
+		for i := 0; i < len(rules); i++ {
+			r := rules[i]
+			sp.ln += r.rp.ln
+		}
+		sp.off = rules[0].rp.off
+		return sp.take(buf)
+	}
+	return ""
+}
+
+// Load current snapshot.
+func (db *DB) snap() *snapshot {
+	return (*snapshot)(atomic.LoadPointer(&db.sp))
+}
+
+// Publish current DB data as new snapshot.
+//
+// Database must be locked.
+func (db *DB) publishLF() {
+	s := &snapshot{
+		index: db.index.publish(),
+		rules: db.rules,
+		buf:   db.buf,
+		keys:  db.keys.publish(),
+		kbuf:  db.kbuf,
+		base:  db.base,
+	}
+	db.prules, db.pbuf = len(db.rules), len(db.buf)
+	atomic.StorePointer(&db.sp, unsafe.Pointer(s))
+}
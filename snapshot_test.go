@@ -0,0 +1,61 @@
+package i18n
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+func TestSnapshot(t *testing.T) {
+	const keys = 64
+	db, _ := New(xxhash.Hasher64[string]{})
+	hkeys := make([]uint64, 0, keys)
+	for i := 0; i < keys; i++ {
+		key := "en.key" + strconv.Itoa(i)
+		hkeys = append(hkeys, db.hkey(key))
+		_ = db.Set(key, "foobar")
+	}
+
+	var (
+		wg   sync.WaitGroup
+		stop = make(chan struct{})
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					// All keys of the snapshot must contain the same translation.
+					s := db.snap()
+					first, _ := s.get(hkeys[0], &query{count: 1}, nil)
+					for j := 1; j < len(hkeys); j++ {
+						if t9n, _ := s.get(hkeys[j], &query{count: 1}, nil); t9n != first {
+							t.Errorf("inconsistent snapshot, need %s got %s", first, t9n)
+							return
+						}
+					}
+				}
+			}
+		}()
+	}
+
+	// Alternate longer and shorter translations to cover both appending and in-place updates.
+	t9ns := []string{"qwerty", "foobar", "lorem ipsum", "foo"}
+	for i := 0; i < 100; i++ {
+		db.BeginTXN()
+		for j := 0; j < keys; j++ {
+			_ = db.Set("en.key"+strconv.Itoa(j), t9ns[i%len(t9ns)])
+		}
+		db.Commit()
+	}
+	close(stop)
+	wg.Wait()
+
+	assertT9n(t, db, "en.key42", "foo")
+}
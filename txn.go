@@ -4,29 +4,225 @@ import (
 	"github.com/koykov/byteptr"
 )
 
+const (
+	txnOpSet = iota
+	txnOpDel
+	txnOpDelPrefix
+	txnOpSetICU
+	// Set of unchanged translation, it applies only if translation was changed after transaction begin.
+	txnOpKeep
+)
+
+// Txn is an independent transaction handle.
+//
+// Txn collects changes until Commit() or Rollback() call. Several transactions may be staged concurrently, but single
+// Txn isn't thread-safe.
+type Txn struct {
+	t      *txn
+	strict bool
+	// Error of transaction begin.
+	err error
+}
+
+// Set translation as key in transaction.
+func (tx *Txn) Set(key, translation string) error {
+	return tx.set(key, "", translation)
+}
+
+// Set translation of key in message context ctx in transaction.
+func (tx *Txn) set(key, ctx, translation string) error {
+	if tx.t == nil {
+		return tx.done()
+	}
+	if len(key) == 0 || len(translation) == 0 {
+		return nil
+	}
+	if err := tx.t.db.checkRules(translation); err != nil {
+		return err
+	}
+	tx.t.set(key, ctx, translation, txnOpSet)
+	return nil
+}
+
+// SetICU sets ICU message as key in transaction.
+//
+// See DB.SetICU().
+func (tx *Txn) SetICU(key, message string) error {
+	if tx.t == nil {
+		return tx.done()
+	}
+	if len(key) == 0 || len(message) == 0 {
+		return nil
+	}
+	if _, err := parseICU(nil, message, 0); err != nil {
+		return err
+	}
+	tx.t.set(key, "", message, txnOpSetICU)
+	return nil
+}
+
+// Delete removes translation of key in transaction.
+func (tx *Txn) Delete(key string) error {
+	return tx.delete(key, "")
+}
+
+// Remove translation of key in message context ctx in transaction.
+func (tx *Txn) delete(key, ctx string) error {
+	if tx.t == nil {
+		return tx.done()
+	}
+	if len(key) == 0 {
+		return nil
+	}
+	tx.t.del(key, ctx)
+	return nil
+}
+
+// DeletePrefix removes all translations which keys start with prefix in transaction.
+//
+// Prefix removals never conflict with other transactions.
+func (tx *Txn) DeletePrefix(prefix string) error {
+	if tx.t == nil {
+		return tx.done()
+	}
+	if len(prefix) == 0 {
+		return nil
+	}
+	tx.t.delPrefix(prefix)
+	return nil
+}
+
+// DeleteLocale removes all translations of given locale in transaction.
+func (tx *Txn) DeleteLocale(locale string) error {
+	if len(locale) == 0 {
+		return nil
+	}
+	return tx.DeletePrefix(locale + ".")
+}
+
+// SetPluralForms sets gettext plural forms of the locale in transaction.
+//
+// Plural forms apply on commit together with translations, see DB.SetPluralForms().
+func (tx *Txn) SetPluralForms(locale, header string) error {
+	if tx.t == nil {
+		return tx.done()
+	}
+	var pf *pluralForms
+	if len(header) > 0 {
+		var err error
+		if pf, err = parsePluralForms(header); err != nil {
+			return err
+		}
+	}
+	tx.t.pf = append(tx.t.pf, txnPluralForms{locale: locale, pf: pf})
+	return nil
+}
+
+// Size returns count of collected changes.
+func (tx *Txn) Size() int {
+	if tx.t == nil {
+		return 0
+	}
+	return tx.t.size()
+}
+
+// Commit applies all collected changes at once.
+//
+// Strict transaction (see DB.BeginStrict()) fails with ErrTxnConflict and applies nothing if any of its keys was
+// modified by others after transaction begin. Otherwise, the last committed transaction wins.
+func (tx *Txn) Commit() error {
+	if tx.t == nil {
+		return tx.done()
+	}
+	defer tx.release()
+	db := tx.t.db
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	if tx.strict && tx.t.conflict() {
+		return ErrTxnConflict
+	}
+	tx.t.commit()
+	db.autoCompactLF()
+	db.publishLF()
+	return nil
+}
+
+// Rollback drops all collected changes.
+func (tx *Txn) Rollback() {
+	if tx.t == nil {
+		return
+	}
+	tx.release()
+}
+
+func (tx *Txn) done() error {
+	if tx.err != nil {
+		return tx.err
+	}
+	return ErrTxnDone
+}
+
+func (tx *Txn) release() {
+	txnP.put(tx.t)
+	tx.t = nil
+}
+
 // i18n transaction.
 type txn struct {
 	// Database to apply changes.
 	db *DB
+	// Database snapshot at the moment of transaction begin.
+	base *snapshot
 	// List of records to apply.
 	log []txnLog
 	// Transaction storage.
 	buf []byte
+	// Keys/prefixes storage.
+	kbuf []byte
+	// Count of unchanged translations.
+	kc int
+	// Plural forms of locales to apply.
+	pf []txnPluralForms
+}
+
+// Plural forms of locale of transaction, nil pf removes forms.
+type txnPluralForms struct {
+	locale string
+	pf     *pluralForms
 }
 
 // Key-translation pair of transaction.
 type txnLog struct {
+	op   uint8
 	hkey uint64
+	key  byteptr.Byteptr
 	t9n  byteptr.Byteptr
 }
 
-// Collect new translation.
-func (t *txn) set(key, translation string) {
+// Bind transaction to database.
+func (t *txn) init(db *DB) {
+	t.db = db
+	t.base = db.snap()
+}
+
+// Collect new translation of key in message context ctx, op defines its format.
+func (t *txn) set(key, ctx, translation string, op uint8) {
 	if t.db == nil {
 		return
 	}
-	hkey := t.db.hasher.Sum64(key)
-	if old := t.db.getRawLF(hkey); old == translation {
+	hkey := t.db.hkeyCtx(key, ctx)
+	// Unchanged translations don't store, but log to keep them against changes of other transactions.
+	if old := t.base.getRaw(hkey); old == translation && t.base.isICU(hkey) == (op == txnOpSetICU) {
+		t.log = append(t.log, txnLog{
+			op:   txnOpKeep,
+			hkey: hkey,
+			key:  t.bufKey(key, ctx),
+		})
+		t.kc++
 		return
 	}
 
@@ -35,34 +231,122 @@ func (t *txn) set(key, translation string) {
 	bp := byteptr.Byteptr{}
 	bp.Init(t.buf, offset, len(translation))
 	t.log = append(t.log, txnLog{
+		op:   op,
 		hkey: hkey,
+		key:  t.bufKey(key, ctx),
 		t9n:  bp,
 	})
 }
 
+// Collect removal of key in message context ctx.
+func (t *txn) del(key, ctx string) {
+	if t.db == nil {
+		return
+	}
+	t.log = append(t.log, txnLog{
+		op:   txnOpDel,
+		hkey: t.db.hkeyCtx(key, ctx),
+	})
+}
+
+// Collect removal of all keys starting with prefix.
+func (t *txn) delPrefix(prefix string) {
+	if t.db == nil {
+		return
+	}
+	t.log = append(t.log, txnLog{
+		op:  txnOpDelPrefix,
+		key: t.bufKey(prefix, ""),
+	})
+}
+
+// Save key with message context to keys storage.
+func (t *txn) bufKey(key, ctx string) byteptr.Byteptr {
+	offset := len(t.kbuf)
+	t.kbuf = appendCtxKey(t.kbuf, key, ctx)
+	bp := byteptr.Byteptr{}
+	bp.Init(t.kbuf, offset, len(t.kbuf)-offset)
+	return bp
+}
+
 // Apply all transaction changes at once.
 //
 // Database must be locked.
 func (t *txn) commit() {
-	if t.db == nil || len(t.log) == 0 {
+	if t.db == nil {
+		return
+	}
+	for i := 0; i < len(t.pf); i++ {
+		t.db.setPluralFormsLF(t.pf[i].locale, t.pf[i].pf)
+	}
+	if len(t.log) == 0 {
 		return
 	}
 
 	_ = t.log[len(t.log)-1]
 	for i := 0; i < len(t.log); i++ {
 		log := &t.log[i]
-		t.db.setLF(log.hkey, log.t9n.String())
+		switch log.op {
+		case txnOpSet:
+			t.db.setLF(log.hkey, log.t9n.TakeAddress(t.buf).String())
+			t.db.setKeyLF(log.hkey, log.key.TakeAddress(t.kbuf).String(), "")
+		case txnOpSetICU:
+			t.db.setICULF(log.hkey, log.t9n.TakeAddress(t.buf).String())
+			t.db.setKeyLF(log.hkey, log.key.TakeAddress(t.kbuf).String(), "")
+		case txnOpDel:
+			t.db.deleteLF(log.hkey)
+		case txnOpDelPrefix:
+			t.db.deletePrefixLF(log.key.TakeAddress(t.kbuf).String())
+		case txnOpKeep:
+			// Restore translation if it was changed by others or by previous logs.
+			t9n := t.base.getRaw(log.hkey)
+			if t.db.getRawLF(log.hkey) == t9n && t.db.isICULF(log.hkey) == t.base.isICU(log.hkey) {
+				continue
+			}
+			if t.base.isICU(log.hkey) {
+				t.db.setICULF(log.hkey, t9n)
+			} else {
+				t.db.setLF(log.hkey, t9n)
+			}
+			t.db.setKeyLF(log.hkey, log.key.TakeAddress(t.kbuf).String(), "")
+		}
 	}
 }
 
-// Get count of collected records.
+// Check if any of transaction keys was modified after transaction begin.
+//
+// Database must be locked.
+func (t *txn) conflict() bool {
+	if t.db == nil || t.base == t.db.snap() {
+		return false
+	}
+	for i := 0; i < len(t.log); i++ {
+		log := &t.log[i]
+		if log.op == txnOpDelPrefix {
+			continue
+		}
+		if t.base.getRaw(log.hkey) != t.db.getRawLF(log.hkey) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get count of collected records except unchanged translations.
 func (t *txn) size() int {
-	return len(t.log)
+	return len(t.log) - t.kc + len(t.pf)
 }
 
 // Reset transaction data.
 func (t *txn) reset() {
 	t.db = nil
+	t.base = nil
 	t.log = t.log[:0]
 	t.buf = t.buf[:0]
+	t.kbuf = t.kbuf[:0]
+	t.kc = 0
+	for i := 0; i < len(t.pf); i++ {
+		t.pf[i] = txnPluralForms{}
+	}
+	t.pf = t.pf[:0]
 }
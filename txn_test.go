@@ -3,6 +3,7 @@ package i18n
 import (
 	"bytes"
 	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/koykov/byteconv"
@@ -43,3 +44,160 @@ func TestTXN(t *testing.T) {
 		t.Error("db updated entry mismatch, need qwerty got", s)
 	}
 }
+
+func TestTXNDelete(t *testing.T) {
+	db, _ := New(fnv.Hasher{})
+	_ = db.Set("en.messages.welcome", "Hello there!")
+	_ = db.Set("en.messages.bye", "Goodbye!")
+	_ = db.Set("ru.messages.welcome", "Привет!")
+
+	db.BeginTXN()
+	_ = db.Delete("en.messages.bye")
+	_ = db.DeleteLocale("ru")
+	_ = db.Set("ru.messages.welcome", "Привет!")
+	if s := db.Get("en.messages.bye", ""); s != "Goodbye!" {
+		t.Error("translation deleted before commit")
+	}
+	db.Commit()
+
+	if s := db.Get("en.messages.bye", ""); s != "" {
+		t.Error("translation must be deleted, got", s)
+	}
+	if s := db.Get("ru.messages.welcome", ""); s != "Привет!" {
+		t.Error("translation mismatch, need Привет! got", s)
+	}
+}
+
+func TestTxnHandle(t *testing.T) {
+	t.Run("concurrent", func(t *testing.T) {
+		db, _ := New(fnv.Hasher{})
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				tx := db.Begin()
+				for j := 0; j < 100; j++ {
+					_ = tx.Set("en.key"+strconv.Itoa(i*100+j), "foobar")
+				}
+				if err := tx.Commit(); err != nil {
+					t.Error(err)
+				}
+			}(i)
+		}
+		wg.Wait()
+		if n := db.Stats().Entries; n != 800 {
+			t.Error("entries count mismatch, need 800 got", n)
+		}
+	})
+	t.Run("rollback", func(t *testing.T) {
+		db, _ := New(fnv.Hasher{})
+		tx := db.Begin()
+		_ = tx.Set("en.key", "foobar")
+		tx.Rollback()
+		if s := db.Get("en.key", ""); s != "" {
+			t.Error("rolled back translation must not exist, got", s)
+		}
+		if err := tx.Commit(); err != ErrTxnDone {
+			t.Error("error mismatch, need ErrTxnDone got", err)
+		}
+	})
+	t.Run("lww", func(t *testing.T) {
+		db, _ := New(fnv.Hasher{})
+		tx1, tx2 := db.Begin(), db.Begin()
+		_ = tx1.Set("en.key", "foo")
+		_ = tx2.Set("en.key", "bar")
+		_ = tx2.Commit()
+		if err := tx1.Commit(); err != nil {
+			t.Error(err)
+		}
+		if s := db.Get("en.key", ""); s != "foo" {
+			t.Error("translation mismatch, need foo got", s)
+		}
+
+		// Unchanged translation of tx1 still wins over tx2.
+		tx1, tx2 = db.Begin(), db.Begin()
+		_ = tx1.Set("en.key", "foo")
+		_ = tx2.Set("en.key", "bar")
+		_ = tx2.Commit()
+		if err := tx1.Commit(); err != nil {
+			t.Error(err)
+		}
+		if s := db.Get("en.key", ""); s != "foo" {
+			t.Error("translation mismatch, need foo got", s)
+		}
+
+		// Unchanged translation after delete of the same transaction.
+		tx1 = db.Begin()
+		_ = tx1.Delete("en.key")
+		_ = tx1.Set("en.key", "foo")
+		if err := tx1.Commit(); err != nil {
+			t.Error(err)
+		}
+		if s := db.Get("en.key", ""); s != "foo" {
+			t.Error("translation mismatch, need foo got", s)
+		}
+	})
+	t.Run("strict", func(t *testing.T) {
+		db, _ := New(fnv.Hasher{})
+		_ = db.Set("en.key", "foo")
+		tx1, tx2 := db.BeginStrict(), db.BeginStrict()
+		_ = tx1.Set("en.key", "bar")
+		_ = tx1.Set("en.another", "qwe")
+		_ = tx2.Delete("en.key")
+		_ = tx2.Commit()
+		if err := tx1.Commit(); err != ErrTxnConflict {
+			t.Error("error mismatch, need ErrTxnConflict got", err)
+		}
+		if s := db.Get("en.another", ""); s != "" {
+			t.Error("conflicting transaction must not apply, got", s)
+		}
+
+		// Unchanged translation conflicts too.
+		_ = db.Set("en.key", "foo")
+		tx1, tx2 = db.BeginStrict(), db.BeginStrict()
+		_ = tx1.Set("en.key", "foo")
+		_ = tx2.Set("en.key", "bar")
+		_ = tx2.Commit()
+		if err := tx1.Commit(); err != ErrTxnConflict {
+			t.Error("error mismatch, need ErrTxnConflict got", err)
+		}
+	})
+	t.Run("plural forms", func(t *testing.T) {
+		db, _ := New(fnv.Hasher{})
+		_ = db.Set("fr.files", "fichier|fichiers")
+		tx := db.Begin()
+		_ = tx.Set("fr.dirs", "dossier|dossiers")
+		if err := tx.SetPluralForms("fr", "nplurals=2; plural=(n +);"); err != ErrBadPluralForms {
+			t.Error("error mismatch, need ErrBadPluralForms got", err)
+		}
+		if err := tx.SetPluralForms("fr", "nplurals=2; plural=(n != 1);"); err != nil {
+			t.Fatal(err)
+		}
+		if n := tx.Size(); n != 2 {
+			t.Error("size mismatch, need 2 got", n)
+		}
+		// Forms apply only on commit.
+		assertT9nPlural(t, db, "fr.files", "fichier", 0)
+		tx.Rollback()
+		assertT9nPlural(t, db, "fr.files", "fichier", 0)
+
+		// Conflicting transaction applies no forms.
+		tx1, tx2 := db.BeginStrict(), db.Begin()
+		_ = tx1.Set("fr.files", "fichier|des fichiers")
+		_ = tx1.SetPluralForms("fr", "nplurals=2; plural=(n != 1);")
+		_ = tx2.Set("fr.files", "un fichier|fichiers")
+		_ = tx2.Commit()
+		if err := tx1.Commit(); err != ErrTxnConflict {
+			t.Error("error mismatch, need ErrTxnConflict got", err)
+		}
+		assertT9nPlural(t, db, "fr.files", "un fichier", 0)
+
+		tx = db.Begin()
+		_ = tx.SetPluralForms("fr", "nplurals=2; plural=(n != 1);")
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		assertT9nPlural(t, db, "fr.files", "fichiers", 0)
+	})
+}
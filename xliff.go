@@ -0,0 +1,786 @@
+package i18n
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// XLIFFSidecar keeps XLIFF data which DB doesn't store, so it may survive import and export.
+//
+// Sidecar contains exported fields only and may be saved in any format, eg JSON.
+type XLIFFSidecar struct {
+	// Original file name: "original" attribute of XLIFF 1.2 file or "id" of XLIFF 2.0 file.
+	Original string
+	// Units data by key without locale, eg: "messages.welcome".
+	Units map[string]XLIFFUnit
+}
+
+// XLIFFUnit describes notes and translation state of XLIFF unit.
+type XLIFFUnit struct {
+	// State of translation, eg: "translated" or "final".
+	State string
+	Notes []string
+}
+
+// Namespace of extension attributes.
+const xliffNS = "https://github.com/koykov/i18n"
+
+// Unit types to keep translations as is.
+const (
+	xliffTypeRules   = "i18n:rules"
+	xliffTypeICU     = "i18n:icu"
+	xliffTypePlurals = "i18n:plurals"
+
+	xliffRestypeRules   = "x-i18n-rules"
+	xliffRestypeICU     = "x-icu"
+	xliffRestypePlurals = "x-gettext-plurals"
+)
+
+// LoadXLIFF loads XLIFF 1.2 or 2.0 file to db.
+//
+// Unit id (or "resname" and "name" attributes if present) becomes key and source and target languages become its locale
+// prefixes, so both texts are saved: "en.messages.welcome" and "ru.messages.welcome". Units without target are saved
+// only in source language. Inline markup of texts is dropped, only text contents are saved.
+//
+// Group of plural forms with units "key[N]", "key[category]" and "key[=N]" folds to single translation of key, see
+// DumpXLIFF(). Notes and states of units are saved to sidecar sc if it isn't nil.
+//
+// All translations save in single transaction, so nothing saves if file is malformed.
+func LoadXLIFF(db *DB, r io.Reader, sc *XLIFFSidecar) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	var doc xliffDoc
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return err
+	}
+	v2 := strings.HasPrefix(doc.Version, "2.")
+	if !v2 && doc.Version != "1.2" && doc.Version != "1.1" {
+		return ErrXLIFFVersion
+	}
+	if sc != nil && sc.Units == nil {
+		sc.Units = make(map[string]XLIFFUnit)
+	}
+
+	tx := db.Begin()
+	l := xliffLoader{tx: tx, sc: sc}
+	for i := 0; i < len(doc.Files); i++ {
+		f := &doc.Files[i]
+		l.src, l.trg = f.SrcLang, f.TrgLang
+		if v2 {
+			l.src, l.trg = doc.SrcLang, doc.TrgLang
+		}
+		if len(l.src) == 0 {
+			tx.Rollback()
+			return ErrBadXLIFF
+		}
+		if sc != nil {
+			sc.Original = f.Original
+			if v2 {
+				sc.Original = f.ID
+			}
+		}
+		g := &f.xliffGroup
+		if !v2 {
+			g = &f.Body
+		}
+		if err := l.group(g); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DumpXLIFF writes translations of source and target locales to w in XLIFF of given version: "1.2" or "2.0".
+//
+// Every key of source locale becomes unit with target translation if it exists. Translations with plural forms write as group of units per form: "key[N]"
+// for bare forms, "key[category]" for keywords and "key[=N]" for exact rules. Translations which can't be split to
+// forms write as is with type "i18n:rules" ("x-i18n-rules" in XLIFF 1.2), ICU messages with type "i18n:icu" ("x-icu"),
+// so LoadXLIFF() restores them exactly. Unit has single type, so ICU message with non-ICU translation of the same key
+// returns ErrUnrepresentable. Notes and states are taken from sidecar sc if it isn't nil.
+func DumpXLIFF(db *DB, w io.Writer, version, srcLang, trgLang string, sc *XLIFFSidecar) error {
+	if err := db.checkStatus(); err != nil {
+		return err
+	}
+	if version != "1.2" && version != "2.0" {
+		return ErrXLIFFVersion
+	}
+	if len(srcLang) == 0 || len(trgLang) == 0 {
+		return ErrBadXLIFF
+	}
+	s := db.snap()
+	units := make(map[string]*xliffExport)
+	var keys []string
+	collect := func(locale string, target bool) {
+		prefix := locale + "."
+		s.eachPrefix(prefix, func(key string, rules []rule, buf []byte) {
+			k, ctx := splitCtx(key)
+			if len(ctx) > 0 {
+				// Contextual keys aren't representable in XLIFF.
+				return
+			}
+			k = k[len(prefix):]
+			u, ok := units[k]
+			if !ok {
+				if target {
+					// Source is required, so keys without source translation are skipped.
+					return
+				}
+				u = &xliffExport{key: k}
+				units[k] = u
+				keys = append(keys, k)
+			}
+			e := xliffEntry{ok: true}
+			if isICU(rules) {
+				e.typ, e.raw = xliffTypeICU, rules[0].bp.take(buf)
+			} else {
+				e.raw = rawRules(rules, buf)
+				if e.forms, ok = xliffForms(nil, rules, buf); !ok {
+					e.typ = xliffTypeRules
+				}
+			}
+			if target {
+				u.trg = e
+			} else {
+				u.src = e
+			}
+		})
+	}
+	collect(srcLang, false)
+	collect(trgLang, true)
+	sort.Strings(keys)
+	for _, k := range keys {
+		if u := units[k]; u.trg.ok && (u.src.typ == xliffTypeICU) != (u.trg.typ == xliffTypeICU) {
+			return ErrUnrepresentable
+		}
+	}
+
+	x := xliffWriter{w: bufio.NewWriter(w), v2: version == "2.0", sc: sc}
+	_, _ = x.w.WriteString(xml.Header)
+	var original string
+	if sc != nil {
+		original = sc.Original
+	}
+	if x.v2 {
+		if len(original) == 0 {
+			original = "f1"
+		}
+		x.printf(`<xliff version="2.0" xmlns="urn:oasis:names:tc:xliff:document:2.0" xmlns:i18n="`+xliffNS+`" srcLang="%s" trgLang="%s">`+"\n", srcLang, trgLang)
+		x.printf(`  <file id="%s">`+"\n", original)
+	} else {
+		if len(original) == 0 {
+			original = "i18n"
+		}
+		x.printf(`<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2" xmlns:i18n="` + xliffNS + `">` + "\n")
+		x.printf(`  <file source-language="%s" target-language="%s" datatype="plaintext" original="%s">`+"\n", srcLang, trgLang, original)
+		x.printf("    <body>\n")
+	}
+	for i, k := range keys {
+		x.unit(units[k], i+1)
+	}
+	if !x.v2 {
+		x.printf("    </body>\n")
+	}
+	x.printf("  </file>\n</xliff>\n")
+	return x.w.Flush()
+}
+
+// XLIFF document of both versions.
+type xliffDoc struct {
+	Version string      `xml:"version,attr"`
+	SrcLang string      `xml:"srcLang,attr"`
+	TrgLang string      `xml:"trgLang,attr"`
+	Files   []xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	SrcLang  string `xml:"source-language,attr"`
+	TrgLang  string `xml:"target-language,attr"`
+	Original string `xml:"original,attr"`
+	// XLIFF 1.2 units are inside body, XLIFF 2.0 units are direct children of file.
+	Body xliffGroup `xml:"body"`
+	xliffGroup
+}
+
+type xliffGroup struct {
+	ID      string       `xml:"id,attr"`
+	Name    string       `xml:"name,attr"`
+	Resname string       `xml:"resname,attr"`
+	Type    string       `xml:"type,attr"`
+	Restype string       `xml:"restype,attr"`
+	Notes   []xliffNote  `xml:"note"`
+	Notes2  []xliffNote  `xml:"notes>note"`
+	Units   []xliffUnit  `xml:"trans-unit"`
+	Units2  []xliffUnit  `xml:"unit"`
+	Groups  []xliffGroup `xml:"group"`
+}
+
+type xliffUnit struct {
+	ID      string `xml:"id,attr"`
+	Name    string `xml:"name,attr"`
+	Resname string `xml:"resname,attr"`
+	Type    string `xml:"type,attr"`
+	Restype string `xml:"restype,attr"`
+	// Fallback source of plural form, see xliffWriter.plurals().
+	SrcAttr string      `xml:"https://github.com/koykov/i18n source,attr"`
+	Source  xliffText   `xml:"source"`
+	Target  *xliffText  `xml:"target"`
+	Notes   []xliffNote `xml:"note"`
+	Notes2  []xliffNote `xml:"notes>note"`
+	// Segments and ignorables of XLIFF 2.0 unit in order.
+	Parts []xliffPart `xml:",any"`
+}
+
+type xliffPart struct {
+	XMLName xml.Name
+	State   string     `xml:"state,attr"`
+	Source  xliffText  `xml:"source"`
+	Target  *xliffText `xml:"target"`
+}
+
+type xliffText struct {
+	State string `xml:"state,attr"`
+	Inner string `xml:",innerxml"`
+}
+
+type xliffNote struct {
+	Text string `xml:",chardata"`
+}
+
+// Get key of group or unit.
+func xliffKey(id, name, resname string) string {
+	switch {
+	case len(resname) > 0:
+		return resname
+	case len(name) > 0:
+		return name
+	}
+	return id
+}
+
+// Get text contents of XML fragment without inline markup.
+func xmlPlain(inner string) (string, error) {
+	if strings.IndexByte(inner, '<') == -1 && strings.IndexByte(inner, '&') == -1 {
+		return inner, nil
+	}
+	dec := xml.NewDecoder(strings.NewReader("<t>" + inner + "</t>"))
+	var b strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return b.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if cd, ok := tok.(xml.CharData); ok {
+			b.Write(cd)
+		}
+	}
+}
+
+// Loader of XLIFF units to transaction.
+type xliffLoader struct {
+	tx       *Txn
+	sc       *XLIFFSidecar
+	src, trg string
+	buf      []byte
+}
+
+// Translation form of plural group or translation.
+type xliffForm struct {
+	label    string
+	src, trg string
+	hasTrg   bool
+	// Source is filled from another form.
+	fallback bool
+}
+
+func (l *xliffLoader) group(g *xliffGroup) error {
+	key := xliffKey(g.ID, g.Name, g.Resname)
+	if g.Type == xliffTypePlurals || g.Restype == xliffRestypePlurals {
+		return l.plurals(key, g)
+	}
+	units := g.Units
+	if len(units) == 0 {
+		units = g.Units2
+	}
+	for i := 0; i < len(units); i++ {
+		if err := l.unit(&units[i]); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < len(g.Groups); i++ {
+		if err := l.group(&g.Groups[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get source, target, state and notes of unit.
+func (l *xliffLoader) texts(u *xliffUnit) (src, trg, state string, hasTrg bool, notes []string, err error) {
+	for _, n := range append(u.Notes, u.Notes2...) {
+		notes = append(notes, strings.TrimSpace(n.Text))
+	}
+	if u.Target != nil || len(u.Source.Inner) > 0 {
+		// XLIFF 1.2 unit.
+		if src, err = xmlPlain(u.Source.Inner); err != nil {
+			return
+		}
+		if u.Target != nil {
+			hasTrg, state = true, u.Target.State
+			trg, err = xmlPlain(u.Target.Inner)
+		}
+		return
+	}
+	var ssrc, strg strings.Builder
+	for i := 0; i < len(u.Parts); i++ {
+		p := &u.Parts[i]
+		if p.XMLName.Local != "segment" && p.XMLName.Local != "ignorable" {
+			continue
+		}
+		if len(state) == 0 {
+			state = p.State
+		}
+		var s string
+		if s, err = xmlPlain(p.Source.Inner); err != nil {
+			return
+		}
+		ssrc.WriteString(s)
+		t := s
+		if p.Target != nil {
+			hasTrg = true
+			if t, err = xmlPlain(p.Target.Inner); err != nil {
+				return
+			}
+		} else if p.XMLName.Local == "segment" {
+			t = ""
+		}
+		strg.WriteString(t)
+	}
+	return ssrc.String(), strg.String(), state, hasTrg, notes, nil
+}
+
+func (l *xliffLoader) unit(u *xliffUnit) error {
+	key := xliffKey(u.ID, u.Name, u.Resname)
+	if len(key) == 0 {
+		return ErrBadXLIFF
+	}
+	src, trg, state, hasTrg, notes, err := l.texts(u)
+	if err != nil {
+		return err
+	}
+	l.meta(key, state, notes)
+	typ := u.Type
+	switch u.Restype {
+	case xliffRestypeRules:
+		typ = xliffTypeRules
+	case xliffRestypeICU:
+		typ = xliffTypeICU
+	}
+	if err = l.set(typ, l.src, key, src); err != nil {
+		return err
+	}
+	if hasTrg && len(l.trg) > 0 {
+		return l.set(typ, l.trg, key, trg)
+	}
+	return nil
+}
+
+// Save translation of locale using unit type typ.
+func (l *xliffLoader) set(typ, locale, key, t9n string) error {
+	if len(t9n) == 0 {
+		return nil
+	}
+	key = locale + "." + key
+	switch typ {
+	case xliffTypeICU:
+		return l.tx.SetICU(key, t9n)
+	case xliffTypeRules:
+		return l.tx.Set(key, t9n)
+	}
+	l.buf = escT9n(l.buf[:0], t9n)
+	return l.tx.Set(key, string(l.buf))
+}
+
+// Save plural forms group.
+func (l *xliffLoader) plurals(key string, g *xliffGroup) error {
+	if len(key) == 0 {
+		return ErrBadXLIFF
+	}
+	units := g.Units
+	if len(units) == 0 {
+		units = g.Units2
+	}
+	var (
+		forms  []xliffForm
+		notes  []string
+		state  string
+		hasTrg bool
+	)
+	for _, n := range append(g.Notes, g.Notes2...) {
+		notes = append(notes, strings.TrimSpace(n.Text))
+	}
+	for i := 0; i < len(units); i++ {
+		u := &units[i]
+		ukey := xliffKey(u.ID, u.Name, u.Resname)
+		if !strings.HasPrefix(ukey, key+"[") || ukey[len(ukey)-1] != ']' {
+			return ErrBadXLIFF
+		}
+		src, trg, st, ht, n, err := l.texts(u)
+		if err != nil {
+			return err
+		}
+		if len(state) == 0 {
+			state = st
+		}
+		notes = append(notes, n...)
+		hasTrg = hasTrg || ht
+		forms = append(forms, xliffForm{
+			label:    ukey[len(key)+1 : len(ukey)-1],
+			src:      src,
+			trg:      trg,
+			hasTrg:   ht,
+			fallback: u.SrcAttr == "fallback",
+		})
+	}
+	l.meta(key, state, notes)
+	if err := l.setForms(l.src, key, forms, false); err != nil {
+		return err
+	}
+	if hasTrg && len(l.trg) > 0 {
+		return l.setForms(l.trg, key, forms, true)
+	}
+	return nil
+}
+
+// Compose and save translation of plural forms.
+func (l *xliffLoader) setForms(locale, key string, forms []xliffForm, target bool) error {
+	l.buf = l.buf[:0]
+	var n int
+	for i := 0; i < len(forms); i++ {
+		f := &forms[i]
+		t9n := f.src
+		if target {
+			if !f.hasTrg {
+				continue
+			}
+			t9n = f.trg
+		} else if f.fallback {
+			continue
+		}
+		if len(t9n) == 0 {
+			// Untranslated form, skip whole translation as bare forms can't be shifted.
+			return nil
+		}
+		if n > 0 {
+			l.buf = append(l.buf, '|')
+		}
+		n++
+		switch {
+		case isDigits(f.label):
+		case len(f.label) > 1 && f.label[0] == '=' && isDigits(f.label[1:]):
+			l.buf = append(l.buf, '{')
+			l.buf = append(l.buf, f.label[1:]...)
+			l.buf = append(l.buf, "} "...)
+		default:
+			if _, ok := ParsePluralCategory(f.label); !ok {
+				return ErrBadXLIFF
+			}
+			l.buf = append(l.buf, '{')
+			l.buf = append(l.buf, f.label...)
+			l.buf = append(l.buf, "} "...)
+		}
+		l.buf = escT9n(l.buf, t9n)
+	}
+	if n == 0 {
+		return nil
+	}
+	return l.tx.Set(locale+"."+key, string(l.buf))
+}
+
+// Save notes and state of key to sidecar.
+func (l *xliffLoader) meta(key, state string, notes []string) {
+	if l.sc == nil || (len(state) == 0 && len(notes) == 0) {
+		return
+	}
+	l.sc.Units[key] = XLIFFUnit{State: state, Notes: notes}
+}
+
+func isDigits(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Exporting unit with source and target translations.
+type xliffExport struct {
+	key      string
+	src, trg xliffEntry
+}
+
+// Exporting translation: forms or raw text of given type.
+type xliffEntry struct {
+	ok    bool
+	typ   string
+	raw   string
+	forms []xliffForm
+}
+
+// Get labeled forms of translation rules.
+//
+// Returns false if rules contain ranges or select keywords.
+func xliffForms(dst []xliffForm, rules []rule, buf []byte) ([]xliffForm, bool) {
+	for i := 0; i < len(rules); i++ {
+		r := &rules[i]
+		if r.arg>>16 != 0 {
+			return dst, false
+		}
+		f := xliffForm{src: unescT9n(r.bp.take(buf))}
+		switch r.kind {
+		case ruleBare:
+			f.label = strconv.Itoa(int(r.pos()))
+		case ruleCategory:
+			f.label = PluralCategory(r.pos()).String()
+		case ruleExact:
+			if r.lo < 0 {
+				return dst, false
+			}
+			f.label = "=" + strconv.FormatInt(r.lo, 10)
+		default:
+			return dst, false
+		}
+		dst = append(dst, f)
+	}
+	return dst, true
+}
+
+// Writer of XLIFF units.
+type xliffWriter struct {
+	w  *bufio.Writer
+	v2 bool
+	sc *XLIFFSidecar
+}
+
+// Write format replacing "%s" verbs with escaped args.
+func (x *xliffWriter) printf(format string, args ...string) {
+	for i := 0; ; i++ {
+		j := strings.Index(format, "%s")
+		if j == -1 || i == len(args) {
+			_, _ = x.w.WriteString(format)
+			return
+		}
+		_, _ = x.w.WriteString(format[:j])
+		_ = xml.EscapeText(x.w, []byte(args[i]))
+		format = format[j+2:]
+	}
+}
+
+// Write element with escaped text.
+func (x *xliffWriter) elem(indent int, name, attrs, text string) {
+	_, _ = x.w.WriteString(strings.Repeat("  ", indent))
+	_, _ = x.w.WriteString("<" + name + attrs + ">")
+	_ = xml.EscapeText(x.w, []byte(text))
+	_, _ = x.w.WriteString("</" + name + ">\n")
+}
+
+// Get attribute string.
+func xliffAttr(name, value string) string {
+	if len(value) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(value))
+	return " " + name + `="` + b.String() + `"`
+}
+
+func (x *xliffWriter) unit(u *xliffExport, n int) {
+	var meta XLIFFUnit
+	if x.sc != nil {
+		meta = x.sc.Units[u.key]
+	}
+	typ := u.src.typ
+	if u.trg.ok && len(u.trg.typ) > 0 {
+		typ = u.trg.typ
+	}
+	level := 3
+	if x.v2 {
+		level = 2
+	}
+	// Plural forms are written as group if either source or target has several forms.
+	if len(typ) == 0 && (len(u.src.forms) > 1 || len(u.trg.forms) > 1) {
+		x.plurals(level, u, &meta, n)
+		return
+	}
+	src, trg := u.src.text(), u.trg.text()
+	if len(typ) > 0 {
+		// Raw translations keep escaped forms, so plain side must be raw too.
+		src, trg = u.src.raw, u.trg.raw
+	}
+	id := strconv.Itoa(n)
+	x.item(level, u.key, id, typ, src, trg, false, u.trg.ok, &meta, true)
+}
+
+// Write plural forms group.
+//
+// Group contains unit per form of both source and target. Source of form which source locale doesn't have is filled
+// with similar source form and marked as fallback, so LoadXLIFF() skips it.
+func (x *xliffWriter) plurals(level int, u *xliffExport, meta *XLIFFUnit, n int) {
+	ind := strings.Repeat("  ", level)
+	id := strconv.Itoa(n)
+	if x.v2 {
+		x.printf(ind+`<group id="g%s" name="%s" type="`+xliffTypePlurals+`">`+"\n", id, u.key)
+	} else {
+		x.printf(ind+`<group id="%s" restype="`+xliffRestypePlurals+`">`+"\n", u.key)
+	}
+	x.notes(level+1, meta.Notes)
+	labels := make([]string, 0, len(u.src.forms)+len(u.trg.forms))
+	for i := 0; i < len(u.trg.forms); i++ {
+		labels = append(labels, u.trg.forms[i].label)
+	}
+	for i := 0; i < len(u.src.forms); i++ {
+		if _, ok := u.trg.find(u.src.forms[i].label); !ok {
+			labels = append(labels, u.src.forms[i].label)
+		}
+	}
+	for i, label := range labels {
+		src, ok := u.src.find(label)
+		if !ok {
+			src = u.src.fallback(label)
+		}
+		trg, hasTrg := u.trg.find(label)
+		x.item(level+1, u.key+"["+label+"]", id+"-"+strconv.Itoa(i), "", src, trg, !ok, hasTrg, meta, false)
+	}
+	_, _ = x.w.WriteString(ind + "</group>\n")
+}
+
+// Write unit with source and optional target.
+func (x *xliffWriter) item(level int, key, id, typ, src, trg string, fallback, hasTrg bool, meta *XLIFFUnit, notes bool) {
+	ind := strings.Repeat("  ", level)
+	var attrs string
+	if fallback {
+		attrs = ` i18n:source="fallback"`
+	}
+	if x.v2 {
+		x.printf(ind+`<unit id="u%s" name="%s"`, id, key)
+		_, _ = x.w.WriteString(xliffAttr("type", typ) + attrs + ">\n")
+		if notes {
+			x.notes(level+1, meta.Notes)
+		}
+		_, _ = x.w.WriteString(ind + "  <segment" + xliffAttr("state", xliffState2(meta.State, hasTrg)) + ">\n")
+		x.elem(level+2, "source", "", src)
+		if hasTrg {
+			x.elem(level+2, "target", "", trg)
+		}
+		_, _ = x.w.WriteString(ind + "  </segment>\n")
+		_, _ = x.w.WriteString(ind + "</unit>\n")
+		return
+	}
+	restype := typ
+	switch typ {
+	case xliffTypeRules:
+		restype = xliffRestypeRules
+	case xliffTypeICU:
+		restype = xliffRestypeICU
+	}
+	x.printf(ind+`<trans-unit id="%s"`, key)
+	_, _ = x.w.WriteString(xliffAttr("restype", restype) + attrs + ">\n")
+	x.elem(level+1, "source", "", src)
+	if hasTrg {
+		x.elem(level+1, "target", xliffAttr("state", xliffState1(meta.State)), trg)
+	}
+	if notes {
+		x.notes(level+1, meta.Notes)
+	}
+	_, _ = x.w.WriteString(ind + "</trans-unit>\n")
+}
+
+// Write notes with indentation.
+func (x *xliffWriter) notes(indent int, notes []string) {
+	if len(notes) == 0 {
+		return
+	}
+	if !x.v2 {
+		for _, n := range notes {
+			x.elem(indent, "note", "", n)
+		}
+		return
+	}
+	_, _ = x.w.WriteString(strings.Repeat("  ", indent) + "<notes>\n")
+	for _, n := range notes {
+		x.elem(indent+1, "note", "", n)
+	}
+	_, _ = x.w.WriteString(strings.Repeat("  ", indent) + "</notes>\n")
+}
+
+// Get XLIFF 2.0 segment state: initial, translated, reviewed or final.
+func xliffState2(state string, hasTrg bool) string {
+	switch state {
+	case "initial", "translated", "reviewed", "final":
+		return state
+	case "new", "needs-translation":
+		return "initial"
+	case "signed-off":
+		return "reviewed"
+	}
+	if len(state) > 0 || hasTrg {
+		return "translated"
+	}
+	return ""
+}
+
+// Get XLIFF 1.2 target state, states of XLIFF 2.0 map to similar ones.
+func xliffState1(state string) string {
+	switch state {
+	case "initial":
+		return "new"
+	case "reviewed":
+		return "signed-off"
+	}
+	return state
+}
+
+// Get single text of the entry.
+func (e *xliffEntry) text() string {
+	if len(e.forms) > 0 {
+		return e.forms[0].src
+	}
+	return e.raw
+}
+
+// Find form of the entry by label.
+func (e *xliffEntry) find(label string) (string, bool) {
+	for i := 0; i < len(e.forms); i++ {
+		if e.forms[i].label == label {
+			return e.forms[i].src, true
+		}
+	}
+	return "", false
+}
+
+// Get form which serves instead of missing label: the last bare form or "other" form.
+func (e *xliffEntry) fallback(label string) string {
+	var last string
+	for i := 0; i < len(e.forms); i++ {
+		f := &e.forms[i]
+		if isDigits(f.label) == isDigits(label) || f.label == "other" {
+			last = f.src
+		}
+	}
+	if len(last) == 0 && len(e.forms) > 0 {
+		last = e.forms[len(e.forms)-1].src
+	}
+	return last
+}
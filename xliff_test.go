@@ -0,0 +1,217 @@
+package i18n
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+const testXLIFF12 = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+  <file source-language="en" target-language="ru" datatype="plaintext" original="messages.po">
+    <body>
+      <trans-unit id="welcome">
+        <source>Hi, <g id="1">!name</g>!</source>
+        <target state="translated">Привет, <g id="1">!name</g>!</target>
+        <note>Greeting on main page</note>
+      </trans-unit>
+      <trans-unit id="pipe">
+        <source>a|b &amp; c</source>
+        <target state="needs-review-translation">а|б &amp; в</target>
+      </trans-unit>
+      <trans-unit id="untranslated">
+        <source>Later</source>
+      </trans-unit>
+      <group id="menu">
+        <trans-unit id="x1" resname="menu.open">
+          <source>Open</source>
+          <target>Открыть</target>
+        </trans-unit>
+      </group>
+      <group id="files" restype="x-gettext-plurals">
+        <note>Files counter</note>
+        <trans-unit id="files[0]">
+          <source>!count file</source>
+          <target state="final">!count файл</target>
+        </trans-unit>
+        <trans-unit id="files[1]">
+          <source>!count files</source>
+          <target state="final">!count файла</target>
+        </trans-unit>
+        <trans-unit id="files[2]">
+          <source>!count files</source>
+          <target state="final">!count файлов</target>
+        </trans-unit>
+      </group>
+    </body>
+  </file>
+</xliff>`
+
+const testXLIFF20 = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="2.0" xmlns="urn:oasis:names:tc:xliff:document:2.0" srcLang="en" trgLang="de">
+  <file id="app">
+    <unit id="u1" name="welcome">
+      <notes>
+        <note>Greeting on main page</note>
+      </notes>
+      <segment state="reviewed">
+        <source>Hi, <ph id="1" equiv="!name"/>friend.</source>
+        <target>Hallo, Freund.</target>
+      </segment>
+      <ignorable>
+        <source> </source>
+      </ignorable>
+      <segment>
+        <source>Welcome!</source>
+        <target>Willkommen!</target>
+      </segment>
+    </unit>
+    <unit id="u2">
+      <segment state="initial">
+        <source>Close</source>
+      </segment>
+    </unit>
+  </file>
+</xliff>`
+
+func TestXLIFF(t *testing.T) {
+	assert12 := func(t *testing.T, db *DB) {
+		assertT9n(t, db, "en.welcome", "Hi, !name!")
+		assertT9n(t, db, "ru.welcome", "Привет, !name!")
+		assertT9n(t, db, "ru.pipe", "а|б & в")
+		assertT9n(t, db, "en.untranslated", "Later")
+		assertT9n(t, db, "ru.untranslated", "")
+		assertT9n(t, db, "ru.menu.open", "Открыть")
+		assertT9nPlural(t, db, "en.files", "!count file", 1)
+		assertT9nPlural(t, db, "en.files", "!count files", 5)
+		assertT9nPlural(t, db, "ru.files", "!count файл", 21)
+		assertT9nPlural(t, db, "ru.files", "!count файла", 3)
+		assertT9nPlural(t, db, "ru.files", "!count файлов", 5)
+	}
+
+	var sc XLIFFSidecar
+	db, _ := New(xxhash.Hasher64[string]{})
+	if err := LoadXLIFF(db, strings.NewReader(testXLIFF12), &sc); err != nil {
+		t.Fatal(err)
+	}
+	assert12(t, db)
+	expectSC := XLIFFSidecar{
+		Original: "messages.po",
+		Units: map[string]XLIFFUnit{
+			"welcome": {State: "translated", Notes: []string{"Greeting on main page"}},
+			"pipe":    {State: "needs-review-translation"},
+			"files":   {State: "final", Notes: []string{"Files counter"}},
+		},
+	}
+	if !reflect.DeepEqual(sc, expectSC) {
+		t.Errorf("sidecar mismatch, need %v got %v", expectSC, sc)
+	}
+
+	t.Run("2.0", func(t *testing.T) {
+		var sc XLIFFSidecar
+		db, _ := New(xxhash.Hasher64[string]{})
+		if err := LoadXLIFF(db, strings.NewReader(testXLIFF20), &sc); err != nil {
+			t.Fatal(err)
+		}
+		assertT9n(t, db, "en.welcome", "Hi, friend. Welcome!")
+		assertT9n(t, db, "de.welcome", "Hallo, Freund. Willkommen!")
+		assertT9n(t, db, "en.u2", "Close")
+		assertT9n(t, db, "de.u2", "")
+		if u := sc.Units["welcome"]; sc.Original != "app" || u.State != "reviewed" || len(u.Notes) != 1 {
+			t.Errorf("sidecar mismatch, got %v", sc)
+		}
+		if u := sc.Units["u2"]; u.State != "initial" {
+			t.Errorf("state mismatch, got %q", u.State)
+		}
+	})
+	t.Run("dump", func(t *testing.T) {
+		raw := func(db *DB, key string) string { return db.snap().getRaw(db.hkey(key)) }
+		db1, _ := New(xxhash.Hasher64[string]{})
+		_ = db1.Set("en.apples", "{0} no apples|{one} one apple|{other} !count apples")
+		_ = db1.Set("ru.apples", "{0} нет яблок|{one} !count яблоко|{few} !count яблока|{many} !count яблок")
+		_ = db1.Set("en.range", "[0,5] few|many")
+		_ = db1.Set("ru.range", "мало")
+		_ = db1.Set("en.mixed", "[0,5] few|many")
+		_ = db1.Set("ru.mixed", "штука|штуки|штук")
+		_ = db1.SetICU("en.icu", "{n, plural, one {# day} other {# days}}")
+		_ = db1.SetICU("ru.icu", "{n, plural, one {# день} other {# дней}}")
+		_ = db1.Set("ru.only", "Только ru")
+		for _, version := range []string{"1.2", "2.0"} {
+			var buf bytes.Buffer
+			if err := DumpXLIFF(db, &buf, version, "en", "ru", &sc); err != nil {
+				t.Fatal(err)
+			}
+			var sc1 XLIFFSidecar
+			db2, _ := New(xxhash.Hasher64[string]{})
+			if err := LoadXLIFF(db2, bytes.NewReader(buf.Bytes()), &sc1); err != nil {
+				t.Fatal(version, err, buf.String())
+			}
+			assert12(t, db2)
+			if version == "1.2" && !reflect.DeepEqual(sc1, expectSC) {
+				t.Errorf("%s: sidecar mismatch, need %v got %v", version, expectSC, sc1)
+			}
+			if u := sc1.Units["files"]; version == "2.0" && (u.State != "final" || len(u.Notes) != 1) {
+				t.Errorf("%s: sidecar mismatch, got %v", version, sc1)
+			}
+
+			buf.Reset()
+			if err := DumpXLIFF(db1, &buf, version, "en", "ru", nil); err != nil {
+				t.Fatal(err)
+			}
+			db3, _ := New(xxhash.Hasher64[string]{})
+			if err := LoadXLIFF(db3, bytes.NewReader(buf.Bytes()), nil); err != nil {
+				t.Fatal(version, err, buf.String())
+			}
+			for _, key := range []string{"en.apples", "ru.apples", "en.range", "ru.range", "en.icu", "ru.icu", "en.mixed", "ru.mixed"} {
+				if a, b := raw(db1, key), raw(db3, key); a != b {
+					t.Errorf("%s: %s mismatch, need %q got %q", version, key, a, b)
+				}
+			}
+			if raw(db3, "ru.only") != "" || raw(db3, "en.only") != "" {
+				t.Errorf("%s: unit without source exported", version)
+			}
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, src := range []string{
+			`<xliff version="3.0"><file source-language="en"/></xliff>`,
+			`<xliff version="1.2"><file><body><trans-unit id="a"><source>x</source></trans-unit></body></file></xliff>`,
+			`<xliff version="1.2"><file source-language="en"><body><trans-unit id="a"><source>x</source></trans-unit>` +
+				`<trans-unit><source>y</source></trans-unit></body></file></xliff>`,
+			`<xliff version="1.2"><file source-language="en"><body><trans-unit id="a"><source>x</source>` +
+				`</body></file></xliff>`,
+			`<xliff version="1.2"><file source-language="en"><body><trans-unit id="a"><source>x</source></trans-unit>` +
+				`<group id="b" restype="x-gettext-plurals"><trans-unit id="c[0]"><source>y</source></trans-unit>` +
+				`</group></body></file></xliff>`,
+		} {
+			db, _ := New(xxhash.Hasher64[string]{})
+			if err := LoadXLIFF(db, strings.NewReader(src), nil); err == nil {
+				t.Errorf("%s: error expected", src)
+			}
+			if db.Get("en.a", "") != "" {
+				t.Errorf("%s: translations saved despite error", src)
+			}
+		}
+		if err := DumpXLIFF(db, &bytes.Buffer{}, "1.0", "en", "ru", nil); err != ErrXLIFFVersion {
+			t.Errorf("need ErrXLIFFVersion got %v", err)
+		}
+
+		// Unit type can't be both ICU and not ICU.
+		for _, t9n := range []string{"день|дня|дней", "[0,5] мало|много", "день"} {
+			db, _ := New(xxhash.Hasher64[string]{})
+			_ = db.SetICU("en.days", "{n, plural, one {# day} other {# days}}")
+			_ = db.Set("ru.days", t9n)
+			if err := DumpXLIFF(db, &bytes.Buffer{}, "2.0", "en", "ru", nil); err != ErrUnrepresentable {
+				t.Errorf("%s: need ErrUnrepresentable got %v", t9n, err)
+			}
+			_ = db.Set("en.days", t9n)
+			_ = db.SetICU("ru.days", "{n, plural, one {# день} other {# дней}}")
+			if err := DumpXLIFF(db, &bytes.Buffer{}, "1.2", "en", "ru", nil); err != ErrUnrepresentable {
+				t.Errorf("%s: need ErrUnrepresentable got %v", t9n, err)
+			}
+		}
+	})
+}
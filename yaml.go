@@ -0,0 +1,542 @@
+package i18n
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LoadYAML loads Rails-style YAML translations to db, eg:
+//
+//	en:
+//	  messages:
+//	    welcome: "Hi, %{name}!"
+//	  apples:
+//	    zero: no apples
+//	    one: one apple
+//	    other: "%{count} apples"
+//
+// Nested maps flatten to dotted keys: "en.messages.welcome". If locale isn't empty, it prefixes all keys. Maps of plural
+// categories fold to single translation with category keywords, "zero" becomes exact rule "{0}" as Rails does. Use
+// PlaceholderReplacer.SetKeyFormat("%{", "}") to replace "%{name}" markers.
+//
+// Supported subset of YAML is block maps with plain, quoted and block scalars. Sequences and flow collections are
+// skipped, anchors and aliases aren't supported. All translations save in single transaction, so nothing saves if
+// file is malformed. Errors contain line number, see ParseError.
+func LoadYAML(db *DB, locale string, r io.Reader) error {
+	if err := db.checkWrite(); err != nil {
+		return err
+	}
+	var p yamlParser
+	if err := p.parse(r); err != nil {
+		return err
+	}
+	tx := db.Begin()
+	l := yamlLoader{tx: tx}
+	if err := l.load(&p.root, locale); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Node of YAML tree.
+type yamlNode struct {
+	children map[string]*yamlNode
+	// Keys of children in order of appearance.
+	keys  []string
+	value string
+	leaf  bool
+	line  int
+}
+
+// Get or create child map node.
+func (n *yamlNode) child(key string, line int) *yamlNode {
+	if n.children == nil {
+		n.children = make(map[string]*yamlNode)
+	}
+	c, ok := n.children[key]
+	if !ok {
+		c = &yamlNode{}
+		n.children[key] = c
+		n.keys = append(n.keys, key)
+	}
+	c.line = line
+	return c
+}
+
+// Parser of YAML subset.
+type yamlParser struct {
+	root  yamlNode
+	lines []string
+	i     int
+}
+
+// Map on stack of parser.
+type yamlLevel struct {
+	indent int
+	node   *yamlNode
+}
+
+func (p *yamlParser) parse(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 16<<20)
+	for s.Scan() {
+		p.lines = append(p.lines, strings.TrimSuffix(s.Text(), "\r"))
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	stack := []yamlLevel{{indent: -1, node: &p.root}}
+	for p.i = 0; p.i < len(p.lines); p.i++ {
+		line := p.lines[p.i]
+		indent, ok := yamlIndent(line)
+		if !ok {
+			return p.error()
+		}
+		content := line[indent:]
+		if len(content) == 0 || content[0] == '#' {
+			continue
+		}
+		if indent == 0 && (content == "---" || content == "..." || strings.HasPrefix(content, "--- ") || content[0] == '%') {
+			// Document markers and directives.
+			continue
+		}
+		for stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].node
+
+		key, rest, ok := yamlKey(content)
+		if !ok || key == "<<" {
+			return p.error()
+		}
+		if len(rest) > 0 && rest[0] == '&' {
+			// Skip anchor name.
+			if i := strings.IndexByte(rest, ' '); i != -1 {
+				rest = strings.TrimSpace(rest[i:])
+			} else {
+				rest = ""
+			}
+		}
+		if len(rest) == 0 || rest[0] == '#' {
+			if p.skipSeq(indent) {
+				continue
+			}
+			c := parent.child(key, p.i+1)
+			c.leaf, c.value = false, ""
+			stack = append(stack, yamlLevel{indent: indent, node: c})
+			continue
+		}
+		line0 := p.i + 1
+		value, skip, err := p.value(rest, indent)
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
+		c := parent.child(key, line0)
+		c.leaf, c.value, c.children, c.keys = true, value, nil, nil
+	}
+	return nil
+}
+
+// Skip block sequence following the key with indentation indent.
+func (p *yamlParser) skipSeq(indent int) bool {
+	j := p.next(p.i + 1)
+	if j == len(p.lines) {
+		return false
+	}
+	ind, _ := yamlIndent(p.lines[j])
+	if c := p.lines[j][ind:]; ind < indent || c[0] != '-' || (len(c) > 1 && c[1] != ' ') {
+		return false
+	}
+	for ; j < len(p.lines); j = p.next(j + 1) {
+		ind, _ := yamlIndent(p.lines[j])
+		if c := p.lines[j][ind:]; ind < indent || (ind == indent && c[0] != '-') {
+			break
+		}
+		p.i = j
+	}
+	return true
+}
+
+// Get index of the next line with content starting from j.
+func (p *yamlParser) next(j int) int {
+	for ; j < len(p.lines); j++ {
+		if c := strings.TrimLeft(p.lines[j], " "); len(c) > 0 && c[0] != '#' {
+			return j
+		}
+	}
+	return j
+}
+
+// Parse scalar value of key with indentation indent. Returns true if value should be skipped.
+func (p *yamlParser) value(rest string, indent int) (string, bool, error) {
+	switch rest[0] {
+	case '*':
+		return "", false, p.error()
+	case '|', '>':
+		v, err := p.block(rest, indent)
+		return v, false, err
+	case '"', '\'':
+		v, err := p.quoted(rest, indent)
+		return v, false, err
+	case '[', '{':
+		// Flow collections aren't translations.
+		if !yamlBalanced(rest) {
+			return "", false, p.error()
+		}
+		return "", true, nil
+	}
+	v := yamlStripComment(rest)
+	// Continuation lines of plain scalar.
+	for j := p.i + 1; j < len(p.lines); j++ {
+		ind, _ := yamlIndent(p.lines[j])
+		c := p.lines[j][ind:]
+		if len(c) == 0 {
+			if k := p.next(j); k < len(p.lines) {
+				if ind, _ = yamlIndent(p.lines[k]); ind > indent {
+					v += "\n"
+					continue
+				}
+			}
+			break
+		}
+		if ind <= indent || c[0] == '#' {
+			break
+		}
+		if strings.HasSuffix(v, "\n") {
+			v += yamlStripComment(c)
+		} else {
+			v += " " + yamlStripComment(c)
+		}
+		p.i = j
+	}
+	if v == "~" || v == "null" || v == "Null" || v == "NULL" {
+		return "", true, nil
+	}
+	return v, false, nil
+}
+
+// Parse quoted scalar, it may span several lines.
+func (p *yamlParser) quoted(s string, indent int) (string, error) {
+	q, start := s[0], p.i
+	var raw strings.Builder
+	raw.WriteString(s[1:])
+	for {
+		if end := yamlQuoteEnd(raw.String(), q); end != -1 {
+			if tail := strings.TrimSpace(raw.String()[end+1:]); len(tail) > 0 && tail[0] != '#' {
+				return "", p.error()
+			}
+			v, ok := yamlUnquote(raw.String()[:end], q)
+			if !ok {
+				return "", p.error()
+			}
+			return v, nil
+		}
+		if p.i++; p.i == len(p.lines) {
+			p.i = start
+			return "", p.error()
+		}
+		// Line break folds to space, empty line is a newline.
+		c := strings.TrimSpace(p.lines[p.i])
+		if len(c) == 0 {
+			raw.WriteByte('\n')
+			continue
+		}
+		if str := raw.String(); len(str) > 0 && str[len(str)-1] != '\n' {
+			raw.WriteByte(' ')
+		}
+		raw.WriteString(c)
+	}
+}
+
+// Unquote contents of quoted scalar.
+func yamlUnquote(s string, q byte) (string, bool) {
+	if q == '\'' {
+		return strings.ReplaceAll(s, "''", "'"), true
+	}
+	if strings.IndexByte(s, '\\') == -1 {
+		return s, true
+	}
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			buf = append(buf, c)
+			continue
+		}
+		if i++; i == len(s) {
+			return "", false
+		}
+		switch c = s[i]; c {
+		case 'n':
+			buf = append(buf, '\n')
+		case 't':
+			buf = append(buf, '\t')
+		case 'r':
+			buf = append(buf, '\r')
+		case '0':
+			buf = append(buf, 0)
+		case ' ', '"', '\\', '/':
+			buf = append(buf, c)
+		case 'x', 'u', 'U':
+			n := 2
+			if c == 'u' {
+				n = 4
+			} else if c == 'U' {
+				n = 8
+			}
+			if i+n >= len(s) {
+				return "", false
+			}
+			r, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+			if err != nil {
+				return "", false
+			}
+			buf = utf8.AppendRune(buf, rune(r))
+			i += n
+		default:
+			return "", false
+		}
+	}
+	return string(buf), true
+}
+
+// Parse block scalar with header, eg: "|", "|-" or ">+2".
+func (p *yamlParser) block(header string, indent int) (string, error) {
+	folded := header[0] == '>'
+	var (
+		chomp byte
+		bi    int
+	)
+	for _, c := range []byte(yamlStripComment(header[1:])) {
+		switch {
+		case c == '-' || c == '+':
+			chomp = c
+		case c >= '1' && c <= '9':
+			bi = indent + int(c-'0')
+		default:
+			return "", p.error()
+		}
+	}
+	var lines []string
+	for j := p.i + 1; j < len(p.lines); j++ {
+		ind, ok := yamlIndent(p.lines[j])
+		if !ok {
+			p.i = j
+			return "", p.error()
+		}
+		if ind == len(p.lines[j]) {
+			lines = append(lines, "")
+			continue
+		}
+		if bi == 0 {
+			if ind <= indent {
+				break
+			}
+			bi = ind
+		}
+		if ind < bi {
+			break
+		}
+		lines = append(lines, p.lines[j][bi:])
+		p.i = j
+	}
+	// Trailing empty lines belong to the block only for keep chomping.
+	n := len(lines)
+	for n > 0 && len(lines[n-1]) == 0 {
+		n--
+	}
+	trail := len(lines) - n
+	lines = lines[:n]
+
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString(yamlFold(lines[i-1], line, folded))
+		}
+		b.WriteString(line)
+	}
+	switch {
+	case n == 0:
+	case chomp == '-':
+	case chomp == '+':
+		b.WriteString(strings.Repeat("\n", trail+1))
+	default:
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// Get separator of block scalar lines prev and line.
+//
+// Folded block joins lines with space, empty line becomes a newline. More indented lines keep their line breaks.
+func yamlFold(prev, line string, folded bool) string {
+	if !folded {
+		return "\n"
+	}
+	prevMore, lineMore := len(prev) > 0 && prev[0] == ' ', len(line) > 0 && line[0] == ' '
+	switch {
+	case len(prev) == 0 || prevMore || lineMore:
+		return "\n"
+	case len(line) == 0:
+		return ""
+	}
+	return " "
+}
+
+func (p *yamlParser) error() error {
+	return &ParseError{Line: p.i + 1, Err: ErrBadYAML}
+}
+
+// Get indentation of line, tabs aren't allowed.
+func yamlIndent(line string) (int, bool) {
+	var i int
+	for i < len(line) && line[i] == ' ' {
+		i++
+	}
+	if i < len(line) && line[i] == '\t' {
+		return i, false
+	}
+	return i, true
+}
+
+// Split content of line to key and the rest after colon.
+func yamlKey(content string) (key, rest string, ok bool) {
+	if q := content[0]; q == '"' || q == '\'' {
+		end := yamlQuoteEnd(content[1:], q)
+		if end == -1 {
+			return
+		}
+		if key, ok = yamlUnquote(content[1:end+1], q); !ok {
+			return
+		}
+		content = strings.TrimLeft(content[end+2:], " ")
+		if len(content) == 0 || content[0] != ':' {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(content[1:]), true
+	}
+	for i := 0; i < len(content); i++ {
+		if content[i] == ':' && (i+1 == len(content) || content[i+1] == ' ') {
+			return strings.TrimSpace(content[:i]), strings.TrimSpace(content[i+1:]), i > 0
+		}
+		if content[i] == ' ' && i+1 < len(content) && content[i+1] == '#' {
+			break
+		}
+	}
+	return
+}
+
+// Get index of closing quote q in s.
+func yamlQuoteEnd(s string, q byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q:
+			if q == '\'' && i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// Remove trailing comment of plain scalar.
+func yamlStripComment(s string) string {
+	if i := strings.Index(s, " #"); i != -1 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// Check brackets balance of single line flow collection.
+func yamlBalanced(s string) bool {
+	var depth int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case '"', '\'':
+			end := yamlQuoteEnd(s[i+1:], s[i])
+			if end == -1 {
+				return false
+			}
+			i += end + 1
+		}
+	}
+	return depth == 0
+}
+
+// Saver of YAML tree to transaction.
+type yamlLoader struct {
+	tx    *Txn
+	buf   []byte
+	forms []catForm
+}
+
+func (l *yamlLoader) load(n *yamlNode, prefix string) error {
+	if len(prefix) > 0 && l.plural(n) {
+		l.buf = catT9n(l.buf[:0], l.forms)
+		return l.set(prefix, n.line)
+	}
+	for _, key := range n.keys {
+		c := n.children[key]
+		path := jsonKey(prefix, key)
+		if !c.leaf {
+			if err := l.load(c, path); err != nil {
+				return err
+			}
+			continue
+		}
+		l.buf = escT9n(l.buf[:0], c.value)
+		if err := l.set(path, c.line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check if node is a map of plural categories and collect its forms.
+func (l *yamlLoader) plural(n *yamlNode) bool {
+	if len(n.keys) == 0 {
+		return false
+	}
+	l.forms = l.forms[:0]
+	// Exact zero form goes first.
+	if c, ok := n.children["zero"]; ok {
+		if !c.leaf {
+			return false
+		}
+		l.forms = append(l.forms, catForm{zero: true, t9n: c.value})
+	}
+	for _, key := range n.keys {
+		c := n.children[key]
+		cat, ok := ParsePluralCategory(key)
+		if !ok || !c.leaf {
+			return false
+		}
+		if cat != PluralZero {
+			l.forms = append(l.forms, catForm{cat: cat, t9n: c.value})
+		}
+	}
+	return true
+}
+
+func (l *yamlLoader) set(key string, line int) error {
+	if err := l.tx.Set(key, string(l.buf)); err != nil {
+		return &ParseError{Line: line, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package i18n
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/koykov/hash/xxhash"
+)
+
+const testYAML = `# Rails translations.
+---
+en:
+  messages:
+    welcome: "Hi, %{name}!"
+    bye: 'It''s time to go' # comment
+    pipe: a|b
+    hash: "#1 choice"
+    plain: Long plain
+      text continues
+    literal: |
+      Line one
+      Line two
+    folded: >-
+      Folded
+      text
+
+      next paragraph
+    unicode: "\u00e9t\u00e9\n"
+    nothing: ~
+  apples:
+    zero: no apples
+    one: one apple
+    other: "%{count} apples"
+  date:
+    day_names:
+    - Sunday
+    - Monday
+    abbr_day_names: [Sun, Mon]
+    formats:
+      default: "%Y-%m-%d"
+  "quoted key": value
+ru:
+  files:
+    one: файл
+    few: файла
+    many: файлов
+    other: файла
+`
+
+func TestYAML(t *testing.T) {
+	db, _ := New(xxhash.Hasher64[string]{})
+	if err := LoadYAML(db, "", strings.NewReader(testYAML)); err != nil {
+		t.Fatal(err)
+	}
+	assertT9n(t, db, "en.messages.welcome", "Hi, %{name}!")
+	assertT9n(t, db, "en.messages.bye", "It's time to go")
+	assertT9n(t, db, "en.messages.pipe", "a|b")
+	assertT9n(t, db, "en.messages.hash", "#1 choice")
+	assertT9n(t, db, "en.messages.plain", "Long plain text continues")
+	assertT9n(t, db, "en.messages.literal", "Line one\nLine two\n")
+	assertT9n(t, db, "en.messages.folded", "Folded text\nnext paragraph")
+	assertT9n(t, db, "en.messages.unicode", "été\n")
+	assertT9n(t, db, "en.messages.nothing", "")
+	assertT9nPlural(t, db, "en.apples", "no apples", 0)
+	assertT9nPlural(t, db, "en.apples", "one apple", 1)
+	assertT9nPlural(t, db, "en.apples", "%{count} apples", 2)
+	assertT9n(t, db, "en.date.day_names", "")
+	assertT9n(t, db, "en.date.abbr_day_names", "")
+	assertT9n(t, db, "en.date.formats.default", "%Y-%m-%d")
+	assertT9n(t, db, "en.quoted key", "value")
+	assertT9nPlural(t, db, "ru.files", "файла", 22)
+	assertT9nPlural(t, db, "ru.files", "файлов", 5)
+
+	var repl PlaceholderReplacer
+	repl.SetKeyFormat("%{", "}").AddKV("count", "5")
+	if s := db.GetPluralWR("en.apples", "", 5, &repl); s != "5 apples" {
+		t.Errorf("replace mismatch, got %q", s)
+	}
+
+	t.Run("locale", func(t *testing.T) {
+		db, _ := New(xxhash.Hasher64[string]{})
+		if err := LoadYAML(db, "de", strings.NewReader("menu:\n  open: Öffnen\n")); err != nil {
+			t.Fatal(err)
+		}
+		assertT9n(t, db, "de.menu.open", "Öffnen")
+	})
+	t.Run("errors", func(t *testing.T) {
+		stages := []struct {
+			src  string
+			line int
+		}{
+			{"en:\n\ta: b\n", 2},
+			{"en:\n  a: \"b\n", 2},
+			{"en:\n  a: *alias\n", 2},
+			{"en:\n  <<: x\n", 2},
+			{"en:\n  just text\n", 2},
+			{"en:\n  a: \"b\" c\n", 2},
+			{"en:\n  a: [b\n", 2},
+			{"en:\n  a: \"\\q\"\n", 2},
+			{"en:\n  a: x\n  b: |x\n", 3},
+		}
+		for _, st := range stages {
+			db, _ := New(xxhash.Hasher64[string]{})
+			err := LoadYAML(db, "", strings.NewReader(st.src))
+			var pe *ParseError
+			if !errors.As(err, &pe) || pe.Line != st.line || !errors.Is(err, ErrBadYAML) {
+				t.Errorf("%q: need line %d ErrBadYAML got %v", st.src, st.line, err)
+			}
+		}
+	})
+}